@@ -5,6 +5,7 @@ import (
 	"log"
 	"net/http"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/gorilla/mux"
@@ -28,12 +29,21 @@ func main() {
 
 	r := mux.NewRouter()
 
-	// CORS
-	c := cors.New(cors.Options{
-		AllowedOrigins:   []string{"*"},
+	// CORS. The REST API is authenticated with cookies, so only the configured frontend
+	// origins may call it with credentials. The MCP endpoint and OAuth metadata use bearer
+	// tokens instead of cookies and are available to any origin without credentials.
+	appCORS := cors.New(cors.Options{
+		AllowedOrigins:   cfg.CORSAllowedOrigins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "PATCH", "OPTIONS"},
 		AllowedHeaders:   []string{"*"},
 		AllowCredentials: true,
+		MaxAge:           cfg.CORSMaxAge,
+	})
+	publicCORS := cors.New(cors.Options{
+		AllowedOrigins: []string{"*"},
+		AllowedMethods: []string{"GET", "POST", "DELETE", "OPTIONS"},
+		AllowedHeaders: []string{"*"},
+		MaxAge:         cfg.CORSMaxAge,
 	})
 
 	// Health & config check
@@ -53,7 +63,7 @@ func main() {
 
 	srv := &http.Server{
 		Addr:              fmt.Sprintf(":%d", cfg.Port),
-		Handler:           c.Handler(r),
+		Handler:           corsHandler(r, appCORS, publicCORS),
 		ReadTimeout:       15 * time.Second,
 		ReadHeaderTimeout: 10 * time.Second,
 		WriteTimeout:      30 * time.Second,
@@ -66,3 +76,17 @@ func main() {
 		log.Fatalf("server error: %v", err)
 	}
 }
+
+// corsHandler applies the public CORS policy to the token-authenticated MCP and OAuth
+// metadata routes and the credentialed policy to every other route.
+func corsHandler(h http.Handler, app, public *cors.Cors) http.Handler {
+	appHandler := app.Handler(h)
+	publicHandler := public.Handler(h)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/mcp") || strings.HasPrefix(r.URL.Path, "/.well-known/") {
+			publicHandler.ServeHTTP(w, r)
+			return
+		}
+		appHandler.ServeHTTP(w, r)
+	})
+}
@@ -3,6 +3,7 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 )
 
 type Config struct {
@@ -11,6 +12,12 @@ type Config struct {
 	StytchProjectSecret string
 	StytchDomain        string
 	PublicBaseURL       string
+
+	// CORSAllowedOrigins lists the frontend origins, including wildcard subdomain patterns
+	// such as https://*.example.com, that may call the REST API with cookies.
+	CORSAllowedOrigins []string
+	// CORSMaxAge is how many seconds browsers may cache preflight responses.
+	CORSMaxAge int
 }
 
 func Load() *Config {
@@ -20,12 +27,18 @@ func Load() *Config {
 			port = v
 		}
 	}
+	corsMaxAge := 600
+	if v, err := strconv.Atoi(os.Getenv("CORS_MAX_AGE_SECONDS")); err == nil && v >= 0 {
+		corsMaxAge = v
+	}
 	return &Config{
 		Port:                port,
 		StytchProjectID:     os.Getenv("STYTCH_PROJECT_ID"),
 		StytchProjectSecret: os.Getenv("STYTCH_PROJECT_SECRET"),
 		StytchDomain:        os.Getenv("STYTCH_DOMAIN"),
 		PublicBaseURL:       getenvDefault("PUBLIC_BASE_URL", "http://localhost:3001"),
+		CORSAllowedOrigins:  splitList(getenvDefault("CORS_ALLOWED_ORIGINS", "http://localhost:3000")),
+		CORSMaxAge:          corsMaxAge,
 	}
 }
 
@@ -35,3 +48,13 @@ func getenvDefault(key, def string) string {
 	}
 	return def
 }
+
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
@@ -1,8 +1,15 @@
 package main
 
 import (
+	"encoding/base64"
 	"log"
+	"net/http"
+	"net/url"
 	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
@@ -10,6 +17,58 @@ import (
 type Config struct {
 	ProjectID     string
 	ProjectSecret string
+
+	// StytchBaseURI overrides the URL of the Stytch API, for example to point the backend
+	// at the fake Stytch server in cmd/stytchfake. It is empty to use the Stytch API.
+	StytchBaseURI string
+
+	// AllowedOrganizationIDs restricts direct login or signup to these Organizations and
+	// those found through a Discovery flow. When it is empty, Stytch's JIT provisioning and
+	// email allowlists decide.
+	AllowedOrganizationIDs []string
+
+	// SessionDurationMinutes is the lifetime of new sessions. Authenticated requests extend
+	// the session by this duration once the session JWT has expired, which happens every
+	// five minutes.
+	SessionDurationMinutes int32
+
+	// Environment is either "development" or "production". In production the server
+	// refuses to start with the default cookie keys.
+	Environment string
+
+	// CookieKeyPairs holds alternating authentication and encryption keys for cookies.
+	// New cookies are written with the first pair, and every pair is tried when reading
+	// cookies so that keys can be rotated without logging users out.
+	CookieKeyPairs [][]byte
+	CookieDomain   string
+	CookieSecure   bool
+	CookieHTTPOnly bool
+	CookieSameSite http.SameSite
+
+	// SessionStore selects where Stytch tokens are kept: "cookie" stores them in the
+	// client's cookies, while "memory" and "file" keep them on the server and the cookies
+	// only hold an opaque ID. SessionStoreFile is the file used by the "file" store.
+	SessionStore     string
+	SessionStoreFile string
+
+	// CORSAllowedOrigins lists the exact origins and wildcard subdomain patterns, such as
+	// "https://*.example.com", that may call the backend with credentials. CORSMaxAge is how
+	// long browsers may cache preflight responses.
+	CORSAllowedOrigins []string
+	CORSMaxAge         time.Duration
+
+	// CSRFTrustedOrigins lists the origins allowed to send state-changing requests.
+	// CSRFRequireToken makes the X-CSRF-Token header mandatory for those requests.
+	CSRFTrustedOrigins []string
+	CSRFRequireToken   bool
+
+	// These are the frontend pages that authentication flows redirect to. return_to URLs
+	// may point to their hosts and to the hosts in RedirectAllowedHosts.
+	RedirectSuccessURL               string
+	RedirectMFARequiredURL           string
+	RedirectErrorURL                 string
+	RedirectOrganizationSelectionURL string
+	RedirectAllowedHosts             []string
 }
 
 // envFilePath is the path to the .env file located in the golang
@@ -18,6 +77,19 @@ type Config struct {
 // with your project variables.
 const envFilepath = ".env"
 
+// These constants are the environments that the server can run in.
+const (
+	environmentDevelopment = "development"
+	environmentProduction  = "production"
+)
+
+// defaultCookieKeyPairs are the keys used to sign and encrypt cookies when COOKIE_KEYS is
+// not set. They are published with this example and must only be used for local development.
+var defaultCookieKeyPairs = [][]byte{
+	[]byte("stytch-example-secret"),
+	[]byte("stytch-example-encryption-key-32"),
+}
+
 func LoadConfig() Config {
 	if _, err := os.Stat(envFilepath); os.IsNotExist(err) {
 		log.Fatalf(".env file does not exist at path '%s'. Please follow the setup instructions to populate it.", envFilepath)
@@ -37,8 +109,190 @@ func LoadConfig() Config {
 		log.Fatal("PROJECT_SECRET environment variable not found in .env file")
 	}
 
+	environment := vars["ENVIRONMENT"]
+	if environment == "" {
+		environment = environmentDevelopment
+	}
+	if environment != environmentDevelopment && environment != environmentProduction {
+		log.Fatalf("ENVIRONMENT must be '%s' or '%s', got '%s'", environmentDevelopment, environmentProduction, environment)
+	}
+
+	cookieKeyPairs := defaultCookieKeyPairs
+	if value := vars["COOKIE_KEYS"]; value != "" {
+		cookieKeyPairs = parseCookieKeys(value)
+	} else if environment == environmentProduction {
+		log.Fatal("COOKIE_KEYS must be set in production, the default cookie keys are publicly known")
+	} else {
+		log.Println("COOKIE_KEYS is not set, using the default cookie keys for local development")
+	}
+
+	cookieSecure := parseBool(vars, "COOKIE_SECURE", true)
+	cookieSameSite := parseSameSite(vars["COOKIE_SAME_SITE"])
+	if cookieSameSite == http.SameSiteNoneMode && !cookieSecure {
+		log.Fatal("COOKIE_SAME_SITE=none requires COOKIE_SECURE=true")
+	}
+
+	sessionStore := vars["SESSION_STORE"]
+	switch sessionStore {
+	case "":
+		sessionStore = "cookie"
+	case "cookie", "memory", "file":
+	default:
+		log.Fatalf("SESSION_STORE must be cookie, memory or file, got '%s'", sessionStore)
+	}
+	sessionStoreFile := vars["SESSION_STORE_FILE"]
+	if sessionStoreFile == "" {
+		sessionStoreFile = "sessions.json"
+	}
+
+	corsAllowedOrigins := splitList(vars["CORS_ALLOWED_ORIGINS"])
+	if len(corsAllowedOrigins) == 0 {
+		corsAllowedOrigins = []string{"http://localhost:3001"}
+	}
+	if slices.Contains(corsAllowedOrigins, "*") {
+		log.Fatal("CORS_ALLOWED_ORIGINS must not contain '*', list the frontend origins instead")
+	}
+
+	// State-changing requests are trusted from the CORS origins unless configured otherwise.
+	csrfTrustedOrigins := splitList(vars["CSRF_TRUSTED_ORIGINS"])
+	if len(csrfTrustedOrigins) == 0 {
+		csrfTrustedOrigins = corsAllowedOrigins
+	}
+
+	redirectAllowedHosts := splitList(vars["REDIRECT_ALLOWED_HOSTS"])
+	for _, host := range redirectAllowedHosts {
+		if strings.ContainsAny(host, "/*") {
+			log.Fatalf("REDIRECT_ALLOWED_HOSTS must only contain hosts such as app.example.com, got '%s'", host)
+		}
+	}
+
 	return Config{
-		ProjectID:     projectID,
-		ProjectSecret: projectSecret,
+		ProjectID:                        projectID,
+		ProjectSecret:                    projectSecret,
+		StytchBaseURI:                    parseURL(vars, "STYTCH_BASE_URI", ""),
+		AllowedOrganizationIDs:           splitList(vars["ALLOWED_ORGANIZATION_IDS"]),
+		SessionDurationMinutes:           parseMinutes(vars, "SESSION_DURATION_MINUTES", 60),
+		Environment:                      environment,
+		CookieKeyPairs:                   cookieKeyPairs,
+		CookieDomain:                     vars["COOKIE_DOMAIN"],
+		CookieSecure:                     cookieSecure,
+		CookieHTTPOnly:                   parseBool(vars, "COOKIE_HTTP_ONLY", true),
+		CookieSameSite:                   cookieSameSite,
+		SessionStore:                     sessionStore,
+		SessionStoreFile:                 sessionStoreFile,
+		CORSAllowedOrigins:               corsAllowedOrigins,
+		CORSMaxAge:                       parseSeconds(vars, "CORS_MAX_AGE_SECONDS", 600),
+		CSRFTrustedOrigins:               csrfTrustedOrigins,
+		CSRFRequireToken:                 parseBool(vars, "CSRF_REQUIRE_TOKEN", false),
+		RedirectSuccessURL:               parseURL(vars, "REDIRECT_SUCCESS_URL", "http://localhost:3001/view-session"),
+		RedirectMFARequiredURL:           parseURL(vars, "REDIRECT_MFA_REQUIRED_URL", "http://localhost:3001/organizations"),
+		RedirectErrorURL:                 parseURL(vars, "REDIRECT_ERROR_URL", "http://localhost:3001/login"),
+		RedirectOrganizationSelectionURL: parseURL(vars, "REDIRECT_ORGANIZATION_SELECTION_URL", "http://localhost:3001/organizations"),
+		RedirectAllowedHosts:             redirectAllowedHosts,
+	}
+}
+
+// splitList parses a comma-separated .env value, ignoring empty entries.
+func splitList(value string) []string {
+	var list []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// parseMinutes reads an optional duration in minutes from the .env file, falling back
+// to the default value when the variable is not set.
+func parseMinutes(vars map[string]string, key string, defaultValue int32) int32 {
+	value, ok := vars[key]
+	if !ok || value == "" {
+		return defaultValue
+	}
+	minutes, err := strconv.ParseInt(value, 10, 32)
+	if err != nil || minutes <= 0 {
+		log.Fatalf("%s must be a positive number of minutes, got '%s'", key, value)
+	}
+	return int32(minutes)
+}
+
+// parseSeconds reads an optional, non-negative duration in seconds from the .env file,
+// falling back to the default value when the variable is not set.
+func parseSeconds(vars map[string]string, key string, defaultValue int) time.Duration {
+	value, ok := vars[key]
+	if !ok || value == "" {
+		return time.Duration(defaultValue) * time.Second
+	}
+	seconds, err := strconv.Atoi(value)
+	if err != nil || seconds < 0 {
+		log.Fatalf("%s must be a non-negative number of seconds, got '%s'", key, value)
+	}
+	return time.Duration(seconds) * time.Second
+}
+
+// parseURL reads an optional absolute http or https URL from the .env file, falling back
+// to the default value when the variable is not set.
+func parseURL(vars map[string]string, key string, defaultValue string) string {
+	value, ok := vars[key]
+	if !ok || value == "" {
+		return defaultValue
+	}
+	u, err := url.Parse(value)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		log.Fatalf("%s must be an absolute http or https URL, got '%s'", key, value)
+	}
+	return value
+}
+
+// parseBool reads an optional boolean from the .env file, falling back to the default
+// value when the variable is not set.
+func parseBool(vars map[string]string, key string, defaultValue bool) bool {
+	value, ok := vars[key]
+	if !ok || value == "" {
+		return defaultValue
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("%s must be true or false, got '%s'", key, value)
+	}
+	return b
+}
+
+// parseSameSite converts the COOKIE_SAME_SITE value into a SameSite cookie attribute,
+// defaulting to Lax.
+func parseSameSite(value string) http.SameSite {
+	switch strings.ToLower(value) {
+	case "", "lax":
+		return http.SameSiteLaxMode
+	case "strict":
+		return http.SameSiteStrictMode
+	case "none":
+		return http.SameSiteNoneMode
+	default:
+		log.Fatalf("COOKIE_SAME_SITE must be lax, strict or none, got '%s'", value)
+		return http.SameSiteDefaultMode
+	}
+}
+
+// parseCookieKeys parses COOKIE_KEYS, a comma-separated list of base64-encoded
+// "authenticationKey:encryptionKey" pairs ordered from newest to oldest.
+func parseCookieKeys(value string) [][]byte {
+	var keyPairs [][]byte
+	for _, pair := range splitList(value) {
+		hashKey, blockKey, ok := strings.Cut(pair, ":")
+		if !ok {
+			log.Fatal("COOKIE_KEYS entries must have the form authenticationKey:encryptionKey")
+		}
+		hash, err := base64.StdEncoding.DecodeString(hashKey)
+		if err != nil || len(hash) < 32 {
+			log.Fatal("COOKIE_KEYS authentication keys must be base64-encoded and at least 32 bytes long")
+		}
+		block, err := base64.StdEncoding.DecodeString(blockKey)
+		if err != nil || (len(block) != 16 && len(block) != 24 && len(block) != 32) {
+			log.Fatal("COOKIE_KEYS encryption keys must be base64-encoded and 16, 24 or 32 bytes long")
+		}
+		keyPairs = append(keyPairs, hash, block)
 	}
+	return keyPairs
 }
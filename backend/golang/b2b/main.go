@@ -5,33 +5,19 @@ import (
 	"errors"
 	"log"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 
 	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
 
 	"backend/golang/b2b/pkg/authservice"
+	"backend/golang/b2b/pkg/cors"
+	"backend/golang/b2b/pkg/router"
 )
 
 var ctx = context.Background()
 
-// corsMiddleware adds CORS headers to allow cross-origin requests
-func corsMiddleware(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		// Allow requests from the frontend origin
-		w.Header().Set("Access-Control-Allow-Origin", "http://localhost:3001")
-		w.Header().Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
-		w.Header().Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
-		w.Header().Set("Access-Control-Allow-Credentials", "true")
-
-		// Handle preflight requests
-		if r.Method == http.MethodOptions {
-			w.WriteHeader(http.StatusOK)
-			return
-		}
-
-		next.ServeHTTP(w, r)
-	})
-}
-
 // loggingMiddleware logs the requested method and path of the incoming request.
 // It ignores browser preflight requests using OPTIONS for the sake of simplicity.
 func loggingMiddleware(next http.Handler) http.Handler {
@@ -47,44 +33,78 @@ func main() {
 	// Load project id, project secret, and other variables from the .env file.
 	conf := LoadConfig()
 
-	// Initialize a Stytch B2B Client.
-	apiClient, err := b2bstytchapi.NewClient(conf.ProjectID, conf.ProjectSecret)
+	// Initialize a Stytch B2B Client, pointed at a different Stytch API if configured.
+	var clientOptions []b2bstytchapi.Option
+	if conf.StytchBaseURI != "" {
+		clientOptions = append(clientOptions, b2bstytchapi.WithBaseURI(conf.StytchBaseURI))
+	}
+	apiClient, err := b2bstytchapi.NewClient(conf.ProjectID, conf.ProjectSecret, clientOptions...)
 	if err != nil {
 		log.Fatalf("Unable to instantiate Stytch B2B client: %v", err)
 	}
 
 	// Instantiate a controller.
-	service := authservice.New(apiClient)
+	service := authservice.New(apiClient, authservice.Options{
+		AllowedOrganizationIDs:           conf.AllowedOrganizationIDs,
+		SessionDurationMinutes:           conf.SessionDurationMinutes,
+		CookieKeyPairs:                   conf.CookieKeyPairs,
+		CookieDomain:                     conf.CookieDomain,
+		CookieSecure:                     conf.CookieSecure,
+		CookieHTTPOnly:                   conf.CookieHTTPOnly,
+		CookieSameSite:                   conf.CookieSameSite,
+		SessionStore:                     conf.SessionStore,
+		SessionStoreFile:                 conf.SessionStoreFile,
+		CSRFTrustedOrigins:               conf.CSRFTrustedOrigins,
+		CSRFRequireToken:                 conf.CSRFRequireToken,
+		RedirectSuccessURL:               conf.RedirectSuccessURL,
+		RedirectMFARequiredURL:           conf.RedirectMFARequiredURL,
+		RedirectErrorURL:                 conf.RedirectErrorURL,
+		RedirectOrganizationSelectionURL: conf.RedirectOrganizationSelectionURL,
+		RedirectAllowedHosts:             conf.RedirectAllowedHosts,
+	})
 
 	// Instantiate a server mux and set up HTTP routing.
 	mux := http.NewServeMux()
 
-	// Handle index.
-	mux.HandleFunc("/", service.IndexHandler)
-
-	// Handle universal authenticate endpoint.
-	mux.HandleFunc("/authenticate", service.AuthenticateHandler)
-
-	// Handle Email Magic Links routes.
-	mux.HandleFunc("/magic-links/invite", service.MagicLinksController.Invite)
-	mux.HandleFunc("/magic-links/login-signup", service.MagicLinksController.LoginOrSignup)
-	mux.HandleFunc("/magic_links/email/discovery/send", service.MagicLinksController.DiscoveryEmailSend)
-
-	// Handle Discovery routes.
-	mux.HandleFunc("/discovery/organizations", service.DiscoveryController.ListOrganizations)
-	mux.HandleFunc("/discovery/organizations/create", service.DiscoveryController.CreateOrganizationViaDiscovery)
-
-	// Handle Sessions routes.
-	mux.HandleFunc("/sessions/exchange", service.SessionsController.Exchange)
-	mux.HandleFunc("/session", service.SessionsController.GetCurrentSession)
-	mux.HandleFunc("/logout", service.SessionsController.Logout)
-
-	// Wrap the mux with CORS and logging middleware
-	handler := loggingMiddleware(corsMiddleware(mux))
+	// Register the route table. Each route only accepts its declared method, and other
+	// methods receive a 405 response.
+	routes := service.Routes()
+	router.Register(mux, routes)
+
+	// Allow the frontend origins to call the backend with cookies. Routes that need a
+	// different policy override it in the route table.
+	corsHandler := cors.New(cors.Policy{
+		AllowedOrigins:   conf.CORSAllowedOrigins,
+		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-CSRF-Token"},
+		AllowCredentials: true,
+		MaxAge:           conf.CORSMaxAge,
+	})
+	router.RegisterCORS(corsHandler, routes)
+
+	// Wrap the mux with CORS, logging, CSRF and session refresh middleware
+	handler := loggingMiddleware(corsHandler.Middleware(service.CSRF.Middleware(service.SessionsController.Refresh(mux))))
+
+	server := &http.Server{Addr: ":3000", Handler: handler}
+
+	// Stop the server on Ctrl+C or SIGTERM. In-flight requests finish first, and the service
+	// then writes its server-side session store before exiting.
+	signalCtx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdown := make(chan struct{})
+	go func() {
+		defer close(shutdown)
+		<-signalCtx.Done()
+		if err := server.Shutdown(ctx); err != nil {
+			log.Printf("Unable to shut down server: %v", err)
+		}
+	}()
 
 	log.Println("Starting server on port 3000...")
-	if err := http.ListenAndServe(":3000", handler); err != nil && !errors.Is(err, http.ErrServerClosed) {
+	if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
 		log.Fatalf("Unable to start server: %v", err)
 	}
 	log.Println("Shutting down server...")
+	<-shutdown
+	service.Close()
 }
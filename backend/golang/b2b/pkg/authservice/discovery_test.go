@@ -0,0 +1,87 @@
+package authservice_test
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/golang/b2b/pkg/authservice"
+)
+
+func TestDiscoveryCreateOrganization(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+
+	resp := b.post(t, "/magic_links/email/discovery/send", map[string]string{"email_address": "ada@example.com"})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /magic_links/email/discovery/send = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	resp = b.authenticate(t, "discovery", b.lastToken(t, "ada@example.com"))
+	if resp.StatusCode != http.StatusSeeOther || resp.Location != organizationSelectionURL {
+		t.Fatalf("discovery authenticate = %d %q, want a redirect to %q", resp.StatusCode, resp.Location, organizationSelectionURL)
+	}
+
+	resp = b.get(t, "/discovery/organizations")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /discovery/organizations = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if resp.Metadata["canCreateOrganization"] != true {
+		t.Errorf("canCreateOrganization = %v, want true", resp.Metadata["canCreateOrganization"])
+	}
+
+	create := map[string]string{"organizationName": "Example", "organizationSlug": "example"}
+	resp = b.post(t, "/discovery/organizations/create", create)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /discovery/organizations/create = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if resp.Metadata["redirectURL"] != successURL {
+		t.Errorf("redirectURL = %v, want %q", resp.Metadata["redirectURL"], successURL)
+	}
+	if resp := b.get(t, "/session"); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /session = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+
+	// The intermediate session was used up by creating the organization.
+	if resp := b.post(t, "/discovery/organizations/create", create); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("creating a second organization = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestDiscoveryExchange(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	first := b.fake.CreateOrganization("First", "first")
+	second := b.fake.CreateOrganization("Second", "second")
+	b.fake.CreateMember(first, "ada@example.com")
+	b.fake.CreateMember(second, "ada@example.com")
+
+	if resp := b.get(t, "/discovery/organizations"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /discovery/organizations without a session = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	b.post(t, "/magic_links/email/discovery/send", map[string]string{"email_address": "ada@example.com"})
+	b.authenticate(t, "discovery", b.lastToken(t, "ada@example.com"))
+	resp := b.post(t, "/sessions/exchange", map[string]string{"organization_id": first})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("exchanging the intermediate session = %d (%s)", resp.StatusCode, resp.Error)
+	}
+
+	// A member session lists the organizations of the member and can be exchanged too.
+	resp = b.get(t, "/discovery/organizations")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /discovery/organizations = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if got := len(resp.StytchResponse.(map[string]any)["discovered_organizations"].([]any)); got != 2 {
+		t.Errorf("discovered %d organizations, want 2", got)
+	}
+	resp = b.post(t, "/sessions/exchange", map[string]string{"organization_id": second})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("exchanging the member session = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	resp = b.get(t, "/session")
+	if got := resp.StytchResponse.(map[string]any)["organization"].(map[string]any)["organization_id"]; got != second {
+		t.Errorf("session organization = %v, want %s", got, second)
+	}
+
+	resp = b.post(t, "/sessions/exchange", map[string]string{"organization_id": "organization-test-unknown"})
+	if resp.StatusCode != http.StatusNotFound {
+		t.Errorf("exchanging into an unknown organization = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,92 @@
+package authservice_test
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/golang/b2b/pkg/authservice"
+)
+
+func TestMagicLinksLoginOrSignup(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	organizationID := b.fake.CreateOrganization("Example", "example")
+
+	tests := []struct {
+		name       string
+		body       map[string]string
+		wantStatus int
+		wantType   string
+	}{
+		{
+			name:       "sends a magic link",
+			body:       map[string]string{"organization_id": organizationID, "email_address": "ada@example.com"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown organization",
+			body:       map[string]string{"organization_id": "organization-test-unknown", "email_address": "ada@example.com"},
+			wantStatus: http.StatusNotFound,
+			wantType:   "organization_not_found",
+		},
+		{
+			name:       "invalid email address",
+			body:       map[string]string{"organization_id": organizationID, "email_address": "ada"},
+			wantStatus: http.StatusBadRequest,
+			wantType:   "invalid_request",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := b.post(t, "/magic-links/login-signup", tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", resp.StatusCode, tt.wantStatus, resp.Error)
+			}
+			if tt.wantType != "" && (resp.ErrorDetails == nil || resp.ErrorDetails.Type != tt.wantType) {
+				t.Errorf("errorDetails = %+v, want type %q", resp.ErrorDetails, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestMagicLinksAuthenticate(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	organizationID := b.fake.CreateOrganization("Example", "example")
+
+	if resp := b.get(t, "/session"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GET /session before login = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	b.login(t, organizationID, "ada@example.com")
+
+	// Magic links can only be used once.
+	resp := b.authenticate(t, "multi_tenant_magic_links", b.lastToken(t, "ada@example.com"))
+	if resp.StatusCode == http.StatusOK {
+		t.Errorf("reusing a magic link = %d, want an error", resp.StatusCode)
+	}
+}
+
+func TestMagicLinksInvite(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	organizationID := b.fake.CreateOrganization("Example", "example")
+	b.fake.CreateMember(organizationID, "admin@example.com", "stytch_admin")
+	b.fake.CreateMember(organizationID, "member@example.com")
+
+	invite := map[string]string{"email_address": "new@example.com"}
+	if resp := b.post(t, "/magic-links/invite", invite); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("invite without a session = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+
+	b.login(t, organizationID, "member@example.com")
+	if resp := b.post(t, "/magic-links/invite", invite); resp.StatusCode != http.StatusForbidden {
+		t.Errorf("invite without permission = %d, want %d", resp.StatusCode, http.StatusForbidden)
+	}
+
+	b.login(t, organizationID, "admin@example.com")
+	resp := b.post(t, "/magic-links/invite", invite)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("invite = %d, want %d (%s)", resp.StatusCode, http.StatusOK, resp.Error)
+	}
+	messages := b.fake.Messages()
+	if last := messages[len(messages)-1]; last.EmailAddress != "new@example.com" || last.OrganizationID != organizationID {
+		t.Errorf("last message = %+v, want an invite to new@example.com", last)
+	}
+}
@@ -0,0 +1,38 @@
+package authservice_test
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/golang/b2b/pkg/authservice"
+)
+
+// The fake Stytch API does not implement the Organizations Members endpoints, so this test
+// covers the RBAC checks in front of the members routes.
+func TestMembersRequirePermission(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	organizationID := b.fake.CreateOrganization("Example", "example")
+	memberID := b.fake.CreateMember(organizationID, "member@example.com")
+
+	tests := []struct {
+		path string
+		body any
+	}{
+		{"/members/search", map[string]any{}},
+		{"/members/delete", map[string]string{"member_id": memberID}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			anonymous := newBrowser(t, b)
+			if resp := anonymous.post(t, tt.path, tt.body); resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("without a session = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+			}
+
+			member := newBrowser(t, b)
+			member.login(t, organizationID, "member@example.com")
+			if resp := member.post(t, tt.path, tt.body); resp.StatusCode != http.StatusForbidden {
+				t.Errorf("without permission = %d, want %d", resp.StatusCode, http.StatusForbidden)
+			}
+		})
+	}
+}
@@ -0,0 +1,50 @@
+package authservice_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"backend/golang/b2b/pkg/authservice"
+)
+
+func TestOAuthDiscoveryAuthenticate(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	organizationID := b.fake.CreateOrganization("Example", "example")
+	b.fake.CreateMember(organizationID, "ada@example.com")
+
+	resp := b.authenticate(t, "discovery_oauth", b.fake.DiscoveryOAuthToken("ada@example.com"))
+	if resp.StatusCode != http.StatusSeeOther || resp.Location != organizationSelectionURL {
+		t.Fatalf("OAuth discovery authenticate = %d %q, want a redirect to %q", resp.StatusCode, resp.Location, organizationSelectionURL)
+	}
+	resp = b.post(t, "/sessions/exchange", map[string]string{"organization_id": organizationID})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /sessions/exchange = %d (%s)", resp.StatusCode, resp.Error)
+	}
+}
+
+func TestOAuthDiscoveryAuthenticateError(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+
+	resp := b.authenticate(t, "discovery_oauth", "discovery-oauth-token-test-unknown")
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d", resp.StatusCode, http.StatusSeeOther)
+	}
+	location, err := url.Parse(resp.Location)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) = %v", resp.Location, err)
+	}
+	if got := location.Query().Get("error_type"); got != "oauth_token_not_found" {
+		t.Errorf("error_type = %q, want %q", got, "oauth_token_not_found")
+	}
+	if resp := b.get(t, "/discovery/organizations"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /discovery/organizations = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+func TestAuthenticateUnsupportedTokenType(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	if resp := b.authenticate(t, "sso", "token"); resp.StatusCode != http.StatusNotImplemented {
+		t.Errorf("status = %d, want %d", resp.StatusCode, http.StatusNotImplemented)
+	}
+}
@@ -0,0 +1,86 @@
+package authservice
+
+import (
+	"net/http"
+	"slices"
+
+	"backend/golang/b2b/pkg/cors"
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/openapi"
+	"backend/golang/b2b/pkg/router"
+)
+
+// apiTitle is the title of the OpenAPI document that describes the route table.
+const apiTitle = "Stytch B2B Example Backend"
+
+// Routes returns the route table of the backend. Routes that require a session or an RBAC
+// permission are wrapped with the matching RBAC middleware.
+func (s *Service) Routes() []router.Route {
+	routes := []router.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/",
+			Summary: "Check that the backend is running",
+			Handler: s.IndexHandler,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/authenticate",
+			Summary: "Complete a magic link or OAuth flow from a Stytch redirect",
+			Handler: s.AuthenticateHandler,
+			Query:   []string{"stytch_token_type", "token"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/return-to",
+			Summary: "Store the URL to return to after logging in",
+			Handler: s.Redirects.ReturnToHandler,
+			Request: internal.ReturnToRequest{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/csrf-token",
+			Summary: "Get a CSRF token for state-changing requests",
+			Handler: s.CSRF.TokenHandler,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/openapi.json",
+			Summary: "Get the OpenAPI description of the backend",
+			Handler: s.OpenAPIHandler,
+			// The description is public, so any origin may read it, but without cookies.
+			CORS: &cors.Policy{
+				AllowedOrigins: []string{"*"},
+				AllowedMethods: []string{"GET"},
+			},
+		},
+	}
+	routes = slices.Concat(
+		routes,
+		s.MagicLinksController.Routes(),
+		s.DiscoveryController.Routes(),
+		s.MembersController.Routes(),
+		s.SessionsController.Routes(),
+	)
+
+	for i, route := range routes {
+		switch {
+		case route.Permission != nil:
+			routes[i].Authenticated = true
+			routes[i].Handler = s.RBAC.Require(route.Permission.ResourceID, route.Permission.Action, route.Handler)
+		case route.Authenticated:
+			routes[i].Handler = s.RBAC.Authenticate(route.Handler)
+		}
+	}
+	return routes
+}
+
+// OpenAPI returns the OpenAPI document generated from the route table.
+func (s *Service) OpenAPI() openapi.Document {
+	return openapi.Build(apiTitle, s.Routes())
+}
+
+// OpenAPIHandler serves the OpenAPI document generated from the route table.
+func (s *Service) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	openapi.Handler(s.OpenAPI())(w, r)
+}
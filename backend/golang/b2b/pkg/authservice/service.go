@@ -8,7 +8,9 @@ import (
 	"backend/golang/b2b/pkg/discovery"
 	"backend/golang/b2b/pkg/internal"
 	"backend/golang/b2b/pkg/magiclinks"
+	"backend/golang/b2b/pkg/members"
 	"backend/golang/b2b/pkg/oauth"
+	"backend/golang/b2b/pkg/rbac"
 	"backend/golang/b2b/pkg/session"
 )
 
@@ -20,20 +22,94 @@ type Service struct {
 	SessionsController   *session.Controller
 	DiscoveryController  *discovery.Controller
 	OAuthController      *oauth.Controller
+	MembersController    *members.Controller
+
+	// RBAC authenticates sessions and enforces the permissions required by routes.
+	RBAC *rbac.Middleware
+
+	// CSRF protects cookie-authenticated, state-changing requests from cross-site
+	// request forgery.
+	CSRF *internal.CSRFProtection
+
+	// Redirects decides which frontend page the browser is sent to once a flow completes.
+	Redirects *internal.Redirector
 }
 
-func New(stytchAPI *b2bstytchapi.API) *Service {
-	cookieStore := internal.NewCookieStore()
+// Options holds the configuration of the Service that is loaded from the .env file.
+type Options struct {
+	// AllowedOrganizationIDs restricts direct login or signup to these Organizations and
+	// those found through a Discovery flow. When it is empty, Stytch's JIT provisioning and
+	// email allowlists decide.
+	AllowedOrganizationIDs []string
+	// SessionDurationMinutes is the lifetime of new sessions and the extension applied to
+	// existing sessions whenever their session JWT has expired.
+	SessionDurationMinutes int32
+
+	// CookieKeyPairs holds alternating authentication and encryption keys for cookies,
+	// ordered from newest to oldest.
+	CookieKeyPairs [][]byte
+	CookieDomain   string
+	CookieSecure   bool
+	CookieHTTPOnly bool
+	CookieSameSite http.SameSite
+
+	// SessionStore selects whether Stytch tokens are kept in cookies ("cookie") or on the
+	// server ("memory" or "file"), and SessionStoreFile is the file used by "file".
+	SessionStore     string
+	SessionStoreFile string
+
+	// CSRFTrustedOrigins lists the origins that may send state-changing requests, and
+	// CSRFRequireToken makes the X-CSRF-Token header mandatory for them.
+	CSRFTrustedOrigins []string
+	CSRFRequireToken   bool
+
+	// These are the frontend pages that authentication flows redirect to, and
+	// RedirectAllowedHosts lists additional hosts that return_to URLs may point to.
+	RedirectSuccessURL               string
+	RedirectMFARequiredURL           string
+	RedirectErrorURL                 string
+	RedirectOrganizationSelectionURL string
+	RedirectAllowedHosts             []string
+}
+
+func New(stytchAPI *b2bstytchapi.API, opts Options) *Service {
+	cookieStore := internal.NewCookieStore(internal.CookieOptions{
+		KeyPairs: opts.CookieKeyPairs,
+		Domain:   opts.CookieDomain,
+		Secure:   opts.CookieSecure,
+		HttpOnly: opts.CookieHTTPOnly,
+		SameSite: opts.CookieSameSite,
+
+		Store:         opts.SessionStore,
+		StoreFilePath: opts.SessionStoreFile,
+	})
+	redirector := internal.NewRedirector(cookieStore, internal.RedirectOptions{
+		SuccessURL:               opts.RedirectSuccessURL,
+		MFARequiredURL:           opts.RedirectMFARequiredURL,
+		ErrorURL:                 opts.RedirectErrorURL,
+		OrganizationSelectionURL: opts.RedirectOrganizationSelectionURL,
+		AllowedHosts:             opts.RedirectAllowedHosts,
+	})
 	return &Service{
 		stytchAPI:            stytchAPI,
 		cookieStore:          cookieStore,
-		MagicLinksController: magiclinks.NewController(stytchAPI, cookieStore),
-		SessionsController:   session.NewController(stytchAPI, cookieStore),
-		DiscoveryController:  discovery.NewController(stytchAPI, cookieStore),
-		OAuthController:      oauth.NewController(stytchAPI, cookieStore),
+		MagicLinksController: magiclinks.NewController(magiclinks.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, opts.AllowedOrganizationIDs, redirector),
+		SessionsController:   session.NewController(session.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, redirector),
+		DiscoveryController:  discovery.NewController(discovery.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, redirector),
+		OAuthController:      oauth.NewController(oauth.NewAPI(stytchAPI), cookieStore, redirector),
+		MembersController:    members.NewController(members.NewAPI(stytchAPI), cookieStore),
+		RBAC:                 rbac.NewMiddleware(rbac.NewAPI(stytchAPI), cookieStore),
+		CSRF:                 internal.NewCSRFProtection(cookieStore, opts.CSRFTrustedOrigins, opts.CSRFRequireToken),
+		Redirects:            redirector,
 	}
 }
 
+// Close stops the background work of the Service, such as writing the server-side session
+// store to its file.
+func (s *Service) Close() {
+	s.cookieStore.Close()
+}
+
 func (s *Service) IndexHandler(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	_, _ = w.Write([]byte("OK"))
@@ -49,6 +125,8 @@ const (
 	tokenTypeDiscoveryOAuth = "discovery_oauth"
 )
 
+const authenticateMethod = "Authenticate"
+
 func (s *Service) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the token type from the query parameter.
 	tokenType := r.URL.Query().Get("stytch_token_type")
@@ -64,6 +142,10 @@ func (s *Service) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
 	case tokenTypeDiscoveryOAuth:
 		s.OAuthController.DiscoveryOAuthAuthenticate(w, r)
 	default:
-		http.Error(w, "Authentication for this token type has not been implemented", http.StatusNotImplemented)
+		internal.SendError(w, authenticateMethod, internal.NewError(
+			http.StatusNotImplemented,
+			internal.ErrorTypeNotImplemented,
+			"Authentication for this token type has not been implemented",
+		))
 	}
 }
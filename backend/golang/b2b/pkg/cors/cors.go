@@ -0,0 +1,129 @@
+// Package cors implements the CORS policy that lets the frontend call this backend from
+// another origin while sending cookies.
+package cors
+
+import (
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy describes which cross-origin requests are allowed.
+type Policy struct {
+	// AllowedOrigins lists exact origins such as "http://localhost:3001" and wildcard
+	// subdomain patterns such as "https://*.example.com". The wildcard matches one or more
+	// subdomain labels, but never the bare domain. "*" allows every origin, and is only
+	// supported without AllowCredentials, because allowing every origin to send credentialed
+	// requests is unsafe.
+	AllowedOrigins []string
+	AllowedMethods []string
+	AllowedHeaders []string
+
+	// AllowCredentials allows the browser to send cookies with cross-origin requests.
+	AllowCredentials bool
+
+	// MaxAge is how long browsers may cache the result of a preflight request.
+	MaxAge time.Duration
+}
+
+// Handler applies a default Policy to every request, unless a route overrides it.
+type Handler struct {
+	policy Policy
+	routes *http.ServeMux
+}
+
+func New(policy Policy) *Handler {
+	checkPolicy(policy)
+	return &Handler{policy, http.NewServeMux()}
+}
+
+// Route overrides the default Policy for requests that match pattern, a ServeMux path
+// pattern such as "/oauth/start/{provider}". The override applies to every method of the
+// path, including preflight requests. Like ServeMux, Route panics if pattern is already
+// routed.
+func (h *Handler) Route(pattern string, policy Policy) {
+	checkPolicy(policy)
+	h.routes.Handle(pattern, routePolicy(policy))
+}
+
+// routePolicy is registered on the routes mux so that matching a request returns its Policy.
+type routePolicy Policy
+
+func (routePolicy) ServeHTTP(http.ResponseWriter, *http.Request) {}
+
+// policyFor returns the Policy that applies to the request.
+func (h *Handler) policyFor(r *http.Request) Policy {
+	if handler, pattern := h.routes.Handler(r); pattern != "" {
+		if policy, ok := handler.(routePolicy); ok {
+			return Policy(policy)
+		}
+	}
+	return h.policy
+}
+
+func checkPolicy(policy Policy) {
+	if policy.AllowCredentials && slices.Contains(policy.AllowedOrigins, "*") {
+		panic("cors: a policy that allows credentials must list its origins instead of '*'")
+	}
+}
+
+// Middleware adds CORS headers to responses. The Origin of a request is only reflected in
+// Access-Control-Allow-Origin if the Policy allows it; other origins receive no CORS headers
+// and are blocked by the browser. Preflight requests are answered directly.
+func (h *Handler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		policy := h.policyFor(r)
+
+		// Responses differ by Origin, so caches must not share them between origins.
+		w.Header().Add("Vary", "Origin")
+
+		origin := r.Header.Get("Origin")
+		allowed := origin != "" && MatchOrigin(policy.AllowedOrigins, origin)
+		if allowed {
+			w.Header().Set("Access-Control-Allow-Origin", origin)
+			if policy.AllowCredentials {
+				w.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+		}
+
+		// Handle preflight requests
+		if r.Method == http.MethodOptions {
+			if allowed {
+				w.Header().Set("Access-Control-Allow-Methods", strings.Join(policy.AllowedMethods, ", "))
+				w.Header().Set("Access-Control-Allow-Headers", strings.Join(policy.AllowedHeaders, ", "))
+				if policy.MaxAge > 0 {
+					w.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+				}
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// MatchOrigin reports whether origin matches one of the exact origins or wildcard
+// subdomain patterns, or whether the patterns allow every origin with "*".
+func MatchOrigin(patterns []string, origin string) bool {
+	if slices.Contains(patterns, origin) || slices.Contains(patterns, "*") {
+		return true
+	}
+	for _, pattern := range patterns {
+		prefix, suffix, ok := strings.Cut(pattern, "*")
+		if !ok || !strings.HasSuffix(prefix, "://") || !strings.HasPrefix(suffix, ".") {
+			continue
+		}
+		if !strings.HasPrefix(origin, prefix) || !strings.HasSuffix(origin, suffix) {
+			continue
+		}
+		subdomain := origin[len(prefix) : len(origin)-len(suffix)]
+		if len(origin) > len(prefix)+len(suffix) && !strings.ContainsAny(subdomain, "/:@") &&
+			!strings.HasPrefix(subdomain, ".") && !strings.HasSuffix(subdomain, ".") {
+			return true
+		}
+	}
+	return false
+}
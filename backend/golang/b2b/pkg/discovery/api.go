@@ -0,0 +1,28 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/organizations"
+)
+
+// API holds the clients of the Stytch B2B API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	DiscoveryOrganizations DiscoveryOrganizationsClient
+}
+
+// NewAPI returns the clients of the Stytch B2B API that the controller uses.
+func NewAPI(client *b2bstytchapi.API) API {
+	return API{
+		DiscoveryOrganizations: client.Discovery.Organizations,
+	}
+}
+
+// DiscoveryOrganizationsClient lists and creates the Organizations of an end user.
+// It is implemented by b2bstytchapi.API.Discovery.Organizations.
+type DiscoveryOrganizationsClient interface {
+	List(ctx context.Context, body *organizations.ListParams) (*organizations.ListResponse, error)
+	Create(ctx context.Context, body *organizations.CreateParams) (*organizations.CreateResponse, error)
+}
@@ -1,16 +1,16 @@
 package discovery
 
-import (
-	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
-
-	"backend/golang/b2b/pkg/internal"
-)
+import "backend/golang/b2b/pkg/internal"
 
 type Controller struct {
-	api         *b2bstytchapi.API
-	cookieStore *internal.CookieStore
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
 }
 
-func NewController(api *b2bstytchapi.API, cookieStore *internal.CookieStore) *Controller {
-	return &Controller{api, cookieStore}
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, redirector *internal.Redirector) *Controller {
+	return &Controller{api, cookieStore, sessionDurationMinutes, redirector}
 }
@@ -1,8 +1,6 @@
 package discovery
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
 
 	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/organizations"
@@ -26,8 +24,7 @@ func (c *Controller) ListOrganizations(w http.ResponseWriter, r *http.Request) {
 
 		token, ok = c.cookieStore.GetSession(r)
 		if !ok {
-			log.Println("No session or intermediate session cookie found")
-			w.WriteHeader(http.StatusBadRequest)
+			internal.SendError(w, listOrganizationsMethod, internal.ErrNoSessionOrIntermediateSession)
 			return
 		}
 	}
@@ -38,12 +35,9 @@ func (c *Controller) ListOrganizations(w http.ResponseWriter, r *http.Request) {
 	} else {
 		req.SessionToken = token
 	}
-	resp, err := c.api.Discovery.Organizations.List(r.Context(), req)
+	resp, err := c.api.DiscoveryOrganizations.List(r.Context(), req)
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: listOrganizationsMethod,
-			Error:  err.Error(),
-		})
+		internal.SendError(w, listOrganizationsMethod, err)
 		return
 	}
 
@@ -75,41 +69,42 @@ resp, err := c.api.Discovery.Organizations.List(
 }
 
 type createOrganizationViaDiscoveryRequest struct {
-	OrganizationName string `json:"organizationName"`
+	OrganizationName string `json:"organizationName" validate:"omitempty,max=128"`
+	OrganizationSlug string `json:"organizationSlug" validate:"omitempty,slug,min=2,max=128"`
 }
 
 // CreateOrganizationViaDiscovery allows the end user to create a new Organization
 // with an intermediate session token and authenticate into it.
 func (c *Controller) CreateOrganizationViaDiscovery(w http.ResponseWriter, r *http.Request) {
 	var req createOrganizationViaDiscoveryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, createOrganizationViaDiscoveryMethod, err)
 		return
 	}
 
 	ist, ok := c.cookieStore.GetIntermediateSession(r)
 	if !ok {
-		log.Println("No intermediate session token found")
-		w.WriteHeader(http.StatusBadRequest)
+		internal.SendError(w, createOrganizationViaDiscoveryMethod, internal.ErrNoIntermediateSession)
 		return
 	}
 
-	resp, err := c.api.Discovery.Organizations.Create(r.Context(), &organizations.CreateParams{
+	resp, err := c.api.DiscoveryOrganizations.Create(r.Context(), &organizations.CreateParams{
 		IntermediateSessionToken: ist,
 		OrganizationName:         req.OrganizationName,
+		OrganizationSlug:         req.OrganizationSlug,
+		SessionDurationMinutes:   c.sessionDurationMinutes,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: createOrganizationViaDiscoveryMethod,
-			Error:  err.Error(),
-		})
+		internal.SendError(w, createOrganizationViaDiscoveryMethod, err)
 		return
 	}
 
-	c.cookieStore.StoreSession(w, r, resp.SessionToken)
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
 	c.cookieStore.ClearIntermediateSession(w, r)
 
+	// Members that still have to complete MFA receive an intermediate session instead.
+	redirectURL := c.redirector.Next(w, r, resp.SessionToken != "")
+
 	internal.SendResponse(w, &internal.Response{
 		Method:      createOrganizationViaDiscoveryMethod,
 		APIResponse: resp,
@@ -118,7 +113,12 @@ func (c *Controller) CreateOrganizationViaDiscovery(w http.ResponseWriter, r *ht
 	&organizations.CreateParams{
 		IntermediateSessionToken: ist,
 		OrganizationName:         req.OrganizationName,
+		OrganizationSlug:         req.OrganizationSlug,
+		SessionDurationMinutes:   c.sessionDurationMinutes,
 	},
 )`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
 	})
 }
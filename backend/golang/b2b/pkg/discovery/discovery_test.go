@@ -0,0 +1,172 @@
+package discovery
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/organizations"
+
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+func TestListOrganizations(t *testing.T) {
+	tests := []struct {
+		name          string
+		cookie        func(*http.Request, *internal.CookieStore)
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantParams    organizations.ListParams
+	}{
+		{
+			name:       "intermediate session",
+			cookie:     testutil.AddIntermediateSession,
+			wantStatus: http.StatusOK,
+			wantParams: organizations.ListParams{IntermediateSessionToken: "intermediate-session-token"},
+		},
+		{
+			name:       "session",
+			cookie:     testutil.AddSession,
+			wantStatus: http.StatusOK,
+			wantParams: organizations.ListParams{SessionToken: "session-token"},
+		},
+		{
+			name:          "API error",
+			cookie:        testutil.AddSession,
+			apiErr:        testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "session_not_found",
+			wantParams:    organizations.ListParams{SessionToken: "session-token"},
+		},
+		{
+			name:          "no cookie",
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeDiscoveryOrganizations{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(API{DiscoveryOrganizations: f}, env.CookieStore, 60, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/discovery/organizations", nil)
+			if tt.cookie != nil {
+				tt.cookie(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.ListOrganizations(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.Method != listOrganizationsMethod {
+				t.Errorf("method = %q, want %q", resp.Method, listOrganizationsMethod)
+			}
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			var got organizations.ListParams
+			if f.listParams != nil {
+				got = *f.listParams
+			}
+			if got != tt.wantParams {
+				t.Errorf("List() params = %+v, want %+v", got, tt.wantParams)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			wantCanCreate := tt.wantParams.IntermediateSessionToken != ""
+			if got := resp.Metadata["canCreateOrganization"]; got != wantCanCreate {
+				t.Errorf("canCreateOrganization = %v, want %t", got, wantCanCreate)
+			}
+		})
+	}
+}
+
+func TestCreateOrganizationViaDiscovery(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		noCookie        bool
+		resp            organizations.CreateResponse
+		apiErr          error
+		wantStatus      int
+		wantErrorType   string
+		wantRedirectURL string
+	}{
+		{
+			name:            "success",
+			body:            `{"organizationName": "Acme", "organizationSlug": "acme"}`,
+			resp:            organizations.CreateResponse{SessionToken: "session-token", SessionJWT: "session-jwt"},
+			wantStatus:      http.StatusOK,
+			wantRedirectURL: testutil.SuccessURL,
+		},
+		{
+			name:            "MFA required",
+			body:            `{"organizationName": "Acme"}`,
+			resp:            organizations.CreateResponse{IntermediateSessionToken: "intermediate-session-token"},
+			wantStatus:      http.StatusOK,
+			wantRedirectURL: testutil.MFARequiredURL,
+		},
+		{
+			name:          "API error",
+			body:          `{"organizationSlug": "acme"}`,
+			apiErr:        testutil.StytchError(http.StatusConflict, "organization_slug_already_used"),
+			wantStatus:    http.StatusConflict,
+			wantErrorType: "organization_slug_already_used",
+		},
+		{
+			name:          "no cookie",
+			body:          `{"organizationName": "Acme"}`,
+			noCookie:      true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			body:          `{"organizationSlug": "Not A Slug"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeDiscoveryOrganizations{Fake: testutil.Fake{Err: tt.apiErr}, createResponse: tt.resp}
+			env := testutil.NewEnv(t)
+			c := NewController(API{DiscoveryOrganizations: f}, env.CookieStore, 60, env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/discovery/create", tt.body)
+			if !tt.noCookie {
+				testutil.AddIntermediateSession(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.CreateOrganizationViaDiscovery(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if f.createParams.IntermediateSessionToken != "intermediate-session-token" || f.createParams.SessionDurationMinutes != 60 {
+				t.Errorf("Create() params = %+v, want the intermediate session and a 60 minute session", f.createParams)
+			}
+			if got := resp.Metadata["redirectURL"]; got != tt.wantRedirectURL {
+				t.Errorf("redirectURL = %v, want %q", got, tt.wantRedirectURL)
+			}
+			cookies := testutil.ResponseCookies(rec)
+			if _, ok := env.CookieStore.GetIntermediateSession(cookies); ok {
+				t.Errorf("intermediate session was not cleared")
+			}
+			if _, ok := env.CookieStore.GetSession(cookies); ok != (tt.resp.SessionToken != "") {
+				t.Errorf("session stored = %t, want %t", ok, tt.resp.SessionToken != "")
+			}
+		})
+	}
+}
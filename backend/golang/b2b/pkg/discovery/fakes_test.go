@@ -0,0 +1,38 @@
+package discovery
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/organizations"
+
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+// fakeDiscoveryOrganizations implements DiscoveryOrganizationsClient in memory. Each call
+// records its parameters and fails with Err when it is set.
+type fakeDiscoveryOrganizations struct {
+	testutil.Fake
+
+	listParams   *organizations.ListParams
+	createParams *organizations.CreateParams
+
+	// createResponse is returned by Create.
+	createResponse organizations.CreateResponse
+}
+
+func (f *fakeDiscoveryOrganizations) List(_ context.Context, body *organizations.ListParams) (*organizations.ListResponse, error) {
+	f.listParams = body
+	if err := f.Record("List " + body.IntermediateSessionToken + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &organizations.ListResponse{EmailAddress: "member@example.com", StatusCode: 200}, nil
+}
+
+func (f *fakeDiscoveryOrganizations) Create(_ context.Context, body *organizations.CreateParams) (*organizations.CreateResponse, error) {
+	f.createParams = body
+	if err := f.Record("Create " + body.IntermediateSessionToken); err != nil {
+		return nil, err
+	}
+	resp := f.createResponse
+	return &resp, nil
+}
@@ -0,0 +1,26 @@
+package discovery
+
+import (
+	"net/http"
+
+	"backend/golang/b2b/pkg/router"
+)
+
+// Routes returns the Discovery routes.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/discovery/organizations",
+			Summary: "List the organizations the end user can log in to",
+			Handler: c.ListOrganizations,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/discovery/organizations/create",
+			Summary: "Create an organization and log in to it",
+			Handler: c.CreateOrganizationViaDiscovery,
+			Request: createOrganizationViaDiscoveryRequest{},
+		},
+	}
+}
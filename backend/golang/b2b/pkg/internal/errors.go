@@ -0,0 +1,134 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net/http"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/stytcherror"
+)
+
+// Error describes a failed request. Every failure, whether it is rejected by the backend
+// itself or by the Stytch API, is returned to the client with this shape in the
+// errorDetails field of the Response.
+type Error struct {
+	// StatusCode is the HTTP status code of the response.
+	StatusCode int `json:"statusCode"`
+	// Type is a machine-readable error type. Errors returned by the Stytch API keep their
+	// Stytch error_type, such as "session_not_found".
+	Type string `json:"type"`
+	// Message is a human-readable description of the error.
+	Message string `json:"message"`
+	// RequestID is the ID of the failed Stytch API request, if any.
+	RequestID string `json:"requestId,omitempty"`
+	// Fields maps the JSON names of invalid request fields to what is wrong with them.
+	Fields map[string]string `json:"fields,omitempty"`
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// These constants are the error types of failures detected by the backend itself.
+const (
+	ErrorTypeInvalidRequest   = "invalid_request"
+	ErrorTypeUnauthorized     = "unauthorized"
+	ErrorTypeForbidden        = "forbidden"
+	ErrorTypeNotFound         = "not_found"
+	ErrorTypeMethodNotAllowed = "method_not_allowed"
+	ErrorTypeNotImplemented   = "not_implemented"
+	ErrorTypeUpstream         = "upstream_error"
+	ErrorTypeTimeout          = "timeout"
+	ErrorTypeInternal         = "internal_error"
+)
+
+func NewError(statusCode int, errorType string, message string) *Error {
+	return &Error{StatusCode: statusCode, Type: errorType, Message: message}
+}
+
+// These errors are returned when a request does not carry the cookie that a route needs.
+var (
+	ErrNoSession             = NewError(http.StatusUnauthorized, ErrorTypeUnauthorized, "No session token found")
+	ErrNoIntermediateSession = NewError(http.StatusUnauthorized, ErrorTypeUnauthorized, "No intermediate session token found")
+
+	ErrNoSessionOrIntermediateSession = NewError(http.StatusUnauthorized, ErrorTypeUnauthorized, "No session or intermediate session token found")
+)
+
+// AsError converts err into an *Error. Errors returned by the Stytch API keep their status
+// code, error type and request ID, except that Stytch server errors become 502 Bad Gateway.
+// Any other error becomes a 500 Internal Server Error.
+func AsError(err error) *Error {
+	var e *Error
+	if errors.As(err, &e) {
+		return e
+	}
+
+	var stytchErr stytcherror.Error
+	if errors.As(err, &stytchErr) {
+		return fromStytchError(stytchErr)
+	}
+	var stytchErrPtr *stytcherror.Error
+	if errors.As(err, &stytchErrPtr) && stytchErrPtr != nil {
+		return fromStytchError(*stytchErrPtr)
+	}
+
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewError(http.StatusGatewayTimeout, ErrorTypeTimeout, err.Error())
+	}
+	return NewError(http.StatusInternalServerError, ErrorTypeInternal, err.Error())
+}
+
+func fromStytchError(err stytcherror.Error) *Error {
+	statusCode := err.StatusCode
+	if statusCode < http.StatusBadRequest || statusCode >= http.StatusInternalServerError {
+		statusCode = http.StatusBadGateway
+	}
+	errorType := string(err.ErrorType)
+	if errorType == "" {
+		errorType = ErrorTypeUpstream
+	}
+	message := err.Error()
+	if message == "" {
+		message = http.StatusText(statusCode)
+	}
+	return &Error{
+		StatusCode: statusCode,
+		Type:       errorType,
+		Message:    message,
+		RequestID:  err.RequestID,
+	}
+}
+
+// SendError writes err with the status code and error details derived by AsError.
+func SendError(w http.ResponseWriter, method string, err error) {
+	e := AsError(err)
+	if e.StatusCode >= http.StatusInternalServerError {
+		log.Printf("%s failed: %v", method, err)
+	}
+	SendErrorResponse(w, e.StatusCode, &Response{
+		Method:       method,
+		Error:        e.Message,
+		ErrorDetails: e,
+	})
+}
+
+// errorType returns the error type used for responses with the given status code.
+func errorType(statusCode int) string {
+	switch statusCode {
+	case http.StatusBadRequest:
+		return ErrorTypeInvalidRequest
+	case http.StatusUnauthorized:
+		return ErrorTypeUnauthorized
+	case http.StatusForbidden:
+		return ErrorTypeForbidden
+	case http.StatusNotFound:
+		return ErrorTypeNotFound
+	case http.StatusMethodNotAllowed:
+		return ErrorTypeMethodNotAllowed
+	case http.StatusNotImplemented:
+		return ErrorTypeNotImplemented
+	default:
+		return ErrorTypeInternal
+	}
+}
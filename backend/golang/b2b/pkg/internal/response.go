@@ -17,11 +17,33 @@ type Response struct {
 
 	// Error is populated with an error message if one occurred during the request.
 	Error string `json:"error,omitempty"`
+	// ErrorDetails describes the error, if one occurred, in more detail.
+	ErrorDetails *Error `json:"errorDetails,omitempty"`
 }
 
 func SendResponse(w http.ResponseWriter, response *Response) {
 	if response.Error != "" {
-		w.WriteHeader(http.StatusInternalServerError)
+		SendErrorResponse(w, http.StatusInternalServerError, response)
+		return
+	}
+
+	b, err := json.MarshalIndent(response, "", "\t")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(b)
+}
+
+// SendErrorResponse writes the response with the given HTTP status code. It is used when
+// a request is rejected by the backend itself, for example because the caller is not
+// permitted to perform the action, so the client receives the same error shape as for
+// failed Stytch calls.
+func SendErrorResponse(w http.ResponseWriter, statusCode int, response *Response) {
+	if response.ErrorDetails == nil {
+		response.ErrorDetails = NewError(statusCode, errorType(statusCode), response.Error)
 	}
 
 	b, err := json.MarshalIndent(response, "", "\t")
@@ -31,5 +53,6 @@ func SendResponse(w http.ResponseWriter, response *Response) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
 	_, _ = w.Write(b)
 }
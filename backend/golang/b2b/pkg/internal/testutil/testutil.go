@@ -0,0 +1,157 @@
+// Package testutil helps the unit tests of the controllers build controllers and requests
+// that carry the backend's cookies, and fake Stytch clients that fail the way the Stytch API
+// does.
+package testutil
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/stytcherror"
+
+	"backend/golang/b2b/pkg/internal"
+)
+
+// These are the frontend pages of the Redirector returned by NewRedirector.
+const (
+	SuccessURL               = "http://localhost:3001/view-session"
+	MFARequiredURL           = "http://localhost:3001/mfa"
+	ErrorURL                 = "http://localhost:3001/login"
+	OrganizationSelectionURL = "http://localhost:3001/organizations"
+)
+
+// NewCookieStore returns a CookieStore that keeps session values in cookies signed and
+// encrypted with fixed test keys.
+func NewCookieStore(t testing.TB) *internal.CookieStore {
+	t.Helper()
+	cs := internal.NewCookieStore(internal.CookieOptions{
+		KeyPairs: [][]byte{[]byte(strings.Repeat("a", 32)), []byte(strings.Repeat("b", 32))},
+	})
+	t.Cleanup(cs.Close)
+	return cs
+}
+
+// NewRedirector returns a Redirector that sends the browser to the test pages above.
+func NewRedirector(cs *internal.CookieStore) *internal.Redirector {
+	return internal.NewRedirector(cs, internal.RedirectOptions{
+		SuccessURL:               SuccessURL,
+		MFARequiredURL:           MFARequiredURL,
+		ErrorURL:                 ErrorURL,
+		OrganizationSelectionURL: OrganizationSelectionURL,
+	})
+}
+
+// Env holds what the controllers and middleware take besides their Stytch clients.
+type Env struct {
+	CookieStore *internal.CookieStore
+	Redirector  *internal.Redirector
+}
+
+// NewEnv returns a CookieStore from NewCookieStore and a Redirector from NewRedirector that
+// uses it.
+func NewEnv(t testing.TB) Env {
+	t.Helper()
+	cs := NewCookieStore(t)
+	return Env{CookieStore: cs, Redirector: NewRedirector(cs)}
+}
+
+// Fake is embedded in the in-memory fakes of the Stytch clients. It records the calls made
+// to them and fails the calls with Err when it is set.
+type Fake struct {
+	Err error
+	// Calls describes each call with its method name and the IDs, email addresses or
+	// tokens that identify what it acted on, such as "Get user-1".
+	Calls []string
+}
+
+// Record records a call and returns Err.
+func (f *Fake) Record(call string) error {
+	f.Calls = append(f.Calls, call)
+	return f.Err
+}
+
+// NewRequest returns a request with a JSON body. An empty body sends no body.
+func NewRequest(method, target, body string) *http.Request {
+	r := httptest.NewRequest(method, target, strings.NewReader(body))
+	r.Header.Set("Content-Type", "application/json")
+	return r
+}
+
+// SetCookies adds the cookies that store writes to the request, as if a browser had
+// received them in an earlier response. For example:
+//
+//	testutil.SetCookies(r, func(w http.ResponseWriter, r *http.Request) {
+//		cs.StoreSession(w, r, "session-token", "session-jwt")
+//	})
+func SetCookies(r *http.Request, store func(w http.ResponseWriter, r *http.Request)) {
+	rec := httptest.NewRecorder()
+	store(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	for _, c := range rec.Result().Cookies() {
+		r.AddCookie(c)
+	}
+}
+
+// AddSession adds a session cookie holding "session-token" and "session-jwt" to the request.
+func AddSession(r *http.Request, cs *internal.CookieStore) {
+	SetCookies(r, func(w http.ResponseWriter, r *http.Request) {
+		cs.StoreSession(w, r, "session-token", "session-jwt")
+	})
+}
+
+// AddIntermediateSession adds an intermediate session cookie holding
+// "intermediate-session-token" to the request.
+func AddIntermediateSession(r *http.Request, cs *internal.CookieStore) {
+	SetCookies(r, func(w http.ResponseWriter, r *http.Request) {
+		cs.StoreIntermediateSession(w, r, "intermediate-session-token")
+	})
+}
+
+// ResponseCookies returns a request carrying the cookies of a recorded response, so that
+// tests can read what a handler stored with the CookieStore.
+func ResponseCookies(rec *httptest.ResponseRecorder) *http.Request {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	for _, c := range rec.Result().Cookies() {
+		r.AddCookie(c)
+	}
+	return r
+}
+
+// StytchError returns an error like those of the Stytch API.
+func StytchError(statusCode int, errorType string) error {
+	return stytcherror.Error{
+		StatusCode:   statusCode,
+		RequestID:    "request-id-test",
+		ErrorType:    stytcherror.Type(errorType),
+		ErrorMessage: stytcherror.Message("Stytch returned " + errorType),
+	}
+}
+
+// Response is the decoded JSON envelope of a handler response.
+type Response struct {
+	Method         string          `json:"method"`
+	StytchResponse json.RawMessage `json:"stytchResponse"`
+	Metadata       map[string]any  `json:"metadata"`
+	Error          string          `json:"error"`
+	ErrorDetails   *internal.Error `json:"errorDetails"`
+}
+
+// ErrorType returns the type of the error in the response, or "" if it succeeded.
+func (r Response) ErrorType() string {
+	if r.ErrorDetails == nil {
+		return ""
+	}
+	return r.ErrorDetails.Type
+}
+
+// Decode decodes the JSON envelope of a recorded response.
+func Decode(t testing.TB, rec *httptest.ResponseRecorder) Response {
+	t.Helper()
+	var resp Response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		t.Fatalf("decoding response %q: %v", rec.Body.String(), err)
+	}
+	return resp
+}
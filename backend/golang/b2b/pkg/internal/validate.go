@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxBodyBytes is the largest request body that DecodeJSON accepts.
+const MaxBodyBytes = 64 << 10
+
+var (
+	// slugPattern matches the characters that Stytch allows in Organization slugs.
+	slugPattern = regexp.MustCompile(`^[a-z0-9._~-]+$`)
+	// phonePattern matches phone numbers in E.164 format, which Stytch requires.
+	phonePattern = regexp.MustCompile(`^\+[1-9][0-9]{1,14}$`)
+)
+
+// DecodeJSON strictly decodes the JSON body of the request into v and validates it. Bodies
+// larger than MaxBodyBytes, unknown fields and trailing data are rejected, and an empty body
+// is decoded as an empty object. Fields of v are validated according to their `validate`
+// struct tags, a comma-separated list of rules:
+//
+//   - required: the field must not be empty
+//   - omitempty: skip the remaining rules if the field is empty
+//   - email: the field must be an email address
+//   - url: the field must be an absolute http or https URL
+//   - slug: the field may only contain lowercase letters, digits, '-', '.', '_' and '~'
+//   - phone: the field must be a phone number in E.164 format, such as +12025550162
+//   - oneof=A B C: the field must be one of the space-separated values
+//   - min=N, max=N: bounds on the length of strings and slices, or the value of integers
+//
+// Failures result in a 400 *Error, or a 413 *Error for oversized bodies, with field-level
+// messages that can be passed to SendError.
+func DecodeJSON(r *http.Request, v any) error {
+	dec := json.NewDecoder(http.MaxBytesReader(nil, r.Body, MaxBodyBytes))
+	dec.DisallowUnknownFields()
+	err := dec.Decode(v)
+
+	var maxBytesErr *http.MaxBytesError
+	var typeErr *json.UnmarshalTypeError
+	switch {
+	case errors.Is(err, io.EOF):
+		// An empty body is treated as an empty object, so that the required fields are
+		// reported by validation.
+	case errors.As(err, &maxBytesErr):
+		return NewError(http.StatusRequestEntityTooLarge, ErrorTypeInvalidRequest,
+			fmt.Sprintf("Request body must not be larger than %d bytes", maxBytesErr.Limit))
+	case errors.As(err, &typeErr):
+		return NewFieldError(typeErr.Field, "must be of type "+typeErr.Type.String())
+	case err != nil:
+		return NewError(http.StatusBadRequest, ErrorTypeInvalidRequest, fmt.Sprintf("Request body is not valid: %v", err))
+	case !errors.Is(dec.Decode(&struct{}{}), io.EOF):
+		return NewError(http.StatusBadRequest, ErrorTypeInvalidRequest, "Request body must contain a single JSON object")
+	}
+	return Validate(v)
+}
+
+// Validate checks the fields of the struct that v points to against their `validate` tags,
+// as described by DecodeJSON. Nested structs, pointers to structs and slices of structs are
+// validated too, and their failures are reported with dotted field names such as
+// "attributes.ip_address". Malformed or unknown rules are returned as an error rather than a
+// validation failure; CheckRules finds them before any request is made.
+func Validate(v any) error {
+	fields := map[string]string{}
+	if err := validateValue(reflect.ValueOf(v), "", fields); err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		return &Error{
+			StatusCode: http.StatusBadRequest,
+			Type:       ErrorTypeInvalidRequest,
+			Message:    "Request validation failed",
+			Fields:     fields,
+		}
+	}
+	return nil
+}
+
+// validateValue validates the structs that v holds directly, through a pointer or as the
+// elements of a slice, recording failures in fields under their JSON names.
+func validateValue(v reflect.Value, name string, fields map[string]string) error {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			return validateValue(v.Elem(), name, fields)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", name, i), fields); err != nil {
+				return err
+			}
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			f := v.Type().Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			fieldName, _, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name != "" {
+				fieldName = name + "." + fieldName
+			}
+			msg, err := checkField(v.Field(i), f.Tag.Get("validate"))
+			if err != nil {
+				return fmt.Errorf("%s.%s: %w", v.Type().Name(), f.Name, err)
+			}
+			if msg != "" {
+				fields[fieldName] = msg
+			} else if err := validateValue(v.Field(i), fieldName, fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// checkField returns a message describing the first rule of the tag that the value
+// violates, or an empty string if it satisfies every rule.
+func checkField(v reflect.Value, tag string) (string, error) {
+	if tag == "" {
+		return "", nil
+	}
+	rules := strings.Split(tag, ",")
+	if v.IsZero() {
+		if slices.Contains(rules, "required") {
+			return "is required", nil
+		}
+		if slices.Contains(rules, "omitempty") {
+			return "", nil
+		}
+	}
+	if v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return "", nil
+		}
+		v = v.Elem()
+	}
+	for _, rule := range rules {
+		if msg, err := checkRule(v, rule); msg != "" || err != nil {
+			return msg, err
+		}
+	}
+	return "", nil
+}
+
+// checkRule returns a message if the value violates the rule, or an error if the rule is
+// malformed or unknown.
+func checkRule(v reflect.Value, rule string) (string, error) {
+	name, arg, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required", "omitempty":
+	case "email":
+		addr, err := mail.ParseAddress(v.String())
+		if err != nil || addr.Address != v.String() {
+			return "must be a valid email address", nil
+		}
+	case "url":
+		u, err := url.Parse(v.String())
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			return "must be an absolute http or https URL", nil
+		}
+	case "slug":
+		if !slugPattern.MatchString(v.String()) {
+			return "may only contain lowercase letters, digits, '-', '.', '_' and '~'", nil
+		}
+	case "phone":
+		if !phonePattern.MatchString(v.String()) {
+			return "must be a phone number in E.164 format, such as +12025550162", nil
+		}
+	case "oneof":
+		values := strings.Fields(arg)
+		if len(values) == 0 {
+			return "", fmt.Errorf("validate rule %q lists no values", rule)
+		}
+		if !slices.Contains(values, fmt.Sprint(v.Interface())) {
+			return "must be one of " + strings.Join(values, ", "), nil
+		}
+	case "min", "max":
+		limit, err := strconv.Atoi(arg)
+		if err != nil {
+			return "", fmt.Errorf("validate rule %q needs an integer limit", rule)
+		}
+		return checkBound(v, name, limit), nil
+	default:
+		return "", fmt.Errorf("unknown validate rule %q", rule)
+	}
+	return "", nil
+}
+
+// checkBound compares the length of strings and slices, or the value of integers, against
+// a min or max limit.
+func checkBound(v reflect.Value, bound string, limit int) string {
+	var size float64
+	unit := ""
+	switch v.Kind() {
+	case reflect.String:
+		size, unit = float64(utf8.RuneCountInString(v.String())), " characters"
+	case reflect.Slice, reflect.Map:
+		size, unit = float64(v.Len()), " items"
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		size = float64(v.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		size = float64(v.Uint())
+	default:
+		return ""
+	}
+
+	verb := "be"
+	if unit != "" {
+		verb = "have"
+	}
+	if bound == "min" && size < float64(limit) {
+		return fmt.Sprintf("must %s at least %d%s", verb, limit, unit)
+	}
+	if bound == "max" && size > float64(limit) {
+		return fmt.Sprintf("must %s at most %d%s", verb, limit, unit)
+	}
+	return ""
+}
+
+// CheckRules reports malformed or unknown rules in the `validate` tags of the type of v and
+// of the structs nested in it. router.Register calls it for the request type of every
+// route, so that mistakes in the tags stop the server from starting.
+func CheckRules(v any) error {
+	if v == nil {
+		return nil
+	}
+	return checkType(reflect.TypeOf(v))
+}
+
+func checkType(t reflect.Type) error {
+	for t.Kind() == reflect.Pointer || t.Kind() == reflect.Slice {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return nil
+	}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		if tag := f.Tag.Get("validate"); tag != "" {
+			// Checking the rules against a zero value only fails for malformed rules.
+			zero := reflect.Zero(f.Type)
+			if f.Type.Kind() == reflect.Pointer {
+				zero = reflect.Zero(f.Type.Elem())
+			}
+			for _, rule := range strings.Split(tag, ",") {
+				if _, err := checkRule(zero, rule); err != nil {
+					return fmt.Errorf("%s.%s: %w", t.Name(), f.Name, err)
+				}
+			}
+		}
+		if err := checkType(f.Type); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// CheckAllowedURL returns a field-level error unless the URL is empty or one of the allowed
+// URLs. It complements the declarative rules for URLs that must match configuration.
+func CheckAllowedURL(field string, value string, allowed []string) error {
+	if value == "" || slices.Contains(allowed, value) {
+		return nil
+	}
+	return NewFieldError(field, "is not an allowed URL")
+}
+
+// NewFieldError returns a 400 *Error for a single invalid field of the request body, in the
+// same form as the errors of DecodeJSON.
+func NewFieldError(field string, message string) *Error {
+	return &Error{
+		StatusCode: http.StatusBadRequest,
+		Type:       ErrorTypeInvalidRequest,
+		Message:    "Request validation failed",
+		Fields:     map[string]string{field: message},
+	}
+}
@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"errors"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type testAttributes struct {
+	IPAddress string `json:"ip_address" validate:"max=4"`
+}
+
+type testRequest struct {
+	EmailAddress string           `json:"email_address" validate:"required,email"`
+	Locale       *string          `json:"locale" validate:"omitempty,oneof=en fr"`
+	Limit        uint32           `json:"limit" validate:"max=10"`
+	Attributes   *testAttributes  `json:"attributes"`
+	Devices      []testAttributes `json:"devices"`
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		req    testRequest
+		fields map[string]string
+	}{
+		{
+			name: "valid",
+			req:  testRequest{EmailAddress: "user@example.com", Locale: ptr("fr"), Attributes: &testAttributes{IPAddress: "::1"}},
+		},
+		{
+			name:   "missing required field",
+			req:    testRequest{},
+			fields: map[string]string{"email_address": "is required"},
+		},
+		{
+			name: "invalid fields",
+			req:  testRequest{EmailAddress: "User <user@example.com>", Locale: ptr("de"), Limit: 11},
+			fields: map[string]string{
+				"email_address": "must be a valid email address",
+				"locale":        "must be one of en, fr",
+				"limit":         "must be at most 10",
+			},
+		},
+		{
+			name: "nested struct",
+			req: testRequest{
+				EmailAddress: "user@example.com",
+				Attributes:   &testAttributes{IPAddress: "127.0.0.1"},
+				Devices:      []testAttributes{{IPAddress: "::1"}, {IPAddress: "10.0.0.1"}},
+			},
+			fields: map[string]string{
+				"attributes.ip_address": "must have at most 4 characters",
+				"devices[1].ip_address": "must have at most 4 characters",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := Validate(&tt.req)
+			if tt.fields == nil {
+				if err != nil {
+					t.Fatalf("Validate() = %v, want nil", err)
+				}
+				return
+			}
+			var e *Error
+			if !errors.As(err, &e) {
+				t.Fatalf("Validate() = %v, want *Error", err)
+			}
+			if !maps.Equal(e.Fields, tt.fields) {
+				t.Errorf("Validate() fields = %v, want %v", e.Fields, tt.fields)
+			}
+		})
+	}
+}
+
+func TestDecodeJSON(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantFields map[string]string
+	}{
+		{name: "valid", body: `{"email_address": "user@example.com"}`},
+		{name: "empty body", body: "", wantStatus: http.StatusBadRequest, wantFields: map[string]string{"email_address": "is required"}},
+		{name: "unknown field", body: `{"email_address": "user@example.com", "admin": true}`, wantStatus: http.StatusBadRequest},
+		{name: "wrong type", body: `{"email_address": 1}`, wantStatus: http.StatusBadRequest, wantFields: map[string]string{"email_address": "must be of type string"}},
+		{name: "trailing data", body: `{"email_address": "user@example.com"} {}`, wantStatus: http.StatusBadRequest},
+		{name: "too large", body: `{"email_address": "` + strings.Repeat("a", MaxBodyBytes) + `"}`, wantStatus: http.StatusRequestEntityTooLarge},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var req testRequest
+			err := DecodeJSON(httptest.NewRequest(http.MethodPost, "/", strings.NewReader(tt.body)), &req)
+			if tt.wantStatus == 0 {
+				if err != nil {
+					t.Fatalf("DecodeJSON() = %v, want nil", err)
+				}
+				return
+			}
+			var e *Error
+			if !errors.As(err, &e) || e.StatusCode != tt.wantStatus {
+				t.Fatalf("DecodeJSON() = %v, want an *Error with status %d", err, tt.wantStatus)
+			}
+			if tt.wantFields != nil && !maps.Equal(e.Fields, tt.wantFields) {
+				t.Errorf("DecodeJSON() fields = %v, want %v", e.Fields, tt.wantFields)
+			}
+		})
+	}
+}
+
+func TestValidateInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		v    any
+	}{
+		{"unknown rule", &struct {
+			Name string `json:"name" validate:"requird"`
+		}{}},
+		{"malformed limit", &struct {
+			Name string `json:"name" validate:"max=ten"`
+		}{}},
+		{"oneof without values", &struct {
+			Name string `json:"name" validate:"oneof="`
+		}{}},
+		{"nested struct", &struct {
+			Inner struct {
+				Name string `json:"name" validate:"min="`
+			} `json:"inner"`
+		}{}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := CheckRules(tt.v); err == nil {
+				t.Error("CheckRules() = nil, want error")
+			}
+			var e *Error
+			if err := Validate(tt.v); err == nil || errors.As(err, &e) {
+				t.Errorf("Validate() = %v, want a non-validation error", err)
+			}
+		})
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
@@ -0,0 +1,64 @@
+package magiclinks
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks"
+	mldiscovery "github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/discovery"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/email/discovery"
+)
+
+// API holds the clients of the Stytch B2B API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	MagicLinks               MagicLinksClient
+	MagicLinksEmail          MagicLinksEmailClient
+	MagicLinksEmailDiscovery MagicLinksEmailDiscoveryClient
+	MagicLinksDiscovery      MagicLinksDiscoveryClient
+	DiscoveryOrganizations   DiscoveryOrganizationsClient
+}
+
+// NewAPI returns the clients of the Stytch B2B API that the controller uses.
+func NewAPI(client *b2bstytchapi.API) API {
+	return API{
+		MagicLinks:               client.MagicLinks,
+		MagicLinksEmail:          client.MagicLinks.Email,
+		MagicLinksEmailDiscovery: client.MagicLinks.Email.Discovery,
+		MagicLinksDiscovery:      client.MagicLinks.Discovery,
+		DiscoveryOrganizations:   client.Discovery.Organizations,
+	}
+}
+
+// MagicLinksClient authenticates email magic links.
+// It is implemented by b2bstytchapi.API.MagicLinks.
+type MagicLinksClient interface {
+	Authenticate(ctx context.Context, body *magiclinks.AuthenticateParams) (*magiclinks.AuthenticateResponse, error)
+}
+
+// MagicLinksEmailClient sends email magic links to the members of an Organization.
+// It is implemented by b2bstytchapi.API.MagicLinks.Email.
+type MagicLinksEmailClient interface {
+	Invite(ctx context.Context, body *email.InviteParams, methodOptions ...*email.InviteRequestOptions) (*email.InviteResponse, error)
+	LoginOrSignup(ctx context.Context, body *email.LoginOrSignupParams) (*email.LoginOrSignupResponse, error)
+}
+
+// MagicLinksEmailDiscoveryClient sends discovery email magic links.
+// It is implemented by b2bstytchapi.API.MagicLinks.Email.Discovery.
+type MagicLinksEmailDiscoveryClient interface {
+	Send(ctx context.Context, body *discovery.SendParams) (*discovery.SendResponse, error)
+}
+
+// MagicLinksDiscoveryClient authenticates discovery email magic links.
+// It is implemented by b2bstytchapi.API.MagicLinks.Discovery.
+type MagicLinksDiscoveryClient interface {
+	Authenticate(ctx context.Context, body *mldiscovery.AuthenticateParams) (*mldiscovery.AuthenticateResponse, error)
+}
+
+// DiscoveryOrganizationsClient lists the Organizations that an end user can log in to.
+// It is implemented by b2bstytchapi.API.Discovery.Organizations.
+type DiscoveryOrganizationsClient interface {
+	List(ctx context.Context, body *organizations.ListParams) (*organizations.ListResponse, error)
+}
@@ -1,16 +1,20 @@
 package magiclinks
 
-import (
-	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
-
-	"backend/golang/b2b/pkg/internal"
-)
+import "backend/golang/b2b/pkg/internal"
 
 type Controller struct {
-	api         *b2bstytchapi.API
-	cookieStore *internal.CookieStore
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// allowedOrganizationIDs lists the Organizations that LoginOrSignup accepts without
+	// a Discovery flow. When it is empty, Stytch decides which Organizations accept the user.
+	allowedOrganizationIDs []string
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
 }
 
-func NewController(api *b2bstytchapi.API, cookieStore *internal.CookieStore) *Controller {
-	return &Controller{api, cookieStore}
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, allowedOrganizationIDs []string, redirector *internal.Redirector) *Controller {
+	return &Controller{api, cookieStore, sessionDurationMinutes, allowedOrganizationIDs, redirector}
 }
@@ -1,59 +1,88 @@
 package magiclinks
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+	"slices"
 
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/organizations"
 	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks"
 	mldiscovery "github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/discovery"
 	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/email"
 	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/email/discovery"
+	"github.com/stytchauth/stytch-go/v16/stytch/methodoptions"
 
 	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/rbac"
 )
 
 const inviteMethod = "MagicLinks.Email.Invite"
 
 type inviteRequest struct {
 	OrganizationID string `json:"organization_id"`
-	Name           string `json:"name"`
-	EmailAddress   string `json:"email_address"`
+	Name           string `json:"name" validate:"max=128"`
+	EmailAddress   string `json:"email_address" validate:"required,email"`
 }
 
 // Invite wraps Stytch's Email Magic Links Invite endpoint and sends an email to the specified
 // email address that can be used to create an account and authenticate into the specified Stytch
 // Organization.
+//
+// The caller must be authenticated by the RBAC middleware and can only invite new Members into
+// the Organization of their own session.
 func (c *Controller) Invite(w http.ResponseWriter, r *http.Request) {
 	var req inviteRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, inviteMethod, err)
 		return
 	}
 
-	resp, err := c.api.MagicLinks.Email.Invite(r.Context(), &email.InviteParams{
-		OrganizationID: req.OrganizationID,
-		Name:           req.Name,
-		EmailAddress:   req.EmailAddress,
-	})
-	if err != nil {
-		internal.SendResponse(w, &internal.Response{
+	st, session, ok := rbac.FromContext(r.Context())
+	if !ok {
+		internal.SendErrorResponse(w, http.StatusUnauthorized, &internal.Response{
 			Method: inviteMethod,
-			Error:  err.Error(),
+			Error:  "No authenticated session found",
+		})
+		return
+	}
+
+	// Default to the caller's Organization and reject invites into any other one.
+	if req.OrganizationID == "" {
+		req.OrganizationID = session.Organization.OrganizationID
+	}
+	if req.OrganizationID != session.Organization.OrganizationID {
+		internal.SendErrorResponse(w, http.StatusForbidden, &internal.Response{
+			Method: inviteMethod,
+			Error:  "Members can only invite new members into their own organization",
 		})
 		return
 	}
 
+	resp, err := c.api.MagicLinksEmail.Invite(r.Context(), &email.InviteParams{
+		OrganizationID:    req.OrganizationID,
+		Name:              req.Name,
+		EmailAddress:      req.EmailAddress,
+		InvitedByMemberID: session.Member.MemberID,
+	}, &email.InviteRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, inviteMethod, err)
+		return
+	}
+
 	internal.SendResponse(w, &internal.Response{
 		Method:      inviteMethod,
 		APIResponse: resp,
 		CodeSnippet: `resp, err := c.api.MagicLinks.Email.Invite(
 	r.Context(),
 	&email.InviteParams{
-		OrganizationID: req.OrganizationID,
-		Name:           req.Name,
-		EmailAddress:   req.EmailAddress,
+		OrganizationID:    req.OrganizationID,
+		Name:              req.Name,
+		EmailAddress:      req.EmailAddress,
+		InvitedByMemberID: session.Member.MemberID,
+	},
+	&email.InviteRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
 	},
 )`,
 	})
@@ -62,27 +91,44 @@ func (c *Controller) Invite(w http.ResponseWriter, r *http.Request) {
 const loginOrSignupMethod = "MagicLinks.Email.LoginOrSignup"
 
 type loginOrSignupRequest struct {
-	OrganizationID string `json:"organization_id"`
-	EmailAddress   string `json:"email_address"`
+	OrganizationID string `json:"organization_id" validate:"required"`
+	EmailAddress   string `json:"email_address" validate:"required,email"`
 }
 
+// LoginOrSignup wraps Stytch's Email Magic Links LoginOrSignup endpoint and sends an email to
+// the specified email address that can be used to log in or sign up to the specified Stytch
+// Organization.
+//
+// Stytch only sends the email when the address may join the Organization through JIT
+// provisioning or is already a member. When ALLOWED_ORGANIZATION_IDS is configured, the
+// Organization must additionally be listed there or have been surfaced to the user by a
+// Discovery flow.
 func (c *Controller) LoginOrSignup(w http.ResponseWriter, r *http.Request) {
 	var req loginOrSignupRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, loginOrSignupMethod, err)
 		return
 	}
 
-	resp, err := c.api.MagicLinks.Email.LoginOrSignup(r.Context(), &email.LoginOrSignupParams{
+	allowed, err := c.isOrganizationAllowed(r, req.OrganizationID)
+	if err != nil {
+		internal.SendError(w, loginOrSignupMethod, err)
+		return
+	}
+	if !allowed {
+		internal.SendErrorResponse(w, http.StatusForbidden, &internal.Response{
+			Method: loginOrSignupMethod,
+			Error:  "Organization is not available for login or signup",
+		})
+		return
+	}
+
+	resp, err := c.api.MagicLinksEmail.LoginOrSignup(r.Context(), &email.LoginOrSignupParams{
 		OrganizationID: req.OrganizationID,
 		EmailAddress:   req.EmailAddress,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: loginOrSignupMethod,
-			Error:  err.Error(),
-		})
+		internal.SendError(w, loginOrSignupMethod, err)
 		return
 	}
 
@@ -99,10 +145,43 @@ func (c *Controller) LoginOrSignup(w http.ResponseWriter, r *http.Request) {
 	})
 }
 
+// isOrganizationAllowed reports whether the end user may log in or sign up to the Organization.
+// Without an allowlist every Organization is accepted and Stytch enforces its JIT provisioning
+// and email allowlist. Otherwise the Organization must be in the allowlist or one of the
+// discovered Organizations of the intermediate or full session on the request.
+func (c *Controller) isOrganizationAllowed(r *http.Request, organizationID string) (bool, error) {
+	if len(c.allowedOrganizationIDs) == 0 || slices.Contains(c.allowedOrganizationIDs, organizationID) {
+		return true, nil
+	}
+
+	params := &organizations.ListParams{}
+	if ist, ok := c.cookieStore.GetIntermediateSession(r); ok {
+		params.IntermediateSessionToken = ist
+	} else if st, ok := c.cookieStore.GetSession(r); ok {
+		params.SessionToken = st
+	} else {
+		return false, nil
+	}
+
+	resp, err := c.api.DiscoveryOrganizations.List(r.Context(), params)
+	if err != nil {
+		return false, err
+	}
+	for _, discovered := range resp.DiscoveredOrganizations {
+		if discovered.Organization != nil && discovered.Organization.OrganizationID == organizationID {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
 const discoveryMethod = "MagicLinks.Discovery.Send"
 
 type discoveryRequest struct {
-	EmailAddress string `json:"email_address"`
+	EmailAddress string `json:"email_address" validate:"required,email"`
+
+	// ReturnTo is an optional frontend URL that the user returns to after logging in.
+	ReturnTo string `json:"return_to" validate:"max=2048"`
 }
 
 // DiscoveryEmailSend uses Email Magic Links to begin a Discovery flow that, when
@@ -110,23 +189,27 @@ type discoveryRequest struct {
 // to surface Organizations the user is eligible to authenticate into.
 func (c *Controller) DiscoveryEmailSend(w http.ResponseWriter, r *http.Request) {
 	var req discoveryRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, discoveryMethod, err)
 		return
 	}
 
-	resp, err := c.api.MagicLinks.Email.Discovery.Send(r.Context(), &discovery.SendParams{
-		EmailAddress: req.EmailAddress,
-	})
-	if err != nil {
-		internal.SendResponse(w, &internal.Response{
+	if err := c.redirector.StoreReturnTo(w, r, req.ReturnTo); err != nil {
+		internal.SendErrorResponse(w, http.StatusBadRequest, &internal.Response{
 			Method: discoveryMethod,
 			Error:  err.Error(),
 		})
 		return
 	}
 
+	resp, err := c.api.MagicLinksEmailDiscovery.Send(r.Context(), &discovery.SendParams{
+		EmailAddress: req.EmailAddress,
+	})
+	if err != nil {
+		internal.SendError(w, discoveryMethod, err)
+		return
+	}
+
 	internal.SendResponse(w, &internal.Response{
 		Method:      discoveryMethod,
 		APIResponse: resp,
@@ -149,13 +232,11 @@ func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the token from the query parameter.
 	token := r.URL.Query().Get("token")
 	resp, err := c.api.MagicLinks.Authenticate(r.Context(), &magiclinks.AuthenticateParams{
-		MagicLinksToken: token,
+		MagicLinksToken:        token,
+		SessionDurationMinutes: c.sessionDurationMinutes,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: authenticateMethod,
-			Error:  err.Error(),
-		})
+		internal.SendError(w, authenticateMethod, err)
 		return
 	}
 
@@ -163,7 +244,7 @@ func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
 	// auth requirements of the organization that the user is attempting to
 	// authenticate into.
 	if resp.SessionToken != "" {
-		c.cookieStore.StoreSession(w, r, resp.SessionToken)
+		c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
 	}
 	if resp.IntermediateSessionToken != "" {
 		c.cookieStore.StoreIntermediateSession(w, r, resp.IntermediateSessionToken)
@@ -175,7 +256,8 @@ func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
 		CodeSnippet: `resp, err := c.api.MagicLinks.Authenticate(
 	r.Context(),
 	&magiclinks.AuthenticateParams{
-		MagicLinksToken: token,
+		MagicLinksToken:        token,
+		SessionDurationMinutes: c.sessionDurationMinutes,
 	},
 )`,
 	})
@@ -189,14 +271,11 @@ const discoveryAuthenticateMethod = "MagicLinks.Discovery.Authenticate"
 func (c *Controller) DiscoveryAuthenticate(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the token from the query parameter.
 	token := r.URL.Query().Get("token")
-	resp, err := c.api.MagicLinks.Discovery.Authenticate(r.Context(), &mldiscovery.AuthenticateParams{
+	resp, err := c.api.MagicLinksDiscovery.Authenticate(r.Context(), &mldiscovery.AuthenticateParams{
 		DiscoveryMagicLinksToken: token,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: discoveryAuthenticateMethod,
-			Error:  err.Error(),
-		})
+		c.redirector.Error(w, r, discoveryAuthenticateMethod, err)
 		return
 	}
 
@@ -206,6 +285,6 @@ func (c *Controller) DiscoveryAuthenticate(w http.ResponseWriter, r *http.Reques
 	// This helps prevent account enumeration attacks.
 	c.cookieStore.StoreIntermediateSession(w, r, resp.IntermediateSessionToken)
 
-	// Redirect to the organizations page after successful authentication
-	http.Redirect(w, r, "http://localhost:3001/organizations", http.StatusSeeOther)
+	// Redirect to the organization selection page after successful authentication
+	c.redirector.OrganizationSelection(w, r)
 }
@@ -0,0 +1,364 @@
+package magiclinks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks"
+
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/internal/testutil"
+	"backend/golang/b2b/pkg/stytchtest"
+)
+
+func TestInvite(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			body:       `{"email_address": "new@example.com", "name": "New Member"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "own organization",
+			body:       `{"organization_id": "org-1", "email_address": "new@example.com"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "other organization",
+			body:          `{"organization_id": "org-2", "email_address": "new@example.com"}`,
+			wantStatus:    http.StatusForbidden,
+			wantErrorType: internal.ErrorTypeForbidden,
+		},
+		{
+			name:          "API error",
+			body:          `{"email_address": "new@example.com"}`,
+			apiErr:        testutil.StytchError(http.StatusBadRequest, "duplicate_email"),
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: "duplicate_email",
+		},
+		{
+			name:          "no session",
+			body:          `{"email_address": "new@example.com"}`,
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			body:          `{"email_address": "not-an-email"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, nil, env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/magic-links/invite", tt.body)
+			if !tt.noSession {
+				r = stytchtest.WithMemberSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.Invite(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.Method != inviteMethod {
+				t.Errorf("method = %q, want %q", resp.Method, inviteMethod)
+			}
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if f.inviteParams.OrganizationID != "org-1" || f.inviteParams.InvitedByMemberID != "member-1" {
+				t.Errorf("Invite() params = %+v, want org-1 invited by member-1", f.inviteParams)
+			}
+			if len(f.inviteOptions) != 1 || f.inviteOptions[0].Authorization.SessionToken != "session-token" {
+				t.Errorf("Invite() options = %+v, want the caller's session token", f.inviteOptions)
+			}
+		})
+	}
+}
+
+func TestLoginOrSignup(t *testing.T) {
+	tests := []struct {
+		name                      string
+		body                      string
+		allowedOrganizationIDs    []string
+		discoveredOrganizationIDs []string
+		cookie                    func(*http.Request, *internal.CookieStore)
+		apiErr                    error
+		wantStatus                int
+		wantErrorType             string
+		wantList                  bool
+	}{
+		{
+			name:       "success without allowlist",
+			body:       `{"organization_id": "org-1", "email_address": "member@example.com"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:                   "allowed organization",
+			body:                   `{"organization_id": "org-1", "email_address": "member@example.com"}`,
+			allowedOrganizationIDs: []string{"org-1"},
+			wantStatus:             http.StatusOK,
+		},
+		{
+			name:                      "discovered with intermediate session",
+			body:                      `{"organization_id": "org-2", "email_address": "member@example.com"}`,
+			allowedOrganizationIDs:    []string{"org-1"},
+			discoveredOrganizationIDs: []string{"org-2"},
+			cookie:                    testutil.AddIntermediateSession,
+			wantStatus:                http.StatusOK,
+			wantList:                  true,
+		},
+		{
+			name:                      "discovered with session",
+			body:                      `{"organization_id": "org-2", "email_address": "member@example.com"}`,
+			allowedOrganizationIDs:    []string{"org-1"},
+			discoveredOrganizationIDs: []string{"org-2"},
+			cookie:                    testutil.AddSession,
+			wantStatus:                http.StatusOK,
+			wantList:                  true,
+		},
+		{
+			name:                      "not discovered",
+			body:                      `{"organization_id": "org-2", "email_address": "member@example.com"}`,
+			allowedOrganizationIDs:    []string{"org-1"},
+			discoveredOrganizationIDs: []string{"org-3"},
+			cookie:                    testutil.AddIntermediateSession,
+			wantStatus:                http.StatusForbidden,
+			wantErrorType:             internal.ErrorTypeForbidden,
+			wantList:                  true,
+		},
+		{
+			name:                   "no cookie",
+			body:                   `{"organization_id": "org-2", "email_address": "member@example.com"}`,
+			allowedOrganizationIDs: []string{"org-1"},
+			wantStatus:             http.StatusForbidden,
+			wantErrorType:          internal.ErrorTypeForbidden,
+		},
+		{
+			name:          "API error",
+			body:          `{"organization_id": "org-1", "email_address": "member@example.com"}`,
+			apiErr:        testutil.StytchError(http.StatusNotFound, "organization_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "organization_not_found",
+		},
+		{
+			name:          "bad body",
+			body:          `{"email_address": "member@example.com"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}, discoveredOrganizationIDs: tt.discoveredOrganizationIDs}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, tt.allowedOrganizationIDs, env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/magic-links/login-signup", tt.body)
+			if tt.cookie != nil {
+				tt.cookie(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.LoginOrSignup(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if got := f.listParams != nil; got != tt.wantList {
+				t.Errorf("List() called = %t, want %t", got, tt.wantList)
+			}
+			if tt.wantStatus == http.StatusForbidden && f.loginOrSignupParams != nil {
+				t.Errorf("LoginOrSignup() was called for a forbidden Organization")
+			}
+		})
+	}
+}
+
+func TestDiscoveryEmailSend(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantReturnTo  string
+	}{
+		{
+			name:       "success",
+			body:       `{"email_address": "member@example.com"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:         "return_to",
+			body:         `{"email_address": "member@example.com", "return_to": "/settings"}`,
+			wantStatus:   http.StatusOK,
+			wantReturnTo: "http://localhost:3001/settings",
+		},
+		{
+			name:          "return_to on another host",
+			body:          `{"email_address": "member@example.com", "return_to": "https://evil.example.com/"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+		{
+			name:          "API error",
+			body:          `{"email_address": "member@example.com"}`,
+			apiErr:        testutil.StytchError(http.StatusTooManyRequests, "too_many_requests"),
+			wantStatus:    http.StatusTooManyRequests,
+			wantErrorType: "too_many_requests",
+		},
+		{
+			name:          "bad body",
+			body:          `{"email_address": "member@example.com", "unknown": true}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, nil, env.Redirector)
+			rec := httptest.NewRecorder()
+			c.DiscoveryEmailSend(rec, testutil.NewRequest(http.MethodPost, "/magic_links/email/discovery/send", tt.body))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if returnTo, _ := env.CookieStore.GetReturnTo(testutil.ResponseCookies(rec)); returnTo != tt.wantReturnTo {
+				t.Errorf("stored return_to = %q, want %q", returnTo, tt.wantReturnTo)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name                    string
+		resp                    magiclinks.AuthenticateResponse
+		apiErr                  error
+		wantStatus              int
+		wantErrorType           string
+		wantSession             bool
+		wantIntermediateSession bool
+	}{
+		{
+			name:        "session",
+			resp:        magiclinks.AuthenticateResponse{SessionToken: "session-token", SessionJWT: "session-jwt"},
+			wantStatus:  http.StatusOK,
+			wantSession: true,
+		},
+		{
+			name:                    "intermediate session",
+			resp:                    magiclinks.AuthenticateResponse{IntermediateSessionToken: "intermediate-session-token"},
+			wantStatus:              http.StatusOK,
+			wantIntermediateSession: true,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "magic_link_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "magic_link_not_found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}, authenticateResponse: tt.resp}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, nil, env.Redirector)
+			rec := httptest.NewRecorder()
+			c.Authenticate(rec, httptest.NewRequest(http.MethodGet, "/authenticate?token=magic-link-token", nil))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if f.authenticateParams.MagicLinksToken != "magic-link-token" {
+				t.Errorf("Authenticate() token = %q, want %q", f.authenticateParams.MagicLinksToken, "magic-link-token")
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			cookies := testutil.ResponseCookies(rec)
+			if _, ok := env.CookieStore.GetSession(cookies); ok != tt.wantSession {
+				t.Errorf("session stored = %t, want %t", ok, tt.wantSession)
+			}
+			if _, ok := env.CookieStore.GetIntermediateSession(cookies); ok != tt.wantIntermediateSession {
+				t.Errorf("intermediate session stored = %t, want %t", ok, tt.wantIntermediateSession)
+			}
+		})
+	}
+}
+
+func TestDiscoveryAuthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiErr        error
+		wantLocation  string
+		wantErrorType string
+	}{
+		{
+			name:         "success",
+			wantLocation: testutil.OrganizationSelectionURL,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "magic_link_not_found"),
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: "magic_link_not_found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, nil, env.Redirector)
+			rec := httptest.NewRecorder()
+			c.DiscoveryAuthenticate(rec, httptest.NewRequest(http.MethodGet, "/authenticate?token=discovery-token", nil))
+
+			if rec.Code != http.StatusSeeOther {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+			}
+			location, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("parsing Location: %v", err)
+			}
+			if q := location.Query(); q.Get("error_type") != tt.wantErrorType {
+				t.Errorf("error_type = %q, want %q", q.Get("error_type"), tt.wantErrorType)
+			}
+			location.RawQuery = ""
+			if location.String() != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			_, ok := env.CookieStore.GetIntermediateSession(testutil.ResponseCookies(rec))
+			if want := tt.apiErr == nil; ok != want {
+				t.Errorf("intermediate session stored = %t, want %t", ok, want)
+			}
+		})
+	}
+}
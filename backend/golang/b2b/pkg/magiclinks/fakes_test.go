@@ -0,0 +1,109 @@
+package magiclinks
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks"
+	mldiscovery "github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/discovery"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/email"
+	emaildiscovery "github.com/stytchauth/stytch-go/v16/stytch/b2b/magiclinks/email/discovery"
+	b2borganizations "github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory. Each call records its parameters and
+// fails with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+
+	authenticateParams          *magiclinks.AuthenticateParams
+	inviteParams                *email.InviteParams
+	inviteOptions               []*email.InviteRequestOptions
+	loginOrSignupParams         *email.LoginOrSignupParams
+	sendParams                  *emaildiscovery.SendParams
+	discoveryAuthenticateParams *mldiscovery.AuthenticateParams
+	listParams                  *organizations.ListParams
+
+	// authenticateResponse is returned by Authenticate.
+	authenticateResponse magiclinks.AuthenticateResponse
+	// discoveredOrganizationIDs are the Organizations returned by List.
+	discoveredOrganizationIDs []string
+}
+
+func (f *fakeAPI) api() API {
+	return API{
+		MagicLinks:               fakeMagicLinks{f},
+		MagicLinksEmail:          fakeMagicLinksEmail{f},
+		MagicLinksEmailDiscovery: fakeMagicLinksEmailDiscovery{f},
+		MagicLinksDiscovery:      fakeMagicLinksDiscovery{f},
+		DiscoveryOrganizations:   fakeDiscoveryOrganizations{f},
+	}
+}
+
+type fakeMagicLinks struct{ *fakeAPI }
+
+func (f fakeMagicLinks) Authenticate(_ context.Context, body *magiclinks.AuthenticateParams) (*magiclinks.AuthenticateResponse, error) {
+	f.authenticateParams = body
+	if err := f.Record("MagicLinks.Authenticate " + body.MagicLinksToken); err != nil {
+		return nil, err
+	}
+	resp := f.authenticateResponse
+	return &resp, nil
+}
+
+type fakeMagicLinksEmail struct{ *fakeAPI }
+
+func (f fakeMagicLinksEmail) Invite(_ context.Context, body *email.InviteParams, methodOptions ...*email.InviteRequestOptions) (*email.InviteResponse, error) {
+	f.inviteParams, f.inviteOptions = body, methodOptions
+	if err := f.Record("MagicLinksEmail.Invite " + body.EmailAddress); err != nil {
+		return nil, err
+	}
+	return &email.InviteResponse{StatusCode: 200}, nil
+}
+
+func (f fakeMagicLinksEmail) LoginOrSignup(_ context.Context, body *email.LoginOrSignupParams) (*email.LoginOrSignupResponse, error) {
+	f.loginOrSignupParams = body
+	if err := f.Record("MagicLinksEmail.LoginOrSignup " + body.EmailAddress); err != nil {
+		return nil, err
+	}
+	return &email.LoginOrSignupResponse{StatusCode: 200}, nil
+}
+
+type fakeMagicLinksEmailDiscovery struct{ *fakeAPI }
+
+func (f fakeMagicLinksEmailDiscovery) Send(_ context.Context, body *emaildiscovery.SendParams) (*emaildiscovery.SendResponse, error) {
+	f.sendParams = body
+	if err := f.Record("MagicLinksEmailDiscovery.Send " + body.EmailAddress); err != nil {
+		return nil, err
+	}
+	return &emaildiscovery.SendResponse{StatusCode: 200}, nil
+}
+
+type fakeMagicLinksDiscovery struct{ *fakeAPI }
+
+func (f fakeMagicLinksDiscovery) Authenticate(_ context.Context, body *mldiscovery.AuthenticateParams) (*mldiscovery.AuthenticateResponse, error) {
+	f.discoveryAuthenticateParams = body
+	if err := f.Record("MagicLinksDiscovery.Authenticate " + body.DiscoveryMagicLinksToken); err != nil {
+		return nil, err
+	}
+	return &mldiscovery.AuthenticateResponse{IntermediateSessionToken: "intermediate-session-token", StatusCode: 200}, nil
+}
+
+type fakeDiscoveryOrganizations struct{ *fakeAPI }
+
+func (f fakeDiscoveryOrganizations) List(_ context.Context, body *organizations.ListParams) (*organizations.ListResponse, error) {
+	f.listParams = body
+	if err := f.Record("DiscoveryOrganizations.List " + body.IntermediateSessionToken); err != nil {
+		return nil, err
+	}
+	resp := &organizations.ListResponse{StatusCode: 200}
+	for _, id := range f.discoveredOrganizationIDs {
+		resp.DiscoveredOrganizations = append(resp.DiscoveredOrganizations, discovery.DiscoveredOrganization{
+			Organization: &b2borganizations.Organization{OrganizationID: id},
+		})
+	}
+	return resp, nil
+}
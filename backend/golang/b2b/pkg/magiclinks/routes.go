@@ -0,0 +1,36 @@
+package magiclinks
+
+import (
+	"net/http"
+
+	"backend/golang/b2b/pkg/router"
+)
+
+// Routes returns the Email Magic Links routes. Magic links are authenticated through the
+// universal /authenticate route.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:     http.MethodPost,
+			Path:       "/magic-links/invite",
+			Summary:    "Invite a member to the caller's organization",
+			Handler:    c.Invite,
+			Request:    inviteRequest{},
+			Permission: &router.Permission{ResourceID: "stytch.member", Action: "create"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/magic-links/login-signup",
+			Summary: "Send a login or signup magic link for an organization",
+			Handler: c.LoginOrSignup,
+			Request: loginOrSignupRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/magic_links/email/discovery/send",
+			Summary: "Send a discovery magic link",
+			Handler: c.DiscoveryEmailSend,
+			Request: discoveryRequest{},
+		},
+	}
+}
@@ -0,0 +1,40 @@
+package members
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations/members"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+)
+
+// API holds the clients of the Stytch B2B API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	OrganizationsMembers OrganizationsMembersClient
+	Sessions             SessionsClient
+}
+
+// NewAPI returns the clients of the Stytch B2B API that the controller uses.
+func NewAPI(client *b2bstytchapi.API) API {
+	return API{
+		OrganizationsMembers: client.Organizations.Members,
+		Sessions:             client.Sessions,
+	}
+}
+
+// OrganizationsMembersClient manages the Members of an Organization.
+// It is implemented by b2bstytchapi.API.Organizations.Members.
+type OrganizationsMembersClient interface {
+	Search(ctx context.Context, body *members.SearchParams, methodOptions ...*members.SearchRequestOptions) (*members.SearchResponse, error)
+	Get(ctx context.Context, body *members.GetParams) (*members.GetResponse, error)
+	Update(ctx context.Context, body *members.UpdateParams, methodOptions ...*members.UpdateRequestOptions) (*members.UpdateResponse, error)
+	Delete(ctx context.Context, body *members.DeleteParams, methodOptions ...*members.DeleteRequestOptions) (*members.DeleteResponse, error)
+	Reactivate(ctx context.Context, body *members.ReactivateParams, methodOptions ...*members.ReactivateRequestOptions) (*members.ReactivateResponse, error)
+}
+
+// SessionsClient revokes member sessions.
+// It is implemented by b2bstytchapi.API.Sessions.
+type SessionsClient interface {
+	Revoke(ctx context.Context, body *sessions.RevokeParams, methodOptions ...*sessions.RevokeRequestOptions) (*sessions.RevokeResponse, error)
+}
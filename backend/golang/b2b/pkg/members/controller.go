@@ -0,0 +1,12 @@
+package members
+
+import "backend/golang/b2b/pkg/internal"
+
+type Controller struct {
+	api         API
+	cookieStore *internal.CookieStore
+}
+
+func NewController(api API, cookieStore *internal.CookieStore) *Controller {
+	return &Controller{api, cookieStore}
+}
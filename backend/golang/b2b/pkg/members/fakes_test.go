@@ -0,0 +1,85 @@
+package members
+
+import (
+	"context"
+	"slices"
+	"strings"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations/members"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/methodoptions"
+
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory. Each call is recorded with the
+// Organization and Member it acted on and the session token that authorized it, and fails
+// with Err when it is set, or with getErr for Get.
+type fakeAPI struct {
+	testutil.Fake
+	getErr error
+
+	searchParams *members.SearchParams
+	updateParams *members.UpdateParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{OrganizationsMembers: f, Sessions: fakeSessions{f}}
+}
+
+func (f *fakeAPI) record(method, organizationID, memberID string, authorization methodoptions.Authorization) error {
+	return f.Record(strings.Join(slices.DeleteFunc([]string{method, organizationID, memberID, authorization.SessionToken}, func(s string) bool {
+		return s == ""
+	}), " "))
+}
+
+func (f *fakeAPI) Search(_ context.Context, body *members.SearchParams, methodOptions ...*members.SearchRequestOptions) (*members.SearchResponse, error) {
+	f.searchParams = body
+	if err := f.record("Search", body.OrganizationIds[0], "", methodOptions[0].Authorization); err != nil {
+		return nil, err
+	}
+	return &members.SearchResponse{
+		ResultsMetadata: organizations.ResultsMetadata{NextCursor: "next-cursor"},
+		StatusCode:      200,
+	}, nil
+}
+
+func (f *fakeAPI) Get(_ context.Context, body *members.GetParams) (*members.GetResponse, error) {
+	f.Calls = append(f.Calls, "Get "+body.OrganizationID+" "+body.MemberID)
+	if f.getErr != nil {
+		return nil, f.getErr
+	}
+	return &members.GetResponse{MemberID: body.MemberID, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Update(_ context.Context, body *members.UpdateParams, methodOptions ...*members.UpdateRequestOptions) (*members.UpdateResponse, error) {
+	f.updateParams = body
+	if err := f.record("Update", body.OrganizationID, body.MemberID, methodOptions[0].Authorization); err != nil {
+		return nil, err
+	}
+	return &members.UpdateResponse{MemberID: body.MemberID, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Delete(_ context.Context, body *members.DeleteParams, methodOptions ...*members.DeleteRequestOptions) (*members.DeleteResponse, error) {
+	if err := f.record("Delete", body.OrganizationID, body.MemberID, methodOptions[0].Authorization); err != nil {
+		return nil, err
+	}
+	return &members.DeleteResponse{MemberID: body.MemberID, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Reactivate(_ context.Context, body *members.ReactivateParams, methodOptions ...*members.ReactivateRequestOptions) (*members.ReactivateResponse, error) {
+	if err := f.record("Reactivate", body.OrganizationID, body.MemberID, methodOptions[0].Authorization); err != nil {
+		return nil, err
+	}
+	return &members.ReactivateResponse{MemberID: body.MemberID, StatusCode: 200}, nil
+}
+
+type fakeSessions struct{ *fakeAPI }
+
+func (f fakeSessions) Revoke(_ context.Context, body *sessions.RevokeParams, methodOptions ...*sessions.RevokeRequestOptions) (*sessions.RevokeResponse, error) {
+	if err := f.record("Revoke", "", body.MemberID, methodOptions[0].Authorization); err != nil {
+		return nil, err
+	}
+	return &sessions.RevokeResponse{StatusCode: 200}, nil
+}
@@ -0,0 +1,324 @@
+package members
+
+import (
+	"net/http"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations/members"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/methodoptions"
+
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/rbac"
+)
+
+const (
+	searchMethod         = "Organizations.Members.Search"
+	updateMethod         = "Organizations.Members.Update"
+	deleteMethod         = "Organizations.Members.Delete"
+	reactivateMethod     = "Organizations.Members.Reactivate"
+	revokeSessionsMethod = "Sessions.Revoke"
+)
+
+// currentSession returns the session token and member session that the RBAC middleware
+// attached to the request. Every member operation is scoped to the organization of the
+// authenticated member, and the session token is forwarded to Stytch so that RBAC
+// policies are enforced for the caller.
+func currentSession(w http.ResponseWriter, r *http.Request, method string) (string, *sessions.AuthenticateResponse, bool) {
+	st, session, ok := rbac.FromContext(r.Context())
+	if !ok {
+		internal.SendError(w, method, internal.ErrNoSession)
+		return "", nil, false
+	}
+	return st, session, true
+}
+
+type searchRequest struct {
+	EmailAddress string   `json:"email_address" validate:"max=254"`
+	Statuses     []string `json:"statuses"`
+	Roles        []string `json:"roles"`
+	Cursor       string   `json:"cursor"`
+	Limit        uint32   `json:"limit" validate:"max=1000"`
+}
+
+// Search wraps Stytch's Members Search endpoint and returns the Members of the caller's
+// Organization, optionally filtered by email address, status and role. Results are
+// paginated using the cursor returned in the previous response.
+func (c *Controller) Search(w http.ResponseWriter, r *http.Request) {
+	var req searchRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, searchMethod, err)
+		return
+	}
+
+	st, session, ok := currentSession(w, r, searchMethod)
+	if !ok {
+		return
+	}
+
+	// Each filter is expressed as an operand and all operands must match.
+	var operands []map[string]any
+	if req.EmailAddress != "" {
+		operands = append(operands, map[string]any{
+			"filter_name":  "member_email_fuzzy",
+			"filter_value": req.EmailAddress,
+		})
+	}
+	if len(req.Statuses) > 0 {
+		operands = append(operands, map[string]any{
+			"filter_name":  "statuses",
+			"filter_value": req.Statuses,
+		})
+	}
+	if len(req.Roles) > 0 {
+		operands = append(operands, map[string]any{
+			"filter_name":  "member_roles",
+			"filter_value": req.Roles,
+		})
+	}
+
+	params := &members.SearchParams{
+		OrganizationIds: []string{session.Organization.OrganizationID},
+		Cursor:          req.Cursor,
+		Limit:           req.Limit,
+	}
+	if len(operands) > 0 {
+		params.Query = &organizations.SearchQuery{
+			Operator: organizations.SearchQueryOperatorAND,
+			Operands: operands,
+		}
+	}
+
+	resp, err := c.api.OrganizationsMembers.Search(r.Context(), params, &members.SearchRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, searchMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      searchMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Organizations.Members.Search(
+	r.Context(),
+	&members.SearchParams{
+		OrganizationIds: []string{session.Organization.OrganizationID},
+		Cursor:          req.Cursor,
+		Limit:           req.Limit,
+		Query: &organizations.SearchQuery{
+			Operator: organizations.SearchQueryOperatorAND,
+			Operands: operands,
+		},
+	},
+	&members.SearchRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	},
+)`,
+		Metadata: map[string]any{
+			"nextCursor": resp.ResultsMetadata.NextCursor,
+		},
+	})
+}
+
+type updateRequest struct {
+	MemberID string `json:"member_id" validate:"required"`
+	Name     string `json:"name" validate:"max=128"`
+	// Roles replaces the explicit roles of the Member when present. An empty list removes
+	// every explicit role.
+	Roles []string `json:"roles"`
+	// MFAEnrolled enrolls the Member in MFA when true. The SDK omits false values, so it
+	// cannot unenroll Members.
+	MFAEnrolled *bool `json:"mfa_enrolled"`
+}
+
+// Update wraps Stytch's Members Update endpoint and updates the name, roles and MFA
+// enrollment of a Member in the caller's Organization. Fields omitted from the request
+// are left unchanged.
+func (c *Controller) Update(w http.ResponseWriter, r *http.Request) {
+	var req updateRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, updateMethod, err)
+		return
+	}
+
+	st, session, ok := currentSession(w, r, updateMethod)
+	if !ok {
+		return
+	}
+
+	params := &members.UpdateParams{
+		OrganizationID: session.Organization.OrganizationID,
+		MemberID:       req.MemberID,
+		Name:           req.Name,
+	}
+	// Roles are only sent when present, so that omitting them keeps the Member's roles.
+	if req.Roles != nil {
+		params.Roles = &req.Roles
+	}
+	if req.MFAEnrolled != nil {
+		params.MFAEnrolled = *req.MFAEnrolled
+	}
+
+	resp, err := c.api.OrganizationsMembers.Update(r.Context(), params, &members.UpdateRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, updateMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      updateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Organizations.Members.Update(
+	r.Context(),
+	&members.UpdateParams{
+		OrganizationID: session.Organization.OrganizationID,
+		MemberID:       req.MemberID,
+		Name:           req.Name,
+		Roles:          &req.Roles,
+		MFAEnrolled:    *req.MFAEnrolled,
+	},
+	&members.UpdateRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	},
+)`,
+	})
+}
+
+type memberRequest struct {
+	MemberID string `json:"member_id" validate:"required"`
+}
+
+// Delete wraps Stytch's Members Delete endpoint and deactivates a Member in the caller's
+// Organization. Deleted Members can be restored with Reactivate.
+func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
+	var req memberRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, deleteMethod, err)
+		return
+	}
+
+	st, session, ok := currentSession(w, r, deleteMethod)
+	if !ok {
+		return
+	}
+
+	resp, err := c.api.OrganizationsMembers.Delete(r.Context(), &members.DeleteParams{
+		OrganizationID: session.Organization.OrganizationID,
+		MemberID:       req.MemberID,
+	}, &members.DeleteRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, deleteMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      deleteMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Organizations.Members.Delete(
+	r.Context(),
+	&members.DeleteParams{
+		OrganizationID: session.Organization.OrganizationID,
+		MemberID:       req.MemberID,
+	},
+	&members.DeleteRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	},
+)`,
+	})
+}
+
+// Reactivate wraps Stytch's Members Reactivate endpoint and restores a previously
+// deleted Member in the caller's Organization.
+func (c *Controller) Reactivate(w http.ResponseWriter, r *http.Request) {
+	var req memberRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, reactivateMethod, err)
+		return
+	}
+
+	st, session, ok := currentSession(w, r, reactivateMethod)
+	if !ok {
+		return
+	}
+
+	resp, err := c.api.OrganizationsMembers.Reactivate(r.Context(), &members.ReactivateParams{
+		OrganizationID: session.Organization.OrganizationID,
+		MemberID:       req.MemberID,
+	}, &members.ReactivateRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, reactivateMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      reactivateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Organizations.Members.Reactivate(
+	r.Context(),
+	&members.ReactivateParams{
+		OrganizationID: session.Organization.OrganizationID,
+		MemberID:       req.MemberID,
+	},
+	&members.ReactivateRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	},
+)`,
+	})
+}
+
+// RevokeSessions wraps Stytch's Sessions Revoke endpoint and revokes all active sessions
+// of a Member in the caller's Organization.
+func (c *Controller) RevokeSessions(w http.ResponseWriter, r *http.Request) {
+	var req memberRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, revokeSessionsMethod, err)
+		return
+	}
+
+	st, session, ok := currentSession(w, r, revokeSessionsMethod)
+	if !ok {
+		return
+	}
+
+	// Stytch only scopes a revocation by Member ID, so confirm that the target Member
+	// belongs to the caller's Organization before revoking anything.
+	_, err := c.api.OrganizationsMembers.Get(r.Context(), &members.GetParams{
+		OrganizationID: session.Organization.OrganizationID,
+		MemberID:       req.MemberID,
+	})
+	if err != nil {
+		internal.SendError(w, revokeSessionsMethod, err)
+		return
+	}
+
+	resp, err := c.api.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
+		MemberID: req.MemberID,
+	}, &sessions.RevokeRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, revokeSessionsMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      revokeSessionsMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Sessions.Revoke(
+	r.Context(),
+	&sessions.RevokeParams{
+		MemberID: req.MemberID,
+	},
+	&sessions.RevokeRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	},
+)`,
+	})
+}
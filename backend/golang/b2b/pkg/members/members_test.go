@@ -0,0 +1,196 @@
+package members
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/internal/testutil"
+	"backend/golang/b2b/pkg/stytchtest"
+)
+
+// serve calls the handler with the body, as member-1 of org-1 unless noSession is set.
+func serve(t *testing.T, f *fakeAPI, handler func(*Controller) http.HandlerFunc, body string, noSession bool) (*httptest.ResponseRecorder, testutil.Response) {
+	t.Helper()
+	c := NewController(f.api(), testutil.NewCookieStore(t))
+	r := testutil.NewRequest(http.MethodPost, "/members", body)
+	if !noSession {
+		r = stytchtest.WithMemberSession(r)
+	}
+	rec := httptest.NewRecorder()
+	handler(c)(rec, r)
+	return rec, testutil.Decode(t, rec)
+}
+
+func TestHandlers(t *testing.T) {
+	handlers := []struct {
+		name      string
+		handler   func(*Controller) http.HandlerFunc
+		method    string
+		body      string
+		badBody   string
+		wantCalls []string
+	}{
+		{
+			name:      "Search",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Search },
+			method:    searchMethod,
+			body:      `{"email_address": "member@example.com", "limit": 10}`,
+			badBody:   `{"limit": 5000}`,
+			wantCalls: []string{"Search org-1 session-token"},
+		},
+		{
+			name:      "Update",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Update },
+			method:    updateMethod,
+			body:      `{"member_id": "member-2", "name": "Member Two"}`,
+			badBody:   `{"name": "Member Two"}`,
+			wantCalls: []string{"Update org-1 member-2 session-token"},
+		},
+		{
+			name:      "Delete",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Delete },
+			method:    deleteMethod,
+			body:      `{"member_id": "member-2"}`,
+			badBody:   `{"member_id": 2}`,
+			wantCalls: []string{"Delete org-1 member-2 session-token"},
+		},
+		{
+			name:      "Reactivate",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Reactivate },
+			method:    reactivateMethod,
+			body:      `{"member_id": "member-2"}`,
+			badBody:   `{}`,
+			wantCalls: []string{"Reactivate org-1 member-2 session-token"},
+		},
+		{
+			name:      "RevokeSessions",
+			handler:   func(c *Controller) http.HandlerFunc { return c.RevokeSessions },
+			method:    revokeSessionsMethod,
+			body:      `{"member_id": "member-2"}`,
+			badBody:   `{"member_id": ""}`,
+			wantCalls: []string{"Get org-1 member-2", "Revoke member-2 session-token"},
+		},
+	}
+	tests := []struct {
+		name          string
+		badBody       bool
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusForbidden, "unauthorized_action"),
+			wantStatus:    http.StatusForbidden,
+			wantErrorType: "unauthorized_action",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			badBody:       true,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, h := range handlers {
+		for _, tt := range tests {
+			t.Run(h.name+"/"+tt.name, func(t *testing.T) {
+				body := h.body
+				if tt.badBody {
+					body = h.badBody
+				}
+				f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+				rec, resp := serve(t, f, h.handler, body, tt.noSession)
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				if resp.Method != h.method {
+					t.Errorf("method = %q, want %q", resp.Method, h.method)
+				}
+				if resp.ErrorType() != tt.wantErrorType {
+					t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+				}
+				var wantCalls []string
+				if !tt.badBody && !tt.noSession {
+					wantCalls = h.wantCalls
+				}
+				if !slices.Equal(f.Calls, wantCalls) {
+					t.Errorf("calls = %q, want %q", f.Calls, wantCalls)
+				}
+			})
+		}
+	}
+}
+
+func TestSearchFilters(t *testing.T) {
+	f := &fakeAPI{}
+	rec, resp := serve(t, f, func(c *Controller) http.HandlerFunc { return c.Search },
+		`{"email_address": "member", "statuses": ["active"], "roles": ["stytch_admin"], "cursor": "cursor"}`, false)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+
+	var filters []string
+	for _, operand := range f.searchParams.Query.Operands {
+		filters = append(filters, operand["filter_name"].(string))
+	}
+	if want := []string{"member_email_fuzzy", "statuses", "member_roles"}; !slices.Equal(filters, want) {
+		t.Errorf("filters = %q, want %q", filters, want)
+	}
+	if f.searchParams.Cursor != "cursor" {
+		t.Errorf("cursor = %q, want %q", f.searchParams.Cursor, "cursor")
+	}
+	if got := resp.Metadata["nextCursor"]; got != "next-cursor" {
+		t.Errorf("nextCursor = %v, want %q", got, "next-cursor")
+	}
+}
+
+func TestUpdateRoles(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantRoles []string
+	}{
+		{name: "omitted", body: `{"member_id": "member-2"}`},
+		{name: "empty", body: `{"member_id": "member-2", "roles": []}`, wantRoles: []string{}},
+		{name: "set", body: `{"member_id": "member-2", "roles": ["stytch_admin"]}`, wantRoles: []string{"stytch_admin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{}
+			rec, _ := serve(t, f, func(c *Controller) http.HandlerFunc { return c.Update }, tt.body, false)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			roles := f.updateParams.Roles
+			if (roles == nil) != (tt.wantRoles == nil) || roles != nil && !slices.Equal(*roles, tt.wantRoles) {
+				t.Errorf("roles = %v, want %v", roles, tt.wantRoles)
+			}
+		})
+	}
+}
+
+func TestRevokeSessionsOtherOrganization(t *testing.T) {
+	f := &fakeAPI{getErr: testutil.StytchError(http.StatusNotFound, "member_not_found")}
+	rec, resp := serve(t, f, func(c *Controller) http.HandlerFunc { return c.RevokeSessions }, `{"member_id": "member-of-org-2"}`, false)
+	if rec.Code != http.StatusNotFound || resp.ErrorType() != "member_not_found" {
+		t.Fatalf("response = %d %q, want %d %q", rec.Code, resp.ErrorType(), http.StatusNotFound, "member_not_found")
+	}
+	if want := []string{"Get org-1 member-of-org-2"}; !slices.Equal(f.Calls, want) {
+		t.Errorf("calls = %q, want %q", f.Calls, want)
+	}
+}
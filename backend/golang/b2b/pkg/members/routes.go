@@ -0,0 +1,54 @@
+package members
+
+import (
+	"net/http"
+
+	"backend/golang/b2b/pkg/router"
+)
+
+// Routes returns the Members routes. Stytch additionally enforces fine-grained permissions,
+// such as updating roles, using the session token that is forwarded with each call.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:     http.MethodPost,
+			Path:       "/members/search",
+			Summary:    "Search the members of the caller's organization",
+			Handler:    c.Search,
+			Request:    searchRequest{},
+			Permission: &router.Permission{ResourceID: "stytch.member", Action: "search"},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/members/update",
+			Summary:       "Update a member",
+			Handler:       c.Update,
+			Request:       updateRequest{},
+			Authenticated: true,
+		},
+		{
+			Method:     http.MethodPost,
+			Path:       "/members/delete",
+			Summary:    "Delete a member",
+			Handler:    c.Delete,
+			Request:    memberRequest{},
+			Permission: &router.Permission{ResourceID: "stytch.member", Action: "delete"},
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/members/reactivate",
+			Summary:       "Reactivate a deleted member",
+			Handler:       c.Reactivate,
+			Request:       memberRequest{},
+			Authenticated: true,
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/members/sessions/revoke",
+			Summary:       "Revoke all sessions of a member",
+			Handler:       c.RevokeSessions,
+			Request:       memberRequest{},
+			Authenticated: true,
+		},
+	}
+}
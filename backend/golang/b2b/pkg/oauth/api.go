@@ -0,0 +1,27 @@
+package oauth
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
+	discoveryoauth "github.com/stytchauth/stytch-go/v16/stytch/b2b/oauth/discovery"
+)
+
+// API holds the clients of the Stytch B2B API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	OAuthDiscovery OAuthDiscoveryClient
+}
+
+// NewAPI returns the clients of the Stytch B2B API that the controller uses.
+func NewAPI(client *b2bstytchapi.API) API {
+	return API{
+		OAuthDiscovery: client.OAuth.Discovery,
+	}
+}
+
+// OAuthDiscoveryClient authenticates discovery OAuth tokens.
+// It is implemented by b2bstytchapi.API.OAuth.Discovery.
+type OAuthDiscoveryClient interface {
+	Authenticate(ctx context.Context, body *discoveryoauth.AuthenticateParams) (*discoveryoauth.AuthenticateResponse, error)
+}
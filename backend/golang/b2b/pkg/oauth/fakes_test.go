@@ -0,0 +1,24 @@
+package oauth
+
+import (
+	"context"
+
+	discoveryoauth "github.com/stytchauth/stytch-go/v16/stytch/b2b/oauth/discovery"
+
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+// fakeOAuthDiscovery implements OAuthDiscoveryClient in memory. Authenticate records its
+// parameters and fails with Err when it is set.
+type fakeOAuthDiscovery struct {
+	testutil.Fake
+	params *discoveryoauth.AuthenticateParams
+}
+
+func (f *fakeOAuthDiscovery) Authenticate(_ context.Context, body *discoveryoauth.AuthenticateParams) (*discoveryoauth.AuthenticateResponse, error) {
+	f.params = body
+	if err := f.Record("Authenticate " + body.DiscoveryOAuthToken); err != nil {
+		return nil, err
+	}
+	return &discoveryoauth.AuthenticateResponse{IntermediateSessionToken: "intermediate-session-token", StatusCode: 200}, nil
+}
@@ -3,8 +3,6 @@ package oauth
 import (
 	"net/http"
 
-	"backend/golang/b2b/pkg/internal"
-
 	discoveryoauth "github.com/stytchauth/stytch-go/v16/stytch/b2b/oauth/discovery"
 )
 
@@ -19,14 +17,11 @@ const discoveryOAuthAuthenticateMethod = "OAuth.Discovery.Authenticate"
 func (c *Controller) DiscoveryOAuthAuthenticate(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the token from the query parameter.
 	token := r.URL.Query().Get("token")
-	resp, err := c.api.OAuth.Discovery.Authenticate(r.Context(), &discoveryoauth.AuthenticateParams{
+	resp, err := c.api.OAuthDiscovery.Authenticate(r.Context(), &discoveryoauth.AuthenticateParams{
 		DiscoveryOAuthToken: token,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: discoveryOAuthAuthenticateMethod,
-			Error:  err.Error(),
-		})
+		c.redirector.Error(w, r, discoveryOAuthAuthenticateMethod, err)
 		return
 	}
 
@@ -36,6 +31,6 @@ func (c *Controller) DiscoveryOAuthAuthenticate(w http.ResponseWriter, r *http.R
 	// This helps prevent account enumeration attacks.
 	c.cookieStore.StoreIntermediateSession(w, r, resp.IntermediateSessionToken)
 
-	// Redirect to the organizations page after successful authentication
-	http.Redirect(w, r, "http://localhost:3001/organizations", http.StatusSeeOther)
+	// Redirect to the organization selection page after successful authentication
+	c.redirector.OrganizationSelection(w, r)
 }
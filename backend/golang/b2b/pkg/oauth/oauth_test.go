@@ -0,0 +1,61 @@
+package oauth
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+func TestDiscoveryOAuthAuthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		apiErr        error
+		wantLocation  string
+		wantErrorType string
+	}{
+		{
+			name:         "success",
+			wantLocation: testutil.OrganizationSelectionURL,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusUnauthorized, "oauth_token_not_found"),
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: "oauth_token_not_found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeOAuthDiscovery{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(API{OAuthDiscovery: f}, env.CookieStore, env.Redirector)
+			rec := httptest.NewRecorder()
+			c.DiscoveryOAuthAuthenticate(rec, httptest.NewRequest(http.MethodGet, "/authenticate?token=oauth-token", nil))
+
+			if f.params.DiscoveryOAuthToken != "oauth-token" {
+				t.Errorf("Authenticate() token = %q, want %q", f.params.DiscoveryOAuthToken, "oauth-token")
+			}
+			if rec.Code != http.StatusSeeOther {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+			}
+			location, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("parsing Location: %v", err)
+			}
+			if q := location.Query(); q.Get("error_type") != tt.wantErrorType {
+				t.Errorf("error_type = %q, want %q", q.Get("error_type"), tt.wantErrorType)
+			}
+			location.RawQuery = ""
+			if location.String() != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			_, ok := env.CookieStore.GetIntermediateSession(testutil.ResponseCookies(rec))
+			if want := tt.apiErr == nil; ok != want {
+				t.Errorf("intermediate session stored = %t, want %t", ok, want)
+			}
+		})
+	}
+}
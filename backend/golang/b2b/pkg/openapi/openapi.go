@@ -0,0 +1,274 @@
+// Package openapi generates an OpenAPI 3 description of the backend from its route table.
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/router"
+)
+
+// Document is an OpenAPI 3 document encoded as JSON.
+type Document map[string]any
+
+// sessionCookie is the name of the security scheme for routes that require a session.
+const sessionCookie = "sessionCookie"
+
+// These are the schemas shared by every operation.
+var components = map[reflect.Type]string{
+	reflect.TypeOf(internal.Response{}): "Response",
+	reflect.TypeOf(internal.Error{}):    "Error",
+}
+
+// Build describes every route in the table. Request bodies are derived from the Request
+// struct of each route, including the rules in its `validate` tags, and every response
+// uses the shared Response envelope.
+func Build(title string, routes []router.Route) Document {
+	paths := map[string]map[string]any{}
+	for _, route := range routes {
+		if paths[route.Path] == nil {
+			paths[route.Path] = map[string]any{}
+		}
+		paths[route.Path][strings.ToLower(route.Method)] = operation(route)
+	}
+
+	schemas := map[string]any{}
+	for t, name := range components {
+		schemas[name] = schema(t, name)
+	}
+
+	return Document{
+		"openapi": "3.0.3",
+		"info": map[string]any{
+			"title":   title,
+			"version": "1.0.0",
+		},
+		"paths": paths,
+		"components": map[string]any{
+			"schemas": schemas,
+			"securitySchemes": map[string]any{
+				sessionCookie: map[string]any{
+					"type": "apiKey",
+					"in":   "cookie",
+					"name": "stytch_session_key",
+				},
+			},
+		},
+	}
+}
+
+func operation(route router.Route) map[string]any {
+	envelope := map[string]any{
+		"content": map[string]any{
+			"application/json": map[string]any{
+				"schema": ref("Response"),
+			},
+		},
+	}
+	op := map[string]any{
+		"operationId": operationID(route),
+		"summary":     route.Summary,
+		"responses": map[string]any{
+			"200": withDescription(envelope, "Successful response"),
+			"default": withDescription(envelope,
+				"Error response, with details in the error and errorDetails fields"),
+		},
+	}
+
+	var params []any
+	for _, segment := range strings.Split(route.Path, "/") {
+		if name, ok := strings.CutPrefix(segment, "{"); ok {
+			params = append(params, map[string]any{
+				"name":     strings.TrimSuffix(strings.TrimSuffix(name, "}"), "..."),
+				"in":       "path",
+				"required": true,
+				"schema":   map[string]any{"type": "string"},
+			})
+		}
+	}
+	for _, name := range route.Query {
+		params = append(params, map[string]any{
+			"name":   name,
+			"in":     "query",
+			"schema": map[string]any{"type": "string"},
+		})
+	}
+	if len(params) > 0 {
+		op["parameters"] = params
+	}
+
+	if route.Request != nil {
+		// An empty body is decoded as an empty object, so the body can only be omitted when
+		// none of its fields are required.
+		body := schema(reflect.TypeOf(route.Request), "")
+		_, required := body["required"]
+		op["requestBody"] = map[string]any{
+			"required": required,
+			"content": map[string]any{
+				"application/json": map[string]any{
+					"schema": body,
+				},
+			},
+		}
+	}
+
+	if route.Authenticated || route.Permission != nil {
+		op["security"] = []any{map[string]any{sessionCookie: []string{}}}
+	}
+	if route.Permission != nil {
+		op["x-stytch-permission"] = map[string]any{
+			"resource_id": route.Permission.ResourceID,
+			"action":      route.Permission.Action,
+		}
+	}
+	return op
+}
+
+func withDescription(m map[string]any, description string) map[string]any {
+	out := map[string]any{"description": description}
+	for k, v := range m {
+		out[k] = v
+	}
+	return out
+}
+
+// operationID derives a unique ID such as "post_members_search" from the method and path.
+func operationID(route router.Route) string {
+	path := strings.Trim(route.Path, "/")
+	if path == "" {
+		path = "index"
+	}
+	id := strings.ToLower(route.Method) + "_" + path
+	return strings.NewReplacer("/", "_", "-", "_", ".", "_").Replace(id)
+}
+
+func ref(name string) map[string]any {
+	return map[string]any{"$ref": "#/components/schemas/" + name}
+}
+
+// schema returns the JSON schema of a Go type. Types that are shared components are
+// referenced, except for the component named self, which is being described.
+func schema(t reflect.Type, self string) map[string]any {
+	if name, ok := components[t]; ok && name != self {
+		return ref(name)
+	}
+
+	switch t.Kind() {
+	case reflect.Pointer:
+		s := schema(t.Elem(), self)
+		if _, isRef := s["$ref"]; !isRef {
+			s["nullable"] = true
+		}
+		return s
+	case reflect.String:
+		return map[string]any{"type": "string"}
+	case reflect.Bool:
+		return map[string]any{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32:
+		return map[string]any{"type": "integer", "format": "int32"}
+	case reflect.Int64, reflect.Uint64:
+		return map[string]any{"type": "integer", "format": "int64"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]any{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]any{"type": "array", "items": schema(t.Elem(), self)}
+	case reflect.Map:
+		return map[string]any{"type": "object", "additionalProperties": schema(t.Elem(), self)}
+	case reflect.Struct:
+		return structSchema(t, self)
+	default:
+		// Fields of type any, such as the Stytch API response, accept any JSON value.
+		return map[string]any{}
+	}
+}
+
+func structSchema(t reflect.Type, self string) map[string]any {
+	properties := map[string]any{}
+	var required []string
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" || !f.IsExported() {
+			continue
+		}
+		if name == "" {
+			name = f.Name
+		}
+
+		s := schema(f.Type, self)
+		if _, isRef := s["$ref"]; !isRef {
+			applyRules(s, f.Type, f.Tag.Get("validate"))
+		}
+		properties[name] = s
+
+		if strings.Contains(f.Tag.Get("validate"), "required") && !strings.Contains(opts, "omitempty") {
+			required = append(required, name)
+		}
+	}
+
+	s := map[string]any{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+// applyRules translates the rules of a `validate` tag into JSON schema keywords.
+func applyRules(s map[string]any, t reflect.Type, rules string) {
+	if rules == "" {
+		return
+	}
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	for _, rule := range strings.Split(rules, ",") {
+		name, arg, _ := strings.Cut(rule, "=")
+		switch name {
+		case "email":
+			s["format"] = "email"
+		case "url":
+			s["format"] = "uri"
+		case "slug":
+			s["pattern"] = "^[a-z0-9._~-]+$"
+		case "min", "max":
+			limit, err := strconv.ParseFloat(arg, 64)
+			if err != nil {
+				continue
+			}
+			s[boundKeyword(t.Kind(), name)] = limit
+		}
+	}
+}
+
+func boundKeyword(kind reflect.Kind, bound string) string {
+	switch kind {
+	case reflect.String:
+		return bound + "Length"
+	case reflect.Slice, reflect.Array:
+		return bound + "Items"
+	case reflect.Map:
+		return bound + "Properties"
+	default:
+		if bound == "min" {
+			return "minimum"
+		}
+		return "maximum"
+	}
+}
+
+// Handler serves the document as JSON.
+func Handler(doc Document) http.HandlerFunc {
+	b, err := json.MarshalIndent(doc, "", "\t")
+	return func(w http.ResponseWriter, _ *http.Request) {
+		if err != nil {
+			internal.SendError(w, "OpenAPI.Document", err)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(b)
+	}
+}
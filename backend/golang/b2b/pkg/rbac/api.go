@@ -0,0 +1,29 @@
+package rbac
+
+import (
+	"context"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+)
+
+// API holds the clients of the Stytch B2B API that the middleware uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	Sessions SessionsClient
+}
+
+// NewAPI returns the clients of the Stytch B2B API that the middleware uses.
+func NewAPI(client *b2bstytchapi.API) API {
+	return API{
+		Sessions: client.Sessions,
+	}
+}
+
+// SessionsClient authenticates member sessions and checks their permissions.
+// It is implemented by b2bstytchapi.API.Sessions.
+type SessionsClient interface {
+	Authenticate(ctx context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error)
+	AuthenticateJWTLocal(ctx context.Context, token string, maxTokenAge time.Duration, authorizationCheck *sessions.AuthorizationCheck) (*sessions.MemberSession, error)
+}
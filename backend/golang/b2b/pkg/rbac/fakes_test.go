@@ -0,0 +1,41 @@
+package rbac
+
+import (
+	"context"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+// fakeSessions implements SessionsClient in memory for member-1 of org-1. Authenticate
+// records its parameters and fails with Err when it is set, and AuthenticateJWTLocal fails
+// with jwtErr.
+type fakeSessions struct {
+	testutil.Fake
+	jwtErr error
+
+	authenticateParams []*sessions.AuthenticateParams
+}
+
+func (f *fakeSessions) Authenticate(_ context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error) {
+	f.authenticateParams = append(f.authenticateParams, body)
+	if err := f.Record("Authenticate " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &sessions.AuthenticateResponse{
+		MemberSession: sessions.MemberSession{MemberSessionID: "session-1", MemberID: "member-1", OrganizationID: "org-1"},
+		Member:        organizations.Member{MemberID: "member-1", OrganizationID: "org-1"},
+		Organization:  organizations.Organization{OrganizationID: "org-1"},
+		StatusCode:    200,
+	}, nil
+}
+
+func (f *fakeSessions) AuthenticateJWTLocal(_ context.Context, _ string, _ time.Duration, _ *sessions.AuthorizationCheck) (*sessions.MemberSession, error) {
+	if f.jwtErr != nil {
+		return nil, f.jwtErr
+	}
+	return &sessions.MemberSession{MemberSessionID: "session-1", MemberID: "member-1", OrganizationID: "org-1"}, nil
+}
@@ -0,0 +1,211 @@
+package rbac
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/stytcherror"
+
+	"backend/golang/b2b/pkg/internal"
+)
+
+// Middleware authenticates the Stytch session attached to incoming requests and
+// enforces the RBAC permissions declared by individual routes.
+type Middleware struct {
+	api         API
+	cookieStore *internal.CookieStore
+}
+
+func NewMiddleware(api API, cookieStore *internal.CookieStore) *Middleware {
+	return &Middleware{api, cookieStore}
+}
+
+type contextKey string
+
+const sessionKey contextKey = "memberSession"
+
+// jwtMaxTokenAge is the maximum age of a session JWT that is trusted to name the
+// Organization of a session, matching the session middleware.
+const jwtMaxTokenAge = 5 * time.Minute
+
+// These constants name the middleware steps in error responses.
+const (
+	authenticateMethod = "RBAC.Authenticate"
+	authorizeMethod    = "RBAC.Authorize"
+)
+
+type authenticatedSession struct {
+	token string
+	resp  *sessions.AuthenticateResponse
+}
+
+// WithSession returns a copy of the context that carries an already authenticated session,
+// allowing middleware that authenticates sessions earlier in the chain to share the result.
+func WithSession(ctx context.Context, token string, session *sessions.AuthenticateResponse) context.Context {
+	return context.WithValue(ctx, sessionKey, authenticatedSession{token: token, resp: session})
+}
+
+// FromContext returns the session token and the authenticated member session that
+// Authenticate or Require attached to the request context.
+func FromContext(ctx context.Context) (token string, session *sessions.AuthenticateResponse, ok bool) {
+	s, ok := ctx.Value(sessionKey).(authenticatedSession)
+	if !ok {
+		return "", nil, false
+	}
+	return s.token, s.resp, true
+}
+
+// MemberFromContext returns the authenticated Member of the request, if any. When the
+// session was verified locally from its JWT, only the IDs of the Member are populated.
+func MemberFromContext(ctx context.Context) (organizations.Member, bool) {
+	_, session, ok := FromContext(ctx)
+	if !ok {
+		return organizations.Member{}, false
+	}
+	return session.Member, true
+}
+
+// OrganizationFromContext returns the Organization of the authenticated Member, if any. When
+// the session was verified locally from its JWT, only the ID and slug are populated.
+func OrganizationFromContext(ctx context.Context) (organizations.Organization, bool) {
+	_, session, ok := FromContext(ctx)
+	if !ok {
+		return organizations.Organization{}, false
+	}
+	return session.Organization, true
+}
+
+// Authenticate requires a valid Stytch session on the request. The session is
+// authenticated once and the resulting Member and Organization are stored in the
+// request context for downstream handlers.
+func (m *Middleware) Authenticate(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		r, ok := m.authenticate(w, r)
+		if !ok {
+			return
+		}
+		next(w, r)
+	}
+}
+
+// Require authenticates the request and uses Stytch's RBAC authorization check to verify
+// that the Member is permitted to perform the action on the resource within their
+// Organization. Both happen in a single Sessions.Authenticate call, whose response is stored
+// in the request context for downstream handlers. Requests without a valid session receive
+// a 401 response, and requests that are not permitted receive a 403 response.
+//
+// Resource IDs and actions are defined in the RBAC section of the Stytch Dashboard, for
+// example the default "stytch.member" resource and its "create" action. The check needs
+// the Organization of the session, which is read from the session that the session
+// middleware attached to the context, or else from the locally verified session JWT.
+func (m *Middleware) Require(resourceID, action string, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		st, ok := m.cookieStore.GetSession(r)
+		if !ok {
+			internal.SendErrorResponse(w, http.StatusUnauthorized, &internal.Response{
+				Method: authenticateMethod,
+				Error:  "No session token found",
+			})
+			return
+		}
+		organizationID, ok := m.organizationID(r)
+		if !ok {
+			internal.SendErrorResponse(w, http.StatusUnauthorized, &internal.Response{
+				Method: authenticateMethod,
+				Error:  "Session is invalid or has expired",
+			})
+			return
+		}
+
+		resp, err := m.api.Sessions.Authenticate(r.Context(), &sessions.AuthenticateParams{
+			SessionToken: st,
+			AuthorizationCheck: &sessions.AuthorizationCheck{
+				OrganizationID: organizationID,
+				ResourceID:     resourceID,
+				Action:         action,
+			},
+		})
+		if err != nil {
+			var stytchErr stytcherror.Error
+			if !errors.As(err, &stytchErr) {
+				internal.SendError(w, authorizeMethod, err)
+				return
+			}
+			switch stytchErr.StatusCode {
+			case http.StatusForbidden:
+				internal.SendErrorResponse(w, http.StatusForbidden, &internal.Response{
+					Method: authorizeMethod,
+					Error: fmt.Sprintf(
+						"Member is not permitted to perform action %q on resource %q",
+						action, resourceID,
+					),
+				})
+			case http.StatusUnauthorized, http.StatusNotFound:
+				log.Println(err)
+				internal.SendErrorResponse(w, http.StatusUnauthorized, &internal.Response{
+					Method: authenticateMethod,
+					Error:  "Session is invalid or has expired",
+				})
+			default:
+				internal.SendError(w, authorizeMethod, err)
+			}
+			return
+		}
+
+		next(w, r.WithContext(WithSession(r.Context(), st, resp)))
+	}
+}
+
+// organizationID returns the Organization of the request's session without calling the
+// Stytch API. The session middleware usually attached the session to the context already.
+func (m *Middleware) organizationID(r *http.Request) (string, bool) {
+	if _, session, ok := FromContext(r.Context()); ok {
+		return session.Organization.OrganizationID, true
+	}
+	jwt, ok := m.cookieStore.GetSessionJWT(r)
+	if !ok {
+		return "", false
+	}
+	memberSession, err := m.api.Sessions.AuthenticateJWTLocal(r.Context(), jwt, jwtMaxTokenAge, nil)
+	if err != nil {
+		return "", false
+	}
+	return memberSession.OrganizationID, true
+}
+
+// authenticate returns a copy of the request carrying the authenticated session in its
+// context. If the request context already holds a session, it is reused.
+func (m *Middleware) authenticate(w http.ResponseWriter, r *http.Request) (*http.Request, bool) {
+	if _, _, ok := FromContext(r.Context()); ok {
+		return r, true
+	}
+
+	st, ok := m.cookieStore.GetSession(r)
+	if !ok {
+		internal.SendErrorResponse(w, http.StatusUnauthorized, &internal.Response{
+			Method: authenticateMethod,
+			Error:  "No session token found",
+		})
+		return r, false
+	}
+
+	resp, err := m.api.Sessions.Authenticate(r.Context(), &sessions.AuthenticateParams{
+		SessionToken: st,
+	})
+	if err != nil {
+		log.Println(err)
+		internal.SendErrorResponse(w, http.StatusUnauthorized, &internal.Response{
+			Method: authenticateMethod,
+			Error:  "Session is invalid or has expired",
+		})
+		return r, false
+	}
+
+	return r.WithContext(WithSession(r.Context(), st, resp)), true
+}
@@ -0,0 +1,188 @@
+package rbac
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+// serve runs the request through the middleware returned by wrap, and reports the Member
+// that reached the next handler, if any.
+func serve(t *testing.T, f *fakeSessions, cookie, contextSession bool, wrap func(*Middleware, http.HandlerFunc) http.HandlerFunc) (*httptest.ResponseRecorder, string) {
+	t.Helper()
+	cs := testutil.NewCookieStore(t)
+	m := NewMiddleware(API{Sessions: f}, cs)
+	r := httptest.NewRequest(http.MethodPost, "/members/search", nil)
+	if cookie {
+		testutil.AddSession(r, cs)
+	}
+	if contextSession {
+		r = r.WithContext(WithSession(r.Context(), "session-token", &sessions.AuthenticateResponse{
+			Member:       organizations.Member{MemberID: "member-from-context", OrganizationID: "org-2"},
+			Organization: organizations.Organization{OrganizationID: "org-2"},
+		}))
+	}
+
+	var memberID string
+	rec := httptest.NewRecorder()
+	wrap(m, func(w http.ResponseWriter, r *http.Request) {
+		member, _ := MemberFromContext(r.Context())
+		memberID = member.MemberID
+	})(rec, r)
+	return rec, memberID
+}
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name               string
+		noCookie           bool
+		contextSession     bool
+		jwtErr             error
+		apiErr             error
+		wantStatus         int
+		wantErrorType      string
+		wantOrganizationID string
+	}{
+		{
+			name:               "permitted with JWT",
+			wantStatus:         http.StatusOK,
+			wantOrganizationID: "org-1",
+		},
+		{
+			name:               "permitted with context session",
+			contextSession:     true,
+			jwtErr:             testutil.StytchError(http.StatusUnauthorized, "jwt_invalid"),
+			wantStatus:         http.StatusOK,
+			wantOrganizationID: "org-2",
+		},
+		{
+			name:               "not permitted",
+			apiErr:             testutil.StytchError(http.StatusForbidden, "unauthorized_credentials"),
+			wantStatus:         http.StatusForbidden,
+			wantErrorType:      internal.ErrorTypeForbidden,
+			wantOrganizationID: "org-1",
+		},
+		{
+			name:               "revoked session",
+			apiErr:             testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus:         http.StatusUnauthorized,
+			wantErrorType:      internal.ErrorTypeUnauthorized,
+			wantOrganizationID: "org-1",
+		},
+		{
+			name:               "API error",
+			apiErr:             testutil.StytchError(http.StatusInternalServerError, "internal_server_error"),
+			wantStatus:         http.StatusBadGateway,
+			wantErrorType:      "internal_server_error",
+			wantOrganizationID: "org-1",
+		},
+		{
+			name:          "invalid JWT",
+			jwtErr:        testutil.StytchError(http.StatusUnauthorized, "jwt_invalid"),
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "no cookie",
+			noCookie:      true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeSessions{Fake: testutil.Fake{Err: tt.apiErr}, jwtErr: tt.jwtErr}
+			rec, memberID := serve(t, f, !tt.noCookie, tt.contextSession, func(m *Middleware, next http.HandlerFunc) http.HandlerFunc {
+				return m.Require("stytch.member", "search", next)
+			})
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if memberID != "member-1" {
+					t.Errorf("next handler saw member %q, want %q", memberID, "member-1")
+				}
+			} else if resp := testutil.Decode(t, rec); resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+
+			if tt.wantOrganizationID == "" {
+				if len(f.authenticateParams) != 0 {
+					t.Errorf("Authenticate() was called %d times, want 0", len(f.authenticateParams))
+				}
+				return
+			}
+			if len(f.authenticateParams) != 1 {
+				t.Fatalf("Authenticate() was called %d times, want 1", len(f.authenticateParams))
+			}
+			want := sessions.AuthorizationCheck{OrganizationID: tt.wantOrganizationID, ResourceID: "stytch.member", Action: "search"}
+			if got := f.authenticateParams[0].AuthorizationCheck; got == nil || *got != want {
+				t.Errorf("authorization check = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name           string
+		noCookie       bool
+		contextSession bool
+		apiErr         error
+		wantStatus     int
+		wantMemberID   string
+		wantCalls      int
+	}{
+		{
+			name:         "success",
+			wantStatus:   http.StatusOK,
+			wantMemberID: "member-1",
+			wantCalls:    1,
+		},
+		{
+			name:           "context session",
+			contextSession: true,
+			wantStatus:     http.StatusOK,
+			wantMemberID:   "member-from-context",
+		},
+		{
+			name:       "API error",
+			apiErr:     testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus: http.StatusUnauthorized,
+			wantCalls:  1,
+		},
+		{
+			name:       "no cookie",
+			noCookie:   true,
+			wantStatus: http.StatusUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeSessions{Fake: testutil.Fake{Err: tt.apiErr}}
+			rec, memberID := serve(t, f, !tt.noCookie, tt.contextSession, (*Middleware).Authenticate)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if memberID != tt.wantMemberID {
+				t.Errorf("next handler saw member %q, want %q", memberID, tt.wantMemberID)
+			}
+			if len(f.authenticateParams) != tt.wantCalls {
+				t.Errorf("Authenticate() was called %d times, want %d", len(f.authenticateParams), tt.wantCalls)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if resp := testutil.Decode(t, rec); resp.Method != authenticateMethod || resp.ErrorType() != internal.ErrorTypeUnauthorized {
+					t.Errorf("response = %q %q, want %q %q", resp.Method, resp.ErrorType(), authenticateMethod, internal.ErrorTypeUnauthorized)
+				}
+			}
+		})
+	}
+}
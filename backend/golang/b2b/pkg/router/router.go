@@ -0,0 +1,111 @@
+// Package router declares the HTTP routes of the backend in a table that is registered
+// on a ServeMux and can be inspected to generate documentation.
+package router
+
+import (
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"backend/golang/b2b/pkg/cors"
+	"backend/golang/b2b/pkg/internal"
+)
+
+// Route declares a single endpoint of the backend.
+type Route struct {
+	Method  string
+	Path    string
+	Summary string
+	Handler http.HandlerFunc
+
+	// Request is a zero value of the JSON request body, or nil if the route reads no body.
+	Request any
+	// Query lists the query parameters that the route reads.
+	Query []string
+
+	// Authenticated marks routes that require a member session.
+	Authenticated bool
+	// Permission is the RBAC permission that the member must have, if any. Routes with a
+	// permission are always authenticated.
+	Permission *Permission
+
+	// CORS, if set, overrides the default CORS policy for every method of the path.
+	CORS *cors.Policy
+}
+
+// Permission is an RBAC action on a resource, as defined in the Stytch Dashboard.
+type Permission struct {
+	ResourceID string
+	Action     string
+}
+
+// Pattern returns the ServeMux pattern of the route, for example "POST /logout". The
+// index route only matches "/" itself rather than every path.
+func (r Route) Pattern() string {
+	path := r.Path
+	if path == "/" {
+		path = "/{$}"
+	}
+	return r.Method + " " + path
+}
+
+// RegisterCORS registers the CORS policy overrides of the routes on the handler.
+func RegisterCORS(h *cors.Handler, routes []Route) {
+	for _, route := range routes {
+		if route.CORS != nil {
+			h.Route(route.Path, *route.CORS)
+		}
+	}
+}
+
+const routeMethod = "Router.Match"
+
+// Mux is the part of http.ServeMux that routes are registered on.
+type Mux interface {
+	HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request))
+}
+
+// Register registers every route on the mux using method patterns. Requests for a known
+// path with an unsupported method receive a 405 response with an Allow header, and
+// requests for unknown paths receive a 404 response, both in the shared error envelope.
+// Like ServeMux, Register panics on mistakes in the table: here, request types with
+// malformed `validate` tags.
+func Register(mux Mux, routes []Route) {
+	allowed := map[string][]string{}
+	for _, route := range routes {
+		if err := internal.CheckRules(route.Request); err != nil {
+			panic(fmt.Sprintf("router: %s: %v", route.Pattern(), err))
+		}
+		mux.HandleFunc(route.Pattern(), route.Handler)
+		allowed[route.Path] = append(allowed[route.Path], route.Method)
+	}
+
+	for path, methods := range allowed {
+		if slices.Contains(methods, http.MethodGet) && !slices.Contains(methods, http.MethodHead) {
+			methods = append(methods, http.MethodHead)
+		}
+		methods = append(methods, http.MethodOptions)
+		slices.Sort(methods)
+		allow := strings.Join(slices.Compact(methods), ", ")
+
+		pattern := path
+		if path == "/" {
+			pattern = "/{$}"
+		}
+		mux.HandleFunc(pattern, func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Allow", allow)
+			internal.SendErrorResponse(w, http.StatusMethodNotAllowed, &internal.Response{
+				Method: routeMethod,
+				Error:  fmt.Sprintf("Method %s is not allowed, use %s", r.Method, allow),
+			})
+		})
+	}
+
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		internal.SendErrorResponse(w, http.StatusNotFound, &internal.Response{
+			Method: routeMethod,
+			Error:  fmt.Sprintf("No route matches %s", r.URL.Path),
+		})
+	})
+}
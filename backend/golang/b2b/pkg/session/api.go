@@ -0,0 +1,41 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/intermediatesessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+)
+
+// API holds the clients of the Stytch B2B API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	DiscoveryIntermediateSessions DiscoveryIntermediateSessionsClient
+	Sessions                      SessionsClient
+}
+
+// NewAPI returns the clients of the Stytch B2B API that the controller uses.
+func NewAPI(client *b2bstytchapi.API) API {
+	return API{
+		DiscoveryIntermediateSessions: client.Discovery.IntermediateSessions,
+		Sessions:                      client.Sessions,
+	}
+}
+
+// DiscoveryIntermediateSessionsClient exchanges intermediate sessions for member sessions.
+// It is implemented by b2bstytchapi.API.Discovery.IntermediateSessions.
+type DiscoveryIntermediateSessionsClient interface {
+	Exchange(ctx context.Context, body *intermediatesessions.ExchangeParams) (*intermediatesessions.ExchangeResponse, error)
+}
+
+// SessionsClient authenticates, exchanges, lists and revokes member sessions.
+// It is implemented by b2bstytchapi.API.Sessions.
+type SessionsClient interface {
+	Authenticate(ctx context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error)
+	AuthenticateJWTLocal(ctx context.Context, token string, maxTokenAge time.Duration, authorizationCheck *sessions.AuthorizationCheck) (*sessions.MemberSession, error)
+	Exchange(ctx context.Context, body *sessions.ExchangeParams) (*sessions.ExchangeResponse, error)
+	Get(ctx context.Context, body *sessions.GetParams) (*sessions.GetResponse, error)
+	Revoke(ctx context.Context, body *sessions.RevokeParams, methodOptions ...*sessions.RevokeRequestOptions) (*sessions.RevokeResponse, error)
+}
@@ -1,16 +1,24 @@
 package session
 
 import (
-	"github.com/stytchauth/stytch-go/v16/stytch/b2b/b2bstytchapi"
+	"time"
 
 	"backend/golang/b2b/pkg/internal"
 )
 
 type Controller struct {
-	api         *b2bstytchapi.API
-	cookieStore *internal.CookieStore
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
+
+	// devices remembers which device last used each member session.
+	devices *deviceStore
 }
 
-func NewController(api *b2bstytchapi.API, cookieStore *internal.CookieStore) *Controller {
-	return &Controller{api, cookieStore}
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, redirector *internal.Redirector) *Controller {
+	devices := newDeviceStore(time.Duration(sessionDurationMinutes) * time.Minute)
+	return &Controller{api, cookieStore, sessionDurationMinutes, redirector, devices}
 }
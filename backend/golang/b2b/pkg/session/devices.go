@@ -0,0 +1,85 @@
+package session
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// device describes the browser that last used a member session.
+type device struct {
+	UserAgent  string
+	IPAddress  string
+	LastSeenAt time.Time
+}
+
+// deviceStore remembers the device of each member session that made a request to the
+// backend, because Stytch does not record the device of B2B sessions. Devices are only kept
+// in memory, so sessions that have not been used since the server started have none.
+//
+// Devices that have not been seen for longer than the session duration belong to sessions
+// that have expired, and are swept from the store as new requests are recorded.
+type deviceStore struct {
+	mu sync.Mutex
+	// devices maps member session IDs to the device that last used them.
+	devices map[string]device
+
+	ttl       time.Duration
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// deviceSweepInterval is how often devices of expired sessions are removed from the store.
+const deviceSweepInterval = time.Minute
+
+// newDeviceStore creates a device store that keeps devices for ttl after they were last
+// seen.
+func newDeviceStore(ttl time.Duration) *deviceStore {
+	return &deviceStore{devices: map[string]device{}, ttl: ttl, now: time.Now}
+}
+
+// record notes the device of a request made with the member session.
+func (s *deviceStore) record(sessionID string, r *http.Request) {
+	ip, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		ip = r.RemoteAddr
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.now()
+	s.devices[sessionID] = device{
+		UserAgent:  r.UserAgent(),
+		IPAddress:  ip,
+		LastSeenAt: now,
+	}
+	if now.Sub(s.lastSweep) >= deviceSweepInterval {
+		s.sweep(now)
+	}
+}
+
+// sweep removes the devices that have not been seen for longer than the ttl. The caller
+// must hold the lock.
+func (s *deviceStore) sweep(now time.Time) {
+	for sessionID, d := range s.devices {
+		if now.Sub(d.LastSeenAt) > s.ttl {
+			delete(s.devices, sessionID)
+		}
+	}
+	s.lastSweep = now
+}
+
+// get returns the device that last used the member session, if any.
+func (s *deviceStore) get(sessionID string) (device, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	d, ok := s.devices[sessionID]
+	return d, ok
+}
+
+// forget removes the device of a revoked member session.
+func (s *deviceStore) forget(sessionID string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.devices, sessionID)
+}
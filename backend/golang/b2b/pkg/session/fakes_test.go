@@ -0,0 +1,97 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/intermediatesessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+
+	"backend/golang/b2b/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory for member-1 of org-1, whose sessions
+// are listed in memberSessions. Each call is recorded and fails with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+	// jwtErr fails AuthenticateJWTLocal, for example because the JWT has expired.
+	jwtErr error
+
+	memberSessions []sessions.MemberSession
+	// sessionToken and sessionJWT are returned by the calls that issue a session. The
+	// intermediate session exchange returns no session token when MFA is required.
+	sessionToken string
+	sessionJWT   string
+	mfaRequired  bool
+
+	revoked []sessions.RevokeParams
+	// revokeToken is the session token that authorized the last Revoke call.
+	revokeToken string
+}
+
+func (f *fakeAPI) api() API {
+	return API{DiscoveryIntermediateSessions: fakeIntermediateSessions{f}, Sessions: f}
+}
+
+func (f *fakeAPI) memberSession(id string) sessions.MemberSession {
+	return sessions.MemberSession{MemberSessionID: id, MemberID: "member-1", OrganizationID: "org-1"}
+}
+
+func (f *fakeAPI) Authenticate(_ context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error) {
+	if err := f.Record("Authenticate " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &sessions.AuthenticateResponse{
+		MemberSession: f.memberSession("session-1"),
+		SessionToken:  f.sessionToken,
+		SessionJWT:    f.sessionJWT,
+		StatusCode:    200,
+	}, nil
+}
+
+func (f *fakeAPI) AuthenticateJWTLocal(_ context.Context, token string, _ time.Duration, _ *sessions.AuthorizationCheck) (*sessions.MemberSession, error) {
+	f.Calls = append(f.Calls, "AuthenticateJWTLocal "+token)
+	if f.jwtErr != nil {
+		return nil, f.jwtErr
+	}
+	s := f.memberSession("session-1")
+	return &s, nil
+}
+
+func (f *fakeAPI) Exchange(_ context.Context, body *sessions.ExchangeParams) (*sessions.ExchangeResponse, error) {
+	if err := f.Record("Exchange " + body.SessionToken + " " + body.OrganizationID); err != nil {
+		return nil, err
+	}
+	return &sessions.ExchangeResponse{SessionToken: f.sessionToken, SessionJWT: f.sessionJWT, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Get(_ context.Context, body *sessions.GetParams) (*sessions.GetResponse, error) {
+	if err := f.Record("Get " + body.OrganizationID + " " + body.MemberID); err != nil {
+		return nil, err
+	}
+	return &sessions.GetResponse{MemberSessions: f.memberSessions, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Revoke(_ context.Context, body *sessions.RevokeParams, methodOptions ...*sessions.RevokeRequestOptions) (*sessions.RevokeResponse, error) {
+	f.revokeToken = ""
+	if len(methodOptions) > 0 {
+		f.revokeToken = methodOptions[0].Authorization.SessionToken
+	}
+	if err := f.Record("Revoke"); err != nil {
+		return nil, err
+	}
+	f.revoked = append(f.revoked, *body)
+	return &sessions.RevokeResponse{StatusCode: 200}, nil
+}
+
+type fakeIntermediateSessions struct{ *fakeAPI }
+
+func (f fakeIntermediateSessions) Exchange(_ context.Context, body *intermediatesessions.ExchangeParams) (*intermediatesessions.ExchangeResponse, error) {
+	if err := f.Record("IntermediateSessions.Exchange " + body.IntermediateSessionToken + " " + body.OrganizationID); err != nil {
+		return nil, err
+	}
+	if f.mfaRequired {
+		return &intermediatesessions.ExchangeResponse{IntermediateSessionToken: "mfa-intermediate-session-token", StatusCode: 200}, nil
+	}
+	return &intermediatesessions.ExchangeResponse{SessionToken: f.sessionToken, SessionJWT: f.sessionJWT, StatusCode: 200}, nil
+}
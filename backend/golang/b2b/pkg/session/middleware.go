@@ -0,0 +1,101 @@
+package session
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/stytcherror"
+
+	"backend/golang/b2b/pkg/rbac"
+)
+
+// jwtMaxTokenAge is the maximum age of a session JWT that is accepted without checking the
+// session against the Stytch API. Stytch issues session JWTs that expire after five minutes.
+const jwtMaxTokenAge = 5 * time.Minute
+
+// Refresh authenticates the session cookie on every request. The session JWT is verified
+// locally first, which requires no call to Stytch. Once the JWT has expired, the session
+// token is authenticated against the Stytch API instead, which also detects sessions that
+// were revoked elsewhere and extends the session by the configured session duration. Sessions
+// therefore slide forward at most once per JWT lifetime of five minutes, not on every request.
+//
+// New session tokens and JWTs returned by Stytch replace the ones in the cookie, and the
+// cookie is cleared if the session is no longer valid, so that the handlers of the request
+// no longer see it. The authenticated session is attached to the request context so that the
+// RBAC middleware does not need to authenticate it again, and the device of the request is
+// recorded for the session list.
+func (c *Controller) Refresh(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		st, ok := c.cookieStore.GetSession(r)
+		if !ok || r.Method == http.MethodOptions {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		jwt, ok := c.cookieStore.GetSessionJWT(r)
+		if ok {
+			memberSession, err := c.api.Sessions.AuthenticateJWTLocal(r.Context(), jwt, jwtMaxTokenAge, nil)
+			if err == nil {
+				c.devices.record(memberSession.MemberSessionID, r)
+				next.ServeHTTP(w, r.WithContext(rbac.WithSession(r.Context(), st, sessionFromJWT(memberSession, st, jwt))))
+				return
+			}
+		}
+
+		resp, err := c.api.Sessions.Authenticate(r.Context(), &sessions.AuthenticateParams{
+			SessionToken:           st,
+			SessionDurationMinutes: c.sessionDurationMinutes,
+		})
+		if err != nil {
+			if isSessionRevoked(err) {
+				log.Println("Session is no longer valid, clearing session cookie")
+				c.cookieStore.ClearSession(w, r)
+			} else {
+				log.Println(err)
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		c.devices.record(resp.MemberSession.MemberSessionID, r)
+
+		// Rotate the cookie if Stytch issued a new session token or JWT.
+		if resp.SessionToken != st || resp.SessionJWT != jwt {
+			st = resp.SessionToken
+			c.cookieStore.StoreSession(w, r, st, resp.SessionJWT)
+		}
+
+		next.ServeHTTP(w, r.WithContext(rbac.WithSession(r.Context(), st, resp)))
+	})
+}
+
+// sessionFromJWT builds a session from the claims of a locally verified session JWT. Only the
+// IDs of the Member and Organization are known without calling the Stytch API.
+func sessionFromJWT(memberSession *sessions.MemberSession, sessionToken string, sessionJWT string) *sessions.AuthenticateResponse {
+	return &sessions.AuthenticateResponse{
+		MemberSession: *memberSession,
+		SessionToken:  sessionToken,
+		SessionJWT:    sessionJWT,
+		Member: organizations.Member{
+			MemberID:       memberSession.MemberID,
+			OrganizationID: memberSession.OrganizationID,
+		},
+		Organization: organizations.Organization{
+			OrganizationID: memberSession.OrganizationID,
+		},
+	}
+}
+
+// isSessionRevoked reports whether Stytch rejected the session because it was revoked or
+// has expired, as opposed to a transient failure reaching the API.
+func isSessionRevoked(err error) bool {
+	var stytchErr stytcherror.Error
+	if !errors.As(err, &stytchErr) {
+		return false
+	}
+	return stytchErr.StatusCode == http.StatusUnauthorized || stytchErr.StatusCode == http.StatusNotFound
+}
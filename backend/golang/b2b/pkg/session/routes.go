@@ -0,0 +1,61 @@
+package session
+
+import (
+	"net/http"
+
+	"backend/golang/b2b/pkg/router"
+)
+
+// Routes returns the Sessions routes.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/sessions/exchange",
+			Summary: "Exchange the current session for a session in another organization",
+			Handler: c.Exchange,
+			Request: exchangeRequest{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/session",
+			Summary: "Get the current session",
+			Handler: c.GetCurrentSession,
+		},
+		{
+			Method:        http.MethodGet,
+			Path:          "/sessions",
+			Summary:       "List the active sessions of the signed-in member",
+			Handler:       c.List,
+			Authenticated: true,
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/sessions/revoke",
+			Summary:       "Revoke a session of the signed-in member",
+			Handler:       c.Revoke,
+			Request:       revokeRequest{},
+			Authenticated: true,
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/sessions/revoke_others",
+			Summary:       "Revoke every session of the signed-in member except the current one",
+			Handler:       c.RevokeOthers,
+			Authenticated: true,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/logout",
+			Summary: "Log out",
+			Handler: c.Logout,
+		},
+		{
+			Method:        http.MethodPost,
+			Path:          "/logout/all",
+			Summary:       "Log out on every device",
+			Handler:       c.LogoutEverywhere,
+			Authenticated: true,
+		},
+	}
+}
@@ -1,20 +1,23 @@
 package session
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/stytchauth/stytch-go/v16/stytch/b2b/discovery/intermediatesessions"
 	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+	consumersessions "github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/methodoptions"
 
 	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/rbac"
 )
 
 const exchangeMethod = "Discovery.IntermediateSessions.Exchange"
 
 type exchangeRequest struct {
-	OrganizationID string `json:"organization_id"`
+	OrganizationID string `json:"organization_id" validate:"required"`
 }
 
 // Exchange exchanges an intermediate session token for a full session in the specified
@@ -23,9 +26,8 @@ type exchangeRequest struct {
 // Organization.
 func (c *Controller) Exchange(w http.ResponseWriter, r *http.Request) {
 	var req exchangeRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, exchangeMethod, err)
 		return
 	}
 
@@ -38,28 +40,28 @@ func (c *Controller) Exchange(w http.ResponseWriter, r *http.Request) {
 
 		token, ok = c.cookieStore.GetSession(r)
 		if !ok {
-			log.Println("No session or intermediate session cookie found")
-			w.WriteHeader(http.StatusBadRequest)
+			internal.SendError(w, exchangeMethod, internal.ErrNoSessionOrIntermediateSession)
 			return
 		}
 	}
 
 	if istUsed {
-		resp, err := c.api.Discovery.IntermediateSessions.Exchange(r.Context(), &intermediatesessions.ExchangeParams{
+		resp, err := c.api.DiscoveryIntermediateSessions.Exchange(r.Context(), &intermediatesessions.ExchangeParams{
 			OrganizationID:           req.OrganizationID,
 			IntermediateSessionToken: token,
+			SessionDurationMinutes:   c.sessionDurationMinutes,
 		})
 		if err != nil {
-			internal.SendResponse(w, &internal.Response{
-				Method: exchangeMethod,
-				Error:  err.Error(),
-			})
+			internal.SendError(w, exchangeMethod, err)
 			return
 		}
 
-		c.cookieStore.StoreSession(w, r, resp.SessionToken)
+		c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
 		c.cookieStore.ClearIntermediateSession(w, r)
 
+		// Members that still have to complete MFA receive an intermediate session instead.
+		redirectURL := c.redirector.Next(w, r, resp.SessionToken != "")
+
 		internal.SendResponse(w, &internal.Response{
 			Method:      exchangeMethod,
 			APIResponse: resp,
@@ -68,23 +70,26 @@ func (c *Controller) Exchange(w http.ResponseWriter, r *http.Request) {
 	&intermediatesessions.ExchangeParams{
 		OrganizationID:           req.OrganizationID,
 		IntermediateSessionToken: token,
+		SessionDurationMinutes:   c.sessionDurationMinutes,
 	},
 )`,
+			Metadata: map[string]any{
+				"redirectURL": redirectURL,
+			},
 		})
 
 	} else {
 		resp, err := c.api.Sessions.Exchange(r.Context(), &sessions.ExchangeParams{
-			OrganizationID: req.OrganizationID,
-			SessionToken:   token,
+			OrganizationID:         req.OrganizationID,
+			SessionToken:           token,
+			SessionDurationMinutes: c.sessionDurationMinutes,
 		})
 		if err != nil {
-			internal.SendResponse(w, &internal.Response{
-				Method: exchangeMethod,
-				Error:  err.Error(),
-			})
+			internal.SendError(w, exchangeMethod, err)
 			return
 		}
-		c.cookieStore.StoreSession(w, r, resp.SessionToken)
+		c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+		redirectURL := c.redirector.Next(w, r, true)
 
 		internal.SendResponse(w, &internal.Response{
 			Method:      exchangeMethod,
@@ -92,10 +97,14 @@ func (c *Controller) Exchange(w http.ResponseWriter, r *http.Request) {
 			CodeSnippet: `resp, err := c.api.Sessions.Exchange(
 	r.Context(),
 	&sessions.ExchangeParams{
-		OrganizationID: req.OrganizationID,
-		SessionToken:   token,
+		OrganizationID:         req.OrganizationID,
+		SessionToken:           token,
+		SessionDurationMinutes: c.sessionDurationMinutes,
 	},
 )`,
+			Metadata: map[string]any{
+				"redirectURL": redirectURL,
+			},
 		})
 	}
 }
@@ -106,8 +115,7 @@ func (c *Controller) Exchange(w http.ResponseWriter, r *http.Request) {
 func (c *Controller) GetCurrentSession(w http.ResponseWriter, r *http.Request) {
 	st, ok := c.cookieStore.GetSession(r)
 	if !ok {
-		log.Println("No session token found")
-		w.WriteHeader(http.StatusBadRequest)
+		internal.SendError(w, "Session.GetCurrentSession", internal.ErrNoSession)
 		return
 	}
 
@@ -115,10 +123,8 @@ func (c *Controller) GetCurrentSession(w http.ResponseWriter, r *http.Request) {
 		SessionToken: st,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: "Session.GetCurrentSession",
-			Error:  err.Error(),
-		})
+		internal.SendError(w, "Session.GetCurrentSession", err)
+		return
 	}
 
 	internal.SendResponse(w, &internal.Response{
@@ -139,8 +145,7 @@ session, err := c.api.Sessions.Authenticate(
 func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
 	st, ok := c.cookieStore.GetSession(r)
 	if !ok {
-		log.Println("No session token found")
-		w.WriteHeader(http.StatusBadRequest)
+		internal.SendError(w, "Session.Revoke", internal.ErrNoSession)
 		return
 	}
 
@@ -149,10 +154,7 @@ func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: "Session.Revoke",
-			Error:  err.Error(),
-		})
+		internal.SendError(w, "Session.Revoke", err)
 		return
 	}
 
@@ -170,3 +172,262 @@ func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
 )`,
 	})
 }
+
+// LogoutEverywhere revokes all of the requester's sessions in Stytch and deletes the
+// server-side session values of every device, logging the member out everywhere.
+func (c *Controller) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	st, session, ok := rbac.FromContext(r.Context())
+	if !ok {
+		internal.SendError(w, "Session.Revoke", internal.ErrNoSession)
+		return
+	}
+
+	resp, err := c.api.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
+		MemberID: session.Member.MemberID,
+	}, &sessions.RevokeRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, "Session.Revoke", err)
+		return
+	}
+
+	c.cookieStore.ClearAllSessions(session.Member.MemberID)
+	c.cookieStore.ClearSession(w, r)
+	c.cookieStore.ClearIntermediateSession(w, r)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      "Session.Revoke",
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Sessions.Revoke(
+	r.Context(),
+	&sessions.RevokeParams{
+		MemberID: session.Member.MemberID,
+	},
+	&sessions.RevokeRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	},
+)`,
+	})
+}
+
+const (
+	listMethod         = "Sessions.List"
+	revokeMethod       = "Sessions.Revoke"
+	revokeOthersMethod = "Sessions.RevokeOthers"
+)
+
+var errSessionNotFound = internal.NewError(http.StatusNotFound, internal.ErrorTypeNotFound,
+	"The signed-in member has no active session with this ID")
+
+// sessionSummary describes an active session on the session management page.
+type sessionSummary struct {
+	SessionID string `json:"session_id"`
+	// Current is true for the session of the request.
+	Current bool `json:"current"`
+	// UserAgent and IPAddress describe the device that last used the session. They are empty
+	// for sessions that have not been used since the server started.
+	UserAgent string `json:"user_agent,omitempty"`
+	IPAddress string `json:"ip_address,omitempty"`
+	// AuthenticationFactors uses the consumer type, which the SDK shares with B2B sessions.
+	AuthenticationFactors []consumersessions.AuthenticationFactor `json:"authentication_factors"`
+	StartedAt             *time.Time                              `json:"started_at"`
+	LastActiveAt          *time.Time                              `json:"last_active_at"`
+	ExpiresAt             *time.Time                              `json:"expires_at"`
+}
+
+// summarize describes the member sessions, combining Stytch's session data with the devices
+// recorded by the Refresh middleware. The last activity is the later of the two.
+func (c *Controller) summarize(memberSessions []sessions.MemberSession, currentID string) []sessionSummary {
+	summaries := make([]sessionSummary, 0, len(memberSessions))
+	for _, s := range memberSessions {
+		summary := sessionSummary{
+			SessionID:             s.MemberSessionID,
+			Current:               s.MemberSessionID == currentID,
+			AuthenticationFactors: s.AuthenticationFactors,
+			StartedAt:             s.StartedAt,
+			LastActiveAt:          s.LastAccessedAt,
+			ExpiresAt:             s.ExpiresAt,
+		}
+		if d, ok := c.devices.get(s.MemberSessionID); ok {
+			summary.UserAgent = d.UserAgent
+			summary.IPAddress = d.IPAddress
+			if summary.LastActiveAt == nil || d.LastSeenAt.After(*summary.LastActiveAt) {
+				summary.LastActiveAt = &d.LastSeenAt
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// List wraps Stytch's Get Sessions endpoint and returns the active sessions of the signed-in
+// member, with the device, IP address, authentication factors and last activity of each.
+func (c *Controller) List(w http.ResponseWriter, r *http.Request) {
+	_, session, ok := rbac.FromContext(r.Context())
+	if !ok {
+		internal.SendError(w, listMethod, internal.ErrNoSession)
+		return
+	}
+
+	resp, err := c.api.Sessions.Get(r.Context(), &sessions.GetParams{
+		OrganizationID: session.MemberSession.OrganizationID,
+		MemberID:       session.MemberSession.MemberID,
+	})
+	if err != nil {
+		internal.SendError(w, listMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      listMethod,
+		APIResponse: c.summarize(resp.MemberSessions, session.MemberSession.MemberSessionID),
+		CodeSnippet: `resp, err := c.api.Sessions.Get(
+	r.Context(),
+	&sessions.GetParams{
+		OrganizationID: session.MemberSession.OrganizationID,
+		MemberID:       session.MemberSession.MemberID,
+	},
+)`,
+	})
+}
+
+type revokeRequest struct {
+	SessionID string `json:"session_id" validate:"required"`
+}
+
+// Revoke wraps Stytch's Revoke Session endpoint and revokes one session of the signed-in
+// member, such as a session on a lost device. Stytch revokes sessions by ID alone, so the ID
+// is checked against the member's own sessions first. Revoking the current session logs the
+// member out like Logout.
+func (c *Controller) Revoke(w http.ResponseWriter, r *http.Request) {
+	var req revokeRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, revokeMethod, err)
+		return
+	}
+
+	st, session, ok := rbac.FromContext(r.Context())
+	if !ok {
+		internal.SendError(w, revokeMethod, internal.ErrNoSession)
+		return
+	}
+
+	list, err := c.api.Sessions.Get(r.Context(), &sessions.GetParams{
+		OrganizationID: session.MemberSession.OrganizationID,
+		MemberID:       session.MemberSession.MemberID,
+	})
+	if err != nil {
+		internal.SendError(w, revokeMethod, err)
+		return
+	}
+	owned := slices.ContainsFunc(list.MemberSessions, func(s sessions.MemberSession) bool {
+		return s.MemberSessionID == req.SessionID
+	})
+	if !owned {
+		internal.SendError(w, revokeMethod, errSessionNotFound)
+		return
+	}
+
+	resp, err := c.api.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
+		MemberSessionID: req.SessionID,
+	}, &sessions.RevokeRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	})
+	if err != nil {
+		internal.SendError(w, revokeMethod, err)
+		return
+	}
+	c.devices.forget(req.SessionID)
+
+	current := req.SessionID == session.MemberSession.MemberSessionID
+	if current {
+		c.cookieStore.ClearSession(w, r)
+		c.cookieStore.ClearIntermediateSession(w, r)
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      revokeMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Sessions.Revoke(
+	r.Context(),
+	&sessions.RevokeParams{
+		MemberSessionID: req.SessionID,
+	},
+	&sessions.RevokeRequestOptions{
+		Authorization: methodoptions.Authorization{SessionToken: st},
+	},
+)`,
+		Metadata: map[string]any{
+			"loggedOut": current,
+		},
+	})
+}
+
+// RevokeOthers revokes every session of the signed-in member except the current one, logging
+// the member out on all other devices. Stytch revokes sessions one at a time, so each of the
+// member's other sessions is revoked in turn, and the revoked sessions are returned.
+func (c *Controller) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	st, session, ok := rbac.FromContext(r.Context())
+	if !ok {
+		internal.SendError(w, revokeOthersMethod, internal.ErrNoSession)
+		return
+	}
+
+	resp, err := c.api.Sessions.Get(r.Context(), &sessions.GetParams{
+		OrganizationID: session.MemberSession.OrganizationID,
+		MemberID:       session.MemberSession.MemberID,
+	})
+	if err != nil {
+		internal.SendError(w, revokeOthersMethod, err)
+		return
+	}
+
+	revoked := []sessionSummary{}
+	for _, summary := range c.summarize(resp.MemberSessions, session.MemberSession.MemberSessionID) {
+		if summary.Current {
+			continue
+		}
+		_, err := c.api.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
+			MemberSessionID: summary.SessionID,
+		}, &sessions.RevokeRequestOptions{
+			Authorization: methodoptions.Authorization{SessionToken: st},
+		})
+		if err != nil {
+			internal.SendError(w, revokeOthersMethod, err)
+			return
+		}
+		c.devices.forget(summary.SessionID)
+		revoked = append(revoked, summary)
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      revokeOthersMethod,
+		APIResponse: revoked,
+		CodeSnippet: `resp, err := c.api.Sessions.Get(
+	r.Context(),
+	&sessions.GetParams{
+		OrganizationID: session.MemberSession.OrganizationID,
+		MemberID:       session.MemberSession.MemberID,
+	},
+)
+
+for _, s := range resp.MemberSessions {
+	if s.MemberSessionID == session.MemberSession.MemberSessionID {
+		continue
+	}
+	_, err := c.api.Sessions.Revoke(
+		r.Context(),
+		&sessions.RevokeParams{
+			MemberSessionID: s.MemberSessionID,
+		},
+		&sessions.RevokeRequestOptions{
+			Authorization: methodoptions.Authorization{SessionToken: st},
+		},
+	)
+}`,
+		Metadata: map[string]any{
+			"revokedSessions": len(revoked),
+		},
+	})
+}
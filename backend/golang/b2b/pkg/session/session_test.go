@@ -0,0 +1,497 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+
+	"backend/golang/b2b/pkg/internal"
+	"backend/golang/b2b/pkg/internal/testutil"
+	"backend/golang/b2b/pkg/stytchtest"
+)
+
+func TestExchange(t *testing.T) {
+	tests := []struct {
+		name            string
+		body            string
+		cookie          func(*http.Request, *internal.CookieStore)
+		mfaRequired     bool
+		apiErr          error
+		wantStatus      int
+		wantErrorType   string
+		wantCalls       []string
+		wantRedirectURL string
+	}{
+		{
+			name:            "intermediate session",
+			body:            `{"organization_id": "org-2"}`,
+			cookie:          testutil.AddIntermediateSession,
+			wantStatus:      http.StatusOK,
+			wantCalls:       []string{"IntermediateSessions.Exchange intermediate-session-token org-2"},
+			wantRedirectURL: testutil.SuccessURL,
+		},
+		{
+			name:            "intermediate session with MFA required",
+			body:            `{"organization_id": "org-2"}`,
+			cookie:          testutil.AddIntermediateSession,
+			mfaRequired:     true,
+			wantStatus:      http.StatusOK,
+			wantCalls:       []string{"IntermediateSessions.Exchange intermediate-session-token org-2"},
+			wantRedirectURL: testutil.MFARequiredURL,
+		},
+		{
+			name:            "session",
+			body:            `{"organization_id": "org-2"}`,
+			cookie:          testutil.AddSession,
+			wantStatus:      http.StatusOK,
+			wantCalls:       []string{"Exchange session-token org-2"},
+			wantRedirectURL: testutil.SuccessURL,
+		},
+		{
+			name:          "API error",
+			body:          `{"organization_id": "org-2"}`,
+			cookie:        testutil.AddSession,
+			apiErr:        testutil.StytchError(http.StatusBadRequest, "invalid_organization_id"),
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: "invalid_organization_id",
+			wantCalls:     []string{"Exchange session-token org-2"},
+		},
+		{
+			name:          "no cookie",
+			body:          `{"organization_id": "org-2"}`,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			body:          `{"organization_id": ""}`,
+			cookie:        testutil.AddSession,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}, mfaRequired: tt.mfaRequired, sessionToken: "new-session-token", sessionJWT: "new-session-jwt"}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/sessions/exchange", tt.body)
+			if tt.cookie != nil {
+				tt.cookie(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.Exchange(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.Method != exchangeMethod {
+				t.Errorf("method = %q, want %q", resp.Method, exchangeMethod)
+			}
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.Calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", f.Calls, tt.wantCalls)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := resp.Metadata["redirectURL"]; got != tt.wantRedirectURL {
+				t.Errorf("redirectURL = %v, want %q", got, tt.wantRedirectURL)
+			}
+			if st, _ := env.CookieStore.GetSession(testutil.ResponseCookies(rec)); !tt.mfaRequired && st != "new-session-token" {
+				t.Errorf("stored session = %q, want %q", st, "new-session-token")
+			}
+		})
+	}
+}
+
+func TestGetCurrentSession(t *testing.T) {
+	tests := []struct {
+		name          string
+		noCookie      bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "session_not_found",
+		},
+		{
+			name:          "no cookie",
+			noCookie:      true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/session", nil)
+			if !tt.noCookie {
+				testutil.AddSession(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.GetCurrentSession(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if resp := testutil.Decode(t, rec); resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		name          string
+		noCookie      bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+	}{
+		{
+			name:        "success",
+			wantStatus:  http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{{SessionToken: "session-token"}},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "session_not_found",
+		},
+		{
+			name:          "no cookie",
+			noCookie:      true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			if !tt.noCookie {
+				testutil.AddSession(r, env.CookieStore)
+				testutil.AddIntermediateSession(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.Logout(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if resp := testutil.Decode(t, rec); resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			cleared := len(rec.Result().Cookies()) == 2
+			if want := tt.wantStatus == http.StatusOK; cleared != want {
+				t.Errorf("cookies cleared = %t, want %t", cleared, want)
+			}
+		})
+	}
+}
+
+func TestLogoutEverywhere(t *testing.T) {
+	tests := []struct {
+		name          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+	}{
+		{
+			name:        "success",
+			wantStatus:  http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{{MemberID: "member-1"}},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusForbidden, "unauthorized_action"),
+			wantStatus:    http.StatusForbidden,
+			wantErrorType: "unauthorized_action",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			r := httptest.NewRequest(http.MethodPost, "/logout/all", nil)
+			if !tt.noSession {
+				r = stytchtest.WithMemberSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.LogoutEverywhere(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if resp := testutil.Decode(t, rec); resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			if tt.wantRevoked != nil && f.revokeToken != "session-token" {
+				t.Errorf("Revoke() authorized by %q, want %q", f.revokeToken, "session-token")
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "member_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "member_not_found",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			f.memberSessions = []sessions.MemberSession{f.memberSession("session-1"), f.memberSession("session-2")}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			c.devices.record("session-2", &http.Request{RemoteAddr: "192.0.2.1:1234", Header: http.Header{"User-Agent": {"Phone"}}})
+			r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+			if !tt.noSession {
+				r = stytchtest.WithMemberSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.List(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var summaries []sessionSummary
+			if err := json.Unmarshal(resp.StytchResponse, &summaries); err != nil || len(summaries) != 2 {
+				t.Fatalf("sessions = %s, want 2 sessions", resp.StytchResponse)
+			}
+			if !summaries[0].Current || summaries[1].Current {
+				t.Errorf("current sessions = %t, %t, want true, false", summaries[0].Current, summaries[1].Current)
+			}
+			if summaries[1].UserAgent != "Phone" || summaries[1].IPAddress != "192.0.2.1" || summaries[1].LastActiveAt == nil {
+				t.Errorf("device of session-2 = %+v, want the recorded device", summaries[1])
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+		wantLoggedOut bool
+	}{
+		{
+			name:        "other session",
+			body:        `{"session_id": "session-2"}`,
+			wantStatus:  http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{{MemberSessionID: "session-2"}},
+		},
+		{
+			name:          "current session",
+			body:          `{"session_id": "session-1"}`,
+			wantStatus:    http.StatusOK,
+			wantRevoked:   []sessions.RevokeParams{{MemberSessionID: "session-1"}},
+			wantLoggedOut: true,
+		},
+		{
+			name:          "session of another member",
+			body:          `{"session_id": "session-of-member-2"}`,
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: internal.ErrorTypeNotFound,
+		},
+		{
+			name:          "API error",
+			body:          `{"session_id": "session-2"}`,
+			apiErr:        testutil.StytchError(http.StatusInternalServerError, "internal_server_error"),
+			wantStatus:    http.StatusBadGateway,
+			wantErrorType: "internal_server_error",
+		},
+		{
+			name:          "no session",
+			body:          `{"session_id": "session-2"}`,
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			body:          `{"session": "session-2"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			f.memberSessions = []sessions.MemberSession{f.memberSession("session-1"), f.memberSession("session-2")}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/sessions/revoke", tt.body)
+			testutil.AddSession(r, env.CookieStore)
+			if !tt.noSession {
+				r = stytchtest.WithMemberSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.Revoke(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := resp.Metadata["loggedOut"]; got != tt.wantLoggedOut {
+				t.Errorf("loggedOut = %v, want %t", got, tt.wantLoggedOut)
+			}
+			if cleared := len(rec.Result().Cookies()) > 0; cleared != tt.wantLoggedOut {
+				t.Errorf("cookies cleared = %t, want %t", cleared, tt.wantLoggedOut)
+			}
+		})
+	}
+}
+
+func TestRevokeOthers(t *testing.T) {
+	tests := []struct {
+		name          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{
+				{MemberSessionID: "session-2"},
+				{MemberSessionID: "session-3"},
+			},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusTooManyRequests, "too_many_requests"),
+			wantStatus:    http.StatusTooManyRequests,
+			wantErrorType: "too_many_requests",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			f.memberSessions = []sessions.MemberSession{f.memberSession("session-1"), f.memberSession("session-2"), f.memberSession("session-3")}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			r := httptest.NewRequest(http.MethodPost, "/sessions/revoke_others", nil)
+			if !tt.noSession {
+				r = stytchtest.WithMemberSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.RevokeOthers(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			if tt.wantStatus == http.StatusOK && resp.Metadata["revokedSessions"] != float64(len(tt.wantRevoked)) {
+				t.Errorf("revokedSessions = %v, want %d", resp.Metadata["revokedSessions"], len(tt.wantRevoked))
+			}
+			if tt.wantStatus == http.StatusOK {
+				var summaries []sessionSummary
+				if err := json.Unmarshal(resp.StytchResponse, &summaries); err != nil {
+					t.Fatalf("decoding revoked sessions: %v", err)
+				}
+				if len(summaries) != len(tt.wantRevoked) {
+					t.Fatalf("revoked sessions = %+v, want %d", summaries, len(tt.wantRevoked))
+				}
+				for i, summary := range summaries {
+					if summary.SessionID != tt.wantRevoked[i].MemberSessionID {
+						t.Errorf("revoked session %d = %q, want %q", i, summary.SessionID, tt.wantRevoked[i].MemberSessionID)
+					}
+				}
+			}
+		})
+	}
+}
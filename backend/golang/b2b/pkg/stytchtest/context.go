@@ -0,0 +1,20 @@
+package stytchtest
+
+import (
+	"net/http"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/organizations"
+	"github.com/stytchauth/stytch-go/v16/stytch/b2b/sessions"
+
+	"backend/golang/b2b/pkg/rbac"
+)
+
+// WithMemberSession attaches session-1 of member-1 in org-1 to the request, as the session
+// and RBAC middleware do, so that handlers can be tested without a server.
+func WithMemberSession(r *http.Request) *http.Request {
+	return r.WithContext(rbac.WithSession(r.Context(), "session-token", &sessions.AuthenticateResponse{
+		MemberSession: sessions.MemberSession{MemberSessionID: "session-1", MemberID: "member-1", OrganizationID: "org-1"},
+		Member:        organizations.Member{MemberID: "member-1", OrganizationID: "org-1"},
+		Organization:  organizations.Organization{OrganizationID: "org-1"},
+	}))
+}
@@ -0,0 +1,468 @@
+package stytchtest
+
+import (
+	"net/http"
+	"slices"
+	"time"
+)
+
+type handlerFunc func(r *http.Request) (map[string]any, *apiError)
+
+// routes maps the method and path of each implemented Stytch endpoint to its handler.
+func (s *Server) routes() map[string]handlerFunc {
+	return map[string]handlerFunc{
+		"POST /v1/b2b/magic_links/email/login_or_signup":        s.loginOrSignup,
+		"POST /v1/b2b/magic_links/email/invite":                 s.invite,
+		"POST /v1/b2b/magic_links/email/discovery/send":         s.discoverySend,
+		"POST /v1/b2b/magic_links/authenticate":                 s.magicLinksAuthenticate,
+		"POST /v1/b2b/magic_links/discovery/authenticate":       s.magicLinksDiscoveryAuthenticate,
+		"POST /v1/b2b/oauth/authenticate":                       s.oauthAuthenticate,
+		"POST /v1/b2b/oauth/discovery/authenticate":             s.oauthDiscoveryAuthenticate,
+		"POST /v1/b2b/discovery/organizations":                  s.discoveryOrganizationsList,
+		"POST /v1/b2b/discovery/organizations/create":           s.discoveryOrganizationsCreate,
+		"POST /v1/b2b/discovery/intermediate_sessions/exchange": s.intermediateSessionsExchange,
+		"POST /v1/b2b/sessions/authenticate":                    s.sessionsAuthenticate,
+		"POST /v1/b2b/sessions/exchange":                        s.sessionsExchange,
+		"POST /v1/b2b/sessions/revoke":                          s.sessionsRevoke,
+		"GET /v1/b2b/sessions":                                  s.sessionsGet,
+	}
+}
+
+func (s *Server) loginOrSignup(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		OrganizationID    string `json:"organization_id"`
+		EmailAddress      string `json:"email_address"`
+		LoginRedirectURL  string `json:"login_redirect_url"`
+		SignupRedirectURL string `json:"signup_redirect_url"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	org, ok := s.organizations[req.OrganizationID]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "organization_not_found", "Organization could not be found.")
+	}
+
+	m := s.memberByEmail(org.ID, req.EmailAddress)
+	created := m == nil
+	redirectURL := req.LoginRedirectURL
+	if created {
+		m = s.createMember(org.ID, req.EmailAddress, memberPending)
+		redirectURL = req.SignupRedirectURL
+	}
+	t := s.issueToken(tokenMagicLink, m.EmailAddress, org.ID)
+	s.sendMessage(MessageLoginOrSignup, m.EmailAddress, org.ID, t, "multi_tenant_magic_links", redirectURL)
+
+	return map[string]any{
+		"member_id":      m.ID,
+		"member_created": created,
+		"member":         m.json(),
+		"organization":   org.json(),
+	}, nil
+}
+
+func (s *Server) invite(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		OrganizationID    string   `json:"organization_id"`
+		EmailAddress      string   `json:"email_address"`
+		Name              string   `json:"name"`
+		Roles             []string `json:"roles"`
+		InviteRedirectURL string   `json:"invite_redirect_url"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	org, ok := s.organizations[req.OrganizationID]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "organization_not_found", "Organization could not be found.")
+	}
+
+	m := s.memberByEmail(org.ID, req.EmailAddress)
+	if m != nil && m.Status == memberActive {
+		return nil, newError(http.StatusBadRequest, "member_already_active", "The member is already active in the organization.")
+	}
+	if m == nil {
+		m = s.createMember(org.ID, req.EmailAddress, memberInvited)
+	}
+	m.Name = req.Name
+	m.Roles = req.Roles
+	t := s.issueToken(tokenMagicLink, m.EmailAddress, org.ID)
+	s.sendMessage(MessageInvite, m.EmailAddress, org.ID, t, "multi_tenant_magic_links", req.InviteRedirectURL)
+
+	return map[string]any{
+		"member_id":    m.ID,
+		"member":       m.json(),
+		"organization": org.json(),
+	}, nil
+}
+
+func (s *Server) discoverySend(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		EmailAddress         string `json:"email_address"`
+		DiscoveryRedirectURL string `json:"discovery_redirect_url"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if req.EmailAddress == "" {
+		return nil, newError(http.StatusBadRequest, "invalid_email", "email_address is required.")
+	}
+	t := s.issueToken(tokenDiscovery, req.EmailAddress, "")
+	s.sendMessage(MessageDiscovery, req.EmailAddress, "", t, "discovery", req.DiscoveryRedirectURL)
+	return map[string]any{}, nil
+}
+
+func (s *Server) magicLinksAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		MagicLinksToken        string `json:"magic_links_token"`
+		SessionDurationMinutes int32  `json:"session_duration_minutes"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	t, err := s.redeemToken(req.MagicLinksToken, tokenMagicLink)
+	if err != nil {
+		return nil, err
+	}
+	return s.authenticateMember(t, req.SessionDurationMinutes, "magic_link")
+}
+
+func (s *Server) oauthAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		OAuthToken             string `json:"oauth_token"`
+		SessionDurationMinutes int32  `json:"session_duration_minutes"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	t, err := s.redeemToken(req.OAuthToken, tokenOAuth)
+	if err != nil {
+		return nil, newError(http.StatusNotFound, "oauth_token_not_found", "The OAuth token could not be found, it may have already been used.")
+	}
+	resp, err := s.authenticateMember(t, req.SessionDurationMinutes, "oauth")
+	if err != nil {
+		return nil, err
+	}
+	resp["provider_type"] = "Google"
+	resp["provider_subject"] = "provider-subject-test-" + t.EmailAddress
+	return resp, nil
+}
+
+// authenticateMember completes a login for the member of a redeemed token, creating the
+// member if it does not exist yet, and starts a session.
+func (s *Server) authenticateMember(t *token, minutes int32, factorType string) (map[string]any, *apiError) {
+	org, ok := s.organizations[t.OrganizationID]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "organization_not_found", "Organization could not be found.")
+	}
+	m := s.memberByEmail(org.ID, t.EmailAddress)
+	if m == nil {
+		m = s.createMember(org.ID, t.EmailAddress, memberActive)
+	}
+	m.Status = memberActive
+
+	sess := s.createSession(m, minutes, factorType)
+	return map[string]any{
+		"member_id":                  m.ID,
+		"method_id":                  "",
+		"reset_sessions":             false,
+		"organization_id":            org.ID,
+		"member":                     m.json(),
+		"member_session":             sess.json(),
+		"session_token":              sess.Token,
+		"session_jwt":                sess.JWT,
+		"organization":               org.json(),
+		"intermediate_session_token": "",
+		"member_authenticated":       true,
+	}, nil
+}
+
+func (s *Server) magicLinksDiscoveryAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		DiscoveryMagicLinksToken string `json:"discovery_magic_links_token"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	t, err := s.redeemToken(req.DiscoveryMagicLinksToken, tokenDiscovery)
+	if err != nil {
+		return nil, err
+	}
+	return s.startDiscovery(t.EmailAddress), nil
+}
+
+func (s *Server) oauthDiscoveryAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		DiscoveryOAuthToken string `json:"discovery_oauth_token"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	t, err := s.redeemToken(req.DiscoveryOAuthToken, tokenDiscoveryOAuth)
+	if err != nil {
+		return nil, newError(http.StatusNotFound, "oauth_token_not_found", "The OAuth token could not be found, it may have already been used.")
+	}
+	resp := s.startDiscovery(t.EmailAddress)
+	resp["provider_type"] = "Google"
+	return resp, nil
+}
+
+// startDiscovery issues an intermediate session for the email address and lists the
+// organizations that it can join.
+func (s *Server) startDiscovery(emailAddress string) map[string]any {
+	ist := "intermediate-session-token-test-" + randomID()
+	s.intermediateSessions[ist] = emailAddress
+	return map[string]any{
+		"intermediate_session_token": ist,
+		"email_address":              emailAddress,
+		"discovered_organizations":   s.discoveredOrganizations(emailAddress),
+	}
+}
+
+func (s *Server) discoveredOrganizations(emailAddress string) []any {
+	discovered := []any{}
+	for _, m := range s.members {
+		if m.EmailAddress != emailAddress {
+			continue
+		}
+		discovered = append(discovered, map[string]any{
+			"organization": s.organizations[m.OrganizationID].json(),
+			"membership": map[string]any{
+				"type":   m.Status + "_member",
+				"member": m.json(),
+			},
+			"member_authenticated": false,
+		})
+	}
+	return discovered
+}
+
+// discoveryEmail returns the email address of an intermediate session, or of the member
+// of a session.
+func (s *Server) discoveryEmail(ist, sessionToken, sessionJWT string) (string, *apiError) {
+	if ist != "" {
+		emailAddress, ok := s.intermediateSessions[ist]
+		if !ok {
+			return "", newError(http.StatusNotFound, "intermediate_session_not_found", "Intermediate session could not be found.")
+		}
+		return emailAddress, nil
+	}
+	sess, err := s.findSession(sessionToken, sessionJWT)
+	if err != nil {
+		return "", err
+	}
+	return s.members[sess.MemberID].EmailAddress, nil
+}
+
+func (s *Server) discoveryOrganizationsList(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		IntermediateSessionToken string `json:"intermediate_session_token"`
+		SessionToken             string `json:"session_token"`
+		SessionJWT               string `json:"session_jwt"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	emailAddress, err := s.discoveryEmail(req.IntermediateSessionToken, req.SessionToken, req.SessionJWT)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]any{
+		"email_address":            emailAddress,
+		"discovered_organizations": s.discoveredOrganizations(emailAddress),
+		"organization_id_hint":     "",
+	}, nil
+}
+
+func (s *Server) discoveryOrganizationsCreate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		IntermediateSessionToken string `json:"intermediate_session_token"`
+		OrganizationName         string `json:"organization_name"`
+		OrganizationSlug         string `json:"organization_slug"`
+		SessionDurationMinutes   int32  `json:"session_duration_minutes"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	emailAddress, ok := s.intermediateSessions[req.IntermediateSessionToken]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "intermediate_session_not_found", "Intermediate session could not be found.")
+	}
+	if req.OrganizationName == "" {
+		return nil, newError(http.StatusBadRequest, "invalid_organization_name", "organization_name is required.")
+	}
+	if req.OrganizationSlug != "" && s.organizationBySlug(req.OrganizationSlug) != nil {
+		return nil, newError(http.StatusBadRequest, "organization_slug_already_used", "The organization slug is already in use.")
+	}
+
+	delete(s.intermediateSessions, req.IntermediateSessionToken)
+	org := s.createOrganization(req.OrganizationName, req.OrganizationSlug)
+	m := s.createMember(org.ID, emailAddress, memberActive)
+	m.Roles = []string{adminRole}
+	return s.sessionResponse(m, s.createSession(m, req.SessionDurationMinutes, "magic_link")), nil
+}
+
+func (s *Server) intermediateSessionsExchange(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		IntermediateSessionToken string `json:"intermediate_session_token"`
+		OrganizationID           string `json:"organization_id"`
+		SessionDurationMinutes   int32  `json:"session_duration_minutes"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	emailAddress, ok := s.intermediateSessions[req.IntermediateSessionToken]
+	if !ok {
+		return nil, newError(http.StatusNotFound, "intermediate_session_not_found", "Intermediate session could not be found.")
+	}
+	m := s.memberByEmail(req.OrganizationID, emailAddress)
+	if m == nil {
+		return nil, newError(http.StatusNotFound, "member_not_found", "Member could not be found in the organization.")
+	}
+
+	delete(s.intermediateSessions, req.IntermediateSessionToken)
+	m.Status = memberActive
+	return s.sessionResponse(m, s.createSession(m, req.SessionDurationMinutes, "magic_link")), nil
+}
+
+// sessionResponse is the response of endpoints that start a session for a member.
+func (s *Server) sessionResponse(m *member, sess *session) map[string]any {
+	return map[string]any{
+		"member_id":                  m.ID,
+		"member_session":             sess.json(),
+		"session_token":              sess.Token,
+		"session_jwt":                sess.JWT,
+		"member":                     m.json(),
+		"organization":               s.organizations[m.OrganizationID].json(),
+		"member_authenticated":       true,
+		"intermediate_session_token": "",
+	}
+}
+
+func (s *Server) sessionsAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		SessionToken           string `json:"session_token"`
+		SessionJWT             string `json:"session_jwt"`
+		SessionDurationMinutes int32  `json:"session_duration_minutes"`
+		AuthorizationCheck     *struct {
+			OrganizationID string `json:"organization_id"`
+			ResourceID     string `json:"resource_id"`
+			Action         string `json:"action"`
+		} `json:"authorization_check"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	sess, err := s.findSession(req.SessionToken, req.SessionJWT)
+	if err != nil {
+		return nil, err
+	}
+	m := s.members[sess.MemberID]
+
+	// Every permission is granted to the admin role, and no other role has permissions.
+	var verdict map[string]any
+	if check := req.AuthorizationCheck; check != nil {
+		if check.OrganizationID != sess.OrganizationID {
+			return nil, newError(http.StatusForbidden, "tenancy_mismatch", "The session does not belong to the organization.")
+		}
+		if !m.isAdmin() {
+			return nil, newError(http.StatusForbidden, "unauthorized_action",
+				"The member is not permitted to perform action "+check.Action+" on resource "+check.ResourceID+".")
+		}
+		verdict = map[string]any{"authorized": true, "granting_roles": []string{adminRole}}
+	}
+
+	now := s.now()
+	sess.LastAccessedAt = now
+	if req.SessionDurationMinutes > 0 {
+		sess.ExpiresAt = now.Add(time.Duration(req.SessionDurationMinutes) * time.Minute)
+	}
+	sess.JWT = s.signJWT(sess)
+
+	resp := s.sessionResponse(m, sess)
+	if verdict != nil {
+		resp["verdict"] = verdict
+	}
+	return resp, nil
+}
+
+func (s *Server) sessionsExchange(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		OrganizationID         string `json:"organization_id"`
+		SessionToken           string `json:"session_token"`
+		SessionJWT             string `json:"session_jwt"`
+		SessionDurationMinutes int32  `json:"session_duration_minutes"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	sess, err := s.findSession(req.SessionToken, req.SessionJWT)
+	if err != nil {
+		return nil, err
+	}
+	m := s.memberByEmail(req.OrganizationID, s.members[sess.MemberID].EmailAddress)
+	if m == nil || m.Status != memberActive {
+		return nil, newError(http.StatusNotFound, "member_not_found", "Member could not be found in the organization.")
+	}
+
+	delete(s.sessions, sess.ID)
+	next := s.createSession(m, req.SessionDurationMinutes, "magic_link")
+	next.Factors = sess.Factors
+	next.JWT = s.signJWT(next)
+	return s.sessionResponse(m, next), nil
+}
+
+func (s *Server) sessionsRevoke(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		MemberSessionID string `json:"member_session_id"`
+		SessionToken    string `json:"session_token"`
+		SessionJWT      string `json:"session_jwt"`
+		MemberID        string `json:"member_id"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	if req.MemberID != "" {
+		for id, sess := range s.sessions {
+			if sess.MemberID == req.MemberID {
+				delete(s.sessions, id)
+			}
+		}
+		return map[string]any{}, nil
+	}
+
+	if req.MemberSessionID != "" {
+		if _, ok := s.sessions[req.MemberSessionID]; !ok {
+			return nil, newError(http.StatusNotFound, "session_not_found", "Session could not be found.")
+		}
+		delete(s.sessions, req.MemberSessionID)
+		return map[string]any{}, nil
+	}
+
+	sess, err := s.findSession(req.SessionToken, req.SessionJWT)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.sessions, sess.ID)
+	return map[string]any{}, nil
+}
+
+func (s *Server) sessionsGet(r *http.Request) (map[string]any, *apiError) {
+	organizationID := r.URL.Query().Get("organization_id")
+	memberID := r.URL.Query().Get("member_id")
+
+	var found []*session
+	for _, sess := range s.sessions {
+		if sess.OrganizationID == organizationID && sess.MemberID == memberID && s.now().Before(sess.ExpiresAt) {
+			found = append(found, sess)
+		}
+	}
+	slices.SortFunc(found, func(a, b *session) int { return a.StartedAt.Compare(b.StartedAt) })
+
+	memberSessions := []any{}
+	for _, sess := range found {
+		memberSessions = append(memberSessions, sess.json())
+	}
+	return map[string]any{"member_sessions": memberSessions}, nil
+}
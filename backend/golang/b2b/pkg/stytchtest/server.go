@@ -0,0 +1,221 @@
+// Package stytchtest provides a fake Stytch API server that keeps its state in memory, so
+// that the backend can be exercised without network access or a Stytch project.
+//
+// The server implements the subset of the B2B API that the backend uses: email magic
+// links, discovery, OAuth authenticate, sessions and JWKS. Magic links are not emailed.
+// Instead, every link that would have been sent is recorded and can be read with Messages.
+//
+// For handler tests that do not need a server, WithMemberSession attaches a session to a
+// request as the session and RBAC middleware do.
+package stytchtest
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Server is a fake Stytch B2B API server.
+type Server struct {
+	ProjectID     string
+	ProjectSecret string
+
+	// OnMessage, if set, is called with every magic link that the server would have sent.
+	// It is called while the server holds its lock and must not call back into the server.
+	OnMessage func(Message)
+
+	key   *rsa.PrivateKey
+	keyID string
+	now   func() time.Time
+
+	mu                   sync.Mutex
+	organizations        map[string]*organization
+	members              map[string]*member
+	sessions             map[string]*session
+	intermediateSessions map[string]string
+	tokens               map[string]*token
+	messages             []Message
+}
+
+// Message is a magic link that the server would have emailed.
+type Message struct {
+	Type           string
+	EmailAddress   string
+	OrganizationID string
+	Token          string
+	URL            string
+}
+
+// These constants are the types of recorded messages.
+const (
+	MessageLoginOrSignup = "login_or_signup"
+	MessageInvite        = "invite"
+	MessageDiscovery     = "discovery"
+)
+
+// NewServer creates a fake server for the given project credentials. Requests must
+// authenticate with them using HTTP basic auth, like requests to the Stytch API.
+func NewServer(projectID, projectSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(fmt.Sprintf("stytchtest: unable to generate signing key: %v", err))
+	}
+	return &Server{
+		ProjectID:            projectID,
+		ProjectSecret:        projectSecret,
+		key:                  key,
+		keyID:                "jwk-test-" + randomID(),
+		now:                  time.Now,
+		organizations:        map[string]*organization{},
+		members:              map[string]*member{},
+		sessions:             map[string]*session{},
+		intermediateSessions: map[string]string{},
+		tokens:               map[string]*token{},
+	}
+}
+
+// Start runs the server on a local port, returning the httptest server whose URL is passed
+// to b2bstytchapi.WithBaseURI. The caller must close it.
+func (s *Server) Start() *httptest.Server {
+	return httptest.NewServer(s)
+}
+
+// ServeHTTP routes a request to the fake implementation of a Stytch API endpoint.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if jwksPath := "/v1/b2b/sessions/jwks/"; strings.HasPrefix(r.URL.Path, jwksPath) {
+		if r.Method != http.MethodGet || strings.TrimPrefix(r.URL.Path, jwksPath) != s.ProjectID {
+			sendError(w, http.StatusNotFound, "project_not_found", "Project not found.")
+			return
+		}
+		sendJSON(w, s.jwks())
+		return
+	}
+
+	projectID, secret, ok := r.BasicAuth()
+	if !ok || projectID != s.ProjectID || secret != s.ProjectSecret {
+		sendError(w, http.StatusUnauthorized, "unauthorized_credentials", "Unauthorized credentials.")
+		return
+	}
+
+	handler, ok := s.routes()[r.Method+" "+r.URL.Path]
+	if !ok {
+		sendError(w, http.StatusNotFound, "route_not_found",
+			fmt.Sprintf("The fake Stytch server does not implement %s %s.", r.Method, r.URL.Path))
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	resp, err := handler(r)
+	if err != nil {
+		sendError(w, err.StatusCode, err.ErrorType, err.ErrorMessage)
+		return
+	}
+	sendJSON(w, resp)
+}
+
+// Messages returns the magic links that the server would have emailed, oldest first.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return slices.Clone(s.messages)
+}
+
+// LastToken returns the token of the most recent magic link sent to the email address.
+func (s *Server) LastToken(emailAddress string) (string, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.messages) - 1; i >= 0; i-- {
+		if strings.EqualFold(s.messages[i].EmailAddress, emailAddress) {
+			return s.messages[i].Token, true
+		}
+	}
+	return "", false
+}
+
+// CreateOrganization adds an organization and returns its ID.
+func (s *Server) CreateOrganization(name, slug string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.createOrganization(name, slug).ID
+}
+
+// CreateMember adds an active member with the given roles to an organization and returns
+// its ID.
+func (s *Server) CreateMember(organizationID, emailAddress string, roles ...string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	m := s.createMember(organizationID, emailAddress, memberActive)
+	m.Roles = roles
+	return m.ID
+}
+
+// OAuthToken issues a token that completes an OAuth login for the member with the email
+// address in the organization, as if the member had signed in with the provider.
+func (s *Server) OAuthToken(organizationID, emailAddress string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueToken(tokenOAuth, emailAddress, organizationID)
+}
+
+// DiscoveryOAuthToken issues a token that completes an OAuth discovery flow for the email
+// address.
+func (s *Server) DiscoveryOAuthToken(emailAddress string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.issueToken(tokenDiscoveryOAuth, emailAddress, "")
+}
+
+// apiError is the error body returned by the Stytch API.
+type apiError struct {
+	StatusCode   int    `json:"status_code"`
+	RequestID    string `json:"request_id"`
+	ErrorType    string `json:"error_type"`
+	ErrorMessage string `json:"error_message"`
+}
+
+func newError(statusCode int, errorType, message string) *apiError {
+	return &apiError{StatusCode: statusCode, ErrorType: errorType, ErrorMessage: message}
+}
+
+// decode reads the JSON body of a request, rejecting malformed bodies like the Stytch API.
+func decode(r *http.Request, v any) *apiError {
+	if err := json.NewDecoder(r.Body).Decode(v); err != nil {
+		return newError(http.StatusBadRequest, "invalid_request_body", "The request body is not valid JSON.")
+	}
+	return nil
+}
+
+func sendJSON(w http.ResponseWriter, body map[string]any) {
+	body["request_id"] = "request-id-test-" + randomID()
+	if _, ok := body["status_code"]; !ok {
+		body["status_code"] = http.StatusOK
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(body)
+}
+
+func sendError(w http.ResponseWriter, statusCode int, errorType, message string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(statusCode)
+	_ = json.NewEncoder(w).Encode(&apiError{
+		StatusCode:   statusCode,
+		RequestID:    "request-id-test-" + randomID(),
+		ErrorType:    errorType,
+		ErrorMessage: message,
+	})
+}
+
+func randomID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
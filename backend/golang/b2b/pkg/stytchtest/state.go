@@ -0,0 +1,276 @@
+package stytchtest
+
+import (
+	"regexp"
+	"strings"
+	"time"
+)
+
+type organization struct {
+	ID        string
+	Name      string
+	Slug      string
+	CreatedAt time.Time
+}
+
+// These constants are the statuses of a member.
+const (
+	memberActive  = "active"
+	memberPending = "pending"
+	memberInvited = "invited"
+)
+
+type member struct {
+	ID             string
+	OrganizationID string
+	EmailAddress   string
+	Name           string
+	Status         string
+	Roles          []string
+	CreatedAt      time.Time
+}
+
+type session struct {
+	ID             string
+	Token          string
+	JWT            string
+	MemberID       string
+	OrganizationID string
+	StartedAt      time.Time
+	LastAccessedAt time.Time
+	ExpiresAt      time.Time
+	Factors        []map[string]any
+}
+
+// These constants are the types of single-use tokens that complete an authentication flow.
+const (
+	tokenMagicLink      = "magic_link"
+	tokenDiscovery      = "discovery"
+	tokenOAuth          = "oauth"
+	tokenDiscoveryOAuth = "discovery_oauth"
+)
+
+type token struct {
+	Type           string
+	EmailAddress   string
+	OrganizationID string
+	ExpiresAt      time.Time
+}
+
+// tokenLifetime is how long magic link and OAuth tokens can be redeemed.
+const tokenLifetime = 10 * time.Minute
+
+// defaultSessionMinutes is the session duration when a request does not set one.
+const defaultSessionMinutes = 60
+
+// adminRole is the default Stytch role that is granted every permission.
+const adminRole = "stytch_admin"
+
+var slugPattern = regexp.MustCompile(`[^a-z0-9._~-]+`)
+
+func (s *Server) createOrganization(name, slug string) *organization {
+	if slug == "" {
+		slug = strings.Trim(slugPattern.ReplaceAllString(strings.ToLower(name), "-"), "-")
+	}
+	org := &organization{
+		ID:        "organization-test-" + randomID(),
+		Name:      name,
+		Slug:      slug,
+		CreatedAt: s.now(),
+	}
+	s.organizations[org.ID] = org
+	return org
+}
+
+func (s *Server) organizationBySlug(slug string) *organization {
+	for _, org := range s.organizations {
+		if org.Slug == slug {
+			return org
+		}
+	}
+	return nil
+}
+
+func (s *Server) createMember(organizationID, emailAddress, status string) *member {
+	m := &member{
+		ID:             "member-test-" + randomID(),
+		OrganizationID: organizationID,
+		EmailAddress:   strings.ToLower(emailAddress),
+		Status:         status,
+		CreatedAt:      s.now(),
+	}
+	s.members[m.ID] = m
+	return m
+}
+
+func (s *Server) memberByEmail(organizationID, emailAddress string) *member {
+	for _, m := range s.members {
+		if m.OrganizationID == organizationID && strings.EqualFold(m.EmailAddress, emailAddress) {
+			return m
+		}
+	}
+	return nil
+}
+
+// issueToken creates a single-use token for a flow of the given type.
+func (s *Server) issueToken(tokenType, emailAddress, organizationID string) string {
+	t := tokenType + "-test-" + randomID()
+	s.tokens[t] = &token{
+		Type:           tokenType,
+		EmailAddress:   strings.ToLower(emailAddress),
+		OrganizationID: organizationID,
+		ExpiresAt:      s.now().Add(tokenLifetime),
+	}
+	return t
+}
+
+// redeemToken consumes a token of the given type. Tokens can only be redeemed once.
+func (s *Server) redeemToken(value, tokenType string) (*token, *apiError) {
+	t, ok := s.tokens[value]
+	if !ok || t.Type != tokenType {
+		return nil, newError(404, "unable_to_auth_magic_link", "The token could not be found, it may have already been used.")
+	}
+	delete(s.tokens, value)
+	if s.now().After(t.ExpiresAt) {
+		return nil, newError(404, "unable_to_auth_magic_link", "The token has expired.")
+	}
+	return t, nil
+}
+
+// sendMessage records a magic link instead of emailing it.
+func (s *Server) sendMessage(messageType, emailAddress, organizationID, token, tokenType, redirectURL string) {
+	url := ""
+	if redirectURL != "" {
+		sep := "?"
+		if strings.Contains(redirectURL, "?") {
+			sep = "&"
+		}
+		url = redirectURL + sep + "stytch_token_type=" + tokenType + "&token=" + token
+	}
+	msg := Message{
+		Type:           messageType,
+		EmailAddress:   strings.ToLower(emailAddress),
+		OrganizationID: organizationID,
+		Token:          token,
+		URL:            url,
+	}
+	s.messages = append(s.messages, msg)
+	if s.OnMessage != nil {
+		s.OnMessage(msg)
+	}
+}
+
+// createSession starts a session for the member with a single authentication factor.
+func (s *Server) createSession(m *member, minutes int32, factorType string) *session {
+	if minutes <= 0 {
+		minutes = defaultSessionMinutes
+	}
+	now := s.now()
+	sess := &session{
+		ID:             "member-session-test-" + randomID(),
+		Token:          "session-token-test-" + randomID(),
+		MemberID:       m.ID,
+		OrganizationID: m.OrganizationID,
+		StartedAt:      now,
+		LastAccessedAt: now,
+		ExpiresAt:      now.Add(time.Duration(minutes) * time.Minute),
+		Factors: []map[string]any{{
+			"type":                  factorType,
+			"delivery_method":       "email",
+			"last_authenticated_at": now.Format(time.RFC3339),
+		}},
+	}
+	sess.JWT = s.signJWT(sess)
+	s.sessions[sess.ID] = sess
+	return sess
+}
+
+// findSession looks up an unexpired session by its token or JWT. Expired sessions are
+// removed.
+func (s *Server) findSession(sessionToken, sessionJWT string) (*session, *apiError) {
+	for id, sess := range s.sessions {
+		if (sessionToken != "" && sess.Token == sessionToken) || (sessionJWT != "" && sess.JWT == sessionJWT) {
+			if s.now().After(sess.ExpiresAt) {
+				delete(s.sessions, id)
+				break
+			}
+			return sess, nil
+		}
+	}
+	return nil, newError(404, "session_not_found", "Session could not be found.")
+}
+
+func (o *organization) json() map[string]any {
+	return map[string]any{
+		"organization_id":        o.ID,
+		"organization_name":      o.Name,
+		"organization_slug":      o.Slug,
+		"organization_logo_url":  "",
+		"trusted_metadata":       map[string]any{},
+		"email_allowed_domains":  []string{},
+		"email_jit_provisioning": "NOT_ALLOWED",
+		"email_invites":          "ALL_ALLOWED",
+		"auth_methods":           "ALL_ALLOWED",
+		"allowed_auth_methods":   []string{},
+		"mfa_policy":             "OPTIONAL",
+		"mfa_methods":            "ALL_ALLOWED",
+		"allowed_mfa_methods":    []string{},
+		"created_at":             o.CreatedAt.Format(time.RFC3339),
+		"updated_at":             o.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (m *member) roleIDs() []string {
+	roles := []string{"stytch_member"}
+	return append(roles, m.Roles...)
+}
+
+func (m *member) json() map[string]any {
+	var roles []any
+	for _, role := range m.roleIDs() {
+		roles = append(roles, map[string]any{
+			"role_id": role,
+			"sources": []any{map[string]any{"type": "direct_assignment", "details": map[string]any{}}},
+		})
+	}
+	return map[string]any{
+		"organization_id":        m.OrganizationID,
+		"member_id":              m.ID,
+		"email_address":          m.EmailAddress,
+		"status":                 m.Status,
+		"name":                   m.Name,
+		"sso_registrations":      []any{},
+		"oauth_registrations":    []any{},
+		"is_breakglass":          false,
+		"is_admin":               m.isAdmin(),
+		"email_address_verified": m.Status == memberActive,
+		"mfa_enrolled":           false,
+		"roles":                  roles,
+		"trusted_metadata":       map[string]any{},
+		"untrusted_metadata":     map[string]any{},
+		"created_at":             m.CreatedAt.Format(time.RFC3339),
+		"updated_at":             m.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (m *member) isAdmin() bool {
+	for _, role := range m.Roles {
+		if role == adminRole {
+			return true
+		}
+	}
+	return false
+}
+
+func (sess *session) json() map[string]any {
+	return map[string]any{
+		"member_session_id":      sess.ID,
+		"member_id":              sess.MemberID,
+		"organization_id":        sess.OrganizationID,
+		"started_at":             sess.StartedAt.Format(time.RFC3339),
+		"last_accessed_at":       sess.LastAccessedAt.Format(time.RFC3339),
+		"expires_at":             sess.ExpiresAt.Format(time.RFC3339),
+		"authentication_factors": sess.Factors,
+		"custom_claims":          map[string]any{},
+	}
+}
@@ -0,0 +1,44 @@
+// Command stytchfake runs the fake Stytch Consumer API server from pkg/stytchtest, so that the
+// backend can be developed offline. Set STYTCH_BASE_URI in the backend's .env file to the
+// address of this server. Magic links are logged instead of emailed. Magic links can only
+// be sent to existing users, which are created with the -users flag.
+package main
+
+import (
+	"flag"
+	"log"
+	"net/http"
+	"strings"
+
+	"github.com/joho/godotenv"
+
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:4000", "address to listen on")
+	envFile := flag.String("env", ".env", "file to read PROJECT_ID and PROJECT_SECRET from")
+	users := flag.String("users", "", "comma-separated email addresses of users to create")
+	flag.Parse()
+
+	vars, err := godotenv.Read(*envFile)
+	if err != nil {
+		log.Fatalf("Error reading from %s: %v", *envFile, err)
+	}
+	if vars["PROJECT_ID"] == "" || vars["PROJECT_SECRET"] == "" {
+		log.Fatalf("PROJECT_ID and PROJECT_SECRET must be set in %s", *envFile)
+	}
+
+	server := stytchtest.NewServer(vars["PROJECT_ID"], vars["PROJECT_SECRET"])
+	for _, emailAddress := range strings.Split(*users, ",") {
+		if emailAddress = strings.TrimSpace(emailAddress); emailAddress != "" {
+			log.Printf("Created user %s for %s", server.CreateUser(emailAddress), emailAddress)
+		}
+	}
+	server.OnMessage = func(msg stytchtest.Message) {
+		log.Printf("Sent %s magic link to %s: %s", msg.Type, msg.EmailAddress, msg.URL)
+	}
+
+	log.Printf("Starting fake Stytch API on http://%s...", *addr)
+	log.Fatal(http.ListenAndServe(*addr, server))
+}
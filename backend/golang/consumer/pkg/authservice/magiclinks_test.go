@@ -0,0 +1,104 @@
+package authservice_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"backend/golang/consumer/pkg/authservice"
+)
+
+func TestMagicLinksSend(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	b.fake.CreateUser("ada@example.com")
+
+	tests := []struct {
+		name       string
+		body       map[string]any
+		wantStatus int
+		wantType   string
+	}{
+		{
+			name:       "sends a magic link",
+			body:       map[string]any{"email_address": "ada@example.com"},
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "unknown email address",
+			body:       map[string]any{"email_address": "grace@example.com"},
+			wantStatus: http.StatusNotFound,
+			wantType:   "email_not_found",
+		},
+		{
+			name:       "invalid nested attributes",
+			body:       map[string]any{"email_address": "ada@example.com", "attributes": map[string]string{"ip_address": string(make([]byte, 65))}},
+			wantStatus: http.StatusBadRequest,
+			wantType:   "invalid_request",
+		},
+		{
+			name:       "magic link URL outside the allowlist",
+			body:       map[string]any{"email_address": "ada@example.com", "login_magic_link_url": "https://attacker.example.com"},
+			wantStatus: http.StatusBadRequest,
+			wantType:   "invalid_request",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := b.post(t, "/magic_links/email/send", tt.body)
+			if resp.StatusCode != tt.wantStatus {
+				t.Fatalf("status = %d, want %d (%s)", resp.StatusCode, tt.wantStatus, resp.Error)
+			}
+			if tt.wantType != "" && (resp.ErrorDetails == nil || resp.ErrorDetails.Type != tt.wantType) {
+				t.Errorf("errorDetails = %+v, want type %q", resp.ErrorDetails, tt.wantType)
+			}
+		})
+	}
+}
+
+func TestMagicLinksAuthenticate(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	b.fake.CreateUser("ada@example.com")
+
+	if resp := b.get(t, "/session"); resp.StatusCode != http.StatusUnauthorized {
+		t.Fatalf("GET /session before login = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	b.login(t, "ada@example.com")
+	if resp := b.get(t, "/session"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /session after login = %d (%s)", resp.StatusCode, resp.Error)
+	}
+
+	// Magic links can only be used once, and failures redirect to the error page.
+	resp := b.authenticate(t, "magic_links", b.lastToken(t, "ada@example.com"))
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("reusing a magic link = %d, want %d", resp.StatusCode, http.StatusSeeOther)
+	}
+	location, err := url.Parse(resp.Location)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) = %v", resp.Location, err)
+	}
+	if got := location.Scheme + "://" + location.Host + location.Path; got != errorURL {
+		t.Errorf("redirected to %q, want %q", got, errorURL)
+	}
+}
+
+func TestMagicLinksPKCE(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	b.fake.CreateUser("ada@example.com")
+
+	resp := b.post(t, "/magic_links/email/send", map[string]any{"email_address": "ada@example.com", "pkce": true})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /magic_links/email/send = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	token := b.lastToken(t, "ada@example.com")
+
+	// Another browser does not hold the code verifier.
+	other := newBrowser(t, b)
+	if resp := other.authenticate(t, "magic_links", token); resp.Location == successURL {
+		t.Fatal("another browser completed a PKCE magic link")
+	}
+
+	b.post(t, "/magic_links/email/send", map[string]any{"email_address": "ada@example.com", "pkce": true})
+	if resp := b.authenticate(t, "magic_links", b.lastToken(t, "ada@example.com")); resp.Location != successURL {
+		t.Errorf("PKCE magic link authenticate redirected to %q, want %q", resp.Location, successURL)
+	}
+}
@@ -0,0 +1,157 @@
+package authservice_test
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"backend/golang/consumer/pkg/authservice"
+)
+
+// startOAuth starts an OAuth flow and returns the state that Stytch would pass back.
+func (b *backend) startOAuth(t *testing.T, provider string) string {
+	t.Helper()
+	resp := b.get(t, "/oauth/start/"+provider)
+	if resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("GET /oauth/start/%s = %d, want %d", provider, resp.StatusCode, http.StatusSeeOther)
+	}
+	start, err := url.Parse(resp.Location)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) = %v", resp.Location, err)
+	}
+	redirect, err := url.Parse(start.Query().Get("login_redirect_url"))
+	if err != nil {
+		t.Fatalf("url.Parse(%q) = %v", start.Query().Get("login_redirect_url"), err)
+	}
+	return redirect.Query().Get("state")
+}
+
+// completeOAuth opens the OAuth start URL that the backend redirected the browser to, and
+// returns the path of the backend callback that Stytch redirects the browser back to.
+func (b *backend) completeOAuth(t *testing.T, startURL string) string {
+	t.Helper()
+	resp, err := b.client.Get(startURL)
+	if err != nil {
+		t.Fatalf("GET %s: %v", startURL, err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusFound {
+		t.Fatalf("GET %s = %d, want %d", startURL, resp.StatusCode, http.StatusFound)
+	}
+	callback, err := resp.Location()
+	if err != nil {
+		t.Fatalf("Location() = %v", err)
+	}
+	return callback.RequestURI()
+}
+
+func TestOAuthStartToCallback(t *testing.T) {
+	b := newBackend(t, authservice.Options{
+		PublicToken:      "public-token-test-oauth",
+		OAuthRedirectURL: "http://localhost:3000/oauth/authenticate",
+	})
+
+	start := b.get(t, "/oauth/start/google")
+	if start.StatusCode != http.StatusSeeOther {
+		t.Fatalf("GET /oauth/start/google = %d, want %d", start.StatusCode, http.StatusSeeOther)
+	}
+	resp := b.get(t, b.completeOAuth(t, start.Location))
+	if resp.StatusCode != http.StatusSeeOther || resp.Location != successURL {
+		t.Fatalf("OAuth callback = %d %q, want a redirect to %q", resp.StatusCode, resp.Location, successURL)
+	}
+	if resp := b.get(t, "/session"); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /session after OAuth = %d (%s), want %d", resp.StatusCode, resp.Error, http.StatusOK)
+	}
+
+	// A callback opened in a browser that did not start the flow has neither the state
+	// cookie nor the PKCE code verifier.
+	start = b.get(t, "/oauth/start/google")
+	other := newBrowser(t, b)
+	resp = other.get(t, b.completeOAuth(t, start.Location))
+	location, err := url.Parse(resp.Location)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) = %v", resp.Location, err)
+	}
+	if got := location.Query().Get("error_type"); got != "invalid_request" {
+		t.Errorf("error_type in another browser = %q, want %q", got, "invalid_request")
+	}
+}
+
+func TestOAuthAuthenticate(t *testing.T) {
+	b := newBackend(t, authservice.Options{
+		PublicToken:      "public-token-test-oauth",
+		OAuthRedirectURL: "http://localhost:3000/oauth/authenticate",
+		OAuthTokenStore:  "memory",
+	})
+
+	state := b.startOAuth(t, "google")
+	resp := b.get(t, "/oauth/authenticate?"+url.Values{
+		"token": {b.fake.OAuthToken("ada@example.com")},
+		"state": {state},
+	}.Encode())
+	if resp.StatusCode != http.StatusSeeOther || resp.Location != successURL {
+		t.Fatalf("OAuth authenticate = %d %q, want a redirect to %q", resp.StatusCode, resp.Location, successURL)
+	}
+
+	resp = b.get(t, "/oauth/providers/google/token")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /oauth/providers/google/token = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if resp := b.get(t, "/oauth/providers/github/token"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /oauth/providers/github/token = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
+
+func TestOAuthAuthenticateErrors(t *testing.T) {
+	b := newBackend(t, authservice.Options{
+		PublicToken:      "public-token-test-oauth",
+		OAuthRedirectURL: "http://localhost:3000/oauth/authenticate",
+	})
+
+	tests := []struct {
+		name          string
+		token         func() string
+		state         func() string
+		wantErrorType string
+	}{
+		{
+			name:          "state of another flow",
+			token:         func() string { return b.fake.OAuthToken("ada@example.com") },
+			state:         func() string { b.startOAuth(t, "google"); return "state-test-other" },
+			wantErrorType: "invalid_request",
+		},
+		{
+			name:          "unknown token",
+			token:         func() string { return "oauth-token-test-unknown" },
+			state:         func() string { return b.startOAuth(t, "google") },
+			wantErrorType: "oauth_token_not_found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := b.get(t, "/oauth/authenticate?"+url.Values{
+				"state": {tt.state()},
+				"token": {tt.token()},
+			}.Encode())
+			location, err := url.Parse(resp.Location)
+			if err != nil {
+				t.Fatalf("url.Parse(%q) = %v", resp.Location, err)
+			}
+			if got := location.Query().Get("error_type"); got != tt.wantErrorType {
+				t.Errorf("error_type = %q, want %q", got, tt.wantErrorType)
+			}
+		})
+	}
+}
+
+func TestOAuthStartUnknownProvider(t *testing.T) {
+	b := newBackend(t, authservice.Options{PublicToken: "public-token-test-oauth"})
+	resp := b.get(t, "/oauth/start/myspace")
+	location, err := url.Parse(resp.Location)
+	if err != nil {
+		t.Fatalf("url.Parse(%q) = %v", resp.Location, err)
+	}
+	if got := location.Query().Get("error_type"); got != "not_found" {
+		t.Errorf("error_type = %q, want %q", got, "not_found")
+	}
+}
@@ -0,0 +1,86 @@
+package authservice
+
+import (
+	"net/http"
+	"slices"
+
+	"backend/golang/consumer/pkg/cors"
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/openapi"
+	"backend/golang/consumer/pkg/router"
+)
+
+// apiTitle is the title of the OpenAPI document that describes the route table.
+const apiTitle = "Stytch Consumer Example Backend"
+
+// Routes returns the route table of the backend. Routes that require a two-factor session
+// are wrapped with the MFA middleware.
+func (s *Service) Routes() []router.Route {
+	routes := []router.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/",
+			Summary: "Check that the backend is running",
+			Handler: s.IndexHandler,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/authenticate",
+			Summary: "Complete a magic link or OAuth flow from a Stytch redirect",
+			Handler: s.AuthenticateHandler,
+			Query:   []string{"stytch_token_type", "token", "state"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/return-to",
+			Summary: "Store the URL to return to after logging in",
+			Handler: s.Redirects.ReturnToHandler,
+			Request: internal.ReturnToRequest{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/csrf-token",
+			Summary: "Get a CSRF token for state-changing requests",
+			Handler: s.CSRF.TokenHandler,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/openapi.json",
+			Summary: "Get the OpenAPI description of the backend",
+			Handler: s.OpenAPIHandler,
+			// The description is public, so any origin may read it, but without cookies.
+			CORS: &cors.Policy{
+				AllowedOrigins: []string{"*"},
+				AllowedMethods: []string{"GET"},
+			},
+		},
+	}
+	routes = slices.Concat(
+		routes,
+		s.MagicLinksController.Routes(),
+		s.OAuthController.Routes(),
+		s.OTPController.Routes(),
+		s.PasswordsController.Routes(),
+		s.WebAuthnController.Routes(),
+		s.TOTPController.Routes(),
+		s.UsersController.Routes(),
+		s.SessionsController.Routes(),
+	)
+
+	for i, route := range routes {
+		if route.RequireMFA {
+			routes[i].Handler = s.MFA.Require(route.Handler)
+		}
+	}
+	return routes
+}
+
+// OpenAPI returns the OpenAPI document generated from the route table.
+func (s *Service) OpenAPI() openapi.Document {
+	return openapi.Build(apiTitle, s.Routes())
+}
+
+// OpenAPIHandler serves the OpenAPI document generated from the route table.
+func (s *Service) OpenAPIHandler(w http.ResponseWriter, r *http.Request) {
+	openapi.Handler(s.OpenAPI())(w, r)
+}
@@ -0,0 +1,74 @@
+package authservice_test
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/golang/consumer/pkg/authservice"
+)
+
+// The fake Stytch API only implements magic links, OAuth and sessions. For the other
+// controllers, these tests cover the checks that run before Stytch is called.
+
+func TestRoutesRequireSession(t *testing.T) {
+	b := newBackend(t, authservice.Options{OAuthTokenStore: "memory"})
+
+	tests := []struct {
+		method string
+		path   string
+		body   any
+	}{
+		{http.MethodGet, "/users/me", nil},
+		{http.MethodPost, "/users/update", map[string]any{}},
+		{http.MethodPost, "/users/emails/add", map[string]string{"email_address": "ada@example.com"}},
+		{http.MethodPost, "/users/phone_numbers/add", map[string]string{"phone_number": "+12025550162"}},
+		{http.MethodPost, "/users/factors/delete", map[string]string{"factor_type": "totp", "factor_id": "totp-test-1"}},
+		{http.MethodGet, "/sessions", nil},
+		{http.MethodPost, "/sessions/revoke_others", nil},
+		{http.MethodPost, "/totps/create", nil},
+		{http.MethodGet, "/totps/recovery_codes", nil},
+		{http.MethodGet, "/webauthn/registrations", nil},
+		{http.MethodGet, "/oauth/providers", nil},
+		{http.MethodGet, "/oauth/providers/google/token", nil},
+		{http.MethodPost, "/passwords/session/reset", map[string]string{"password": "correct horse battery staple"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.method+" "+tt.path, func(t *testing.T) {
+			resp := b.do(t, tt.method, tt.path, tt.body)
+			if resp.StatusCode != http.StatusUnauthorized {
+				t.Errorf("status = %d, want %d (%s)", resp.StatusCode, http.StatusUnauthorized, resp.Error)
+			}
+		})
+	}
+}
+
+func TestRoutesRejectBadBodies(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+
+	tests := []struct {
+		path       string
+		body       any
+		wantFields []string
+	}{
+		{"/otps/sms/send", map[string]string{"phone_number": "555-0162"}, []string{"phone_number"}},
+		{"/otps/email/send", map[string]string{}, []string{"email_address"}},
+		{"/otps/authenticate", map[string]string{"method_id": "phone-number-test-1", "code": "12"}, []string{"code"}},
+		{"/passwords/authenticate", map[string]string{"email_address": "ada"}, []string{"email_address", "password"}},
+		{"/passwords/email/reset/start", map[string]string{}, []string{"email_address"}},
+		{"/webauthn/register/start", map[string]string{"authenticator_type": "usb"}, []string{"authenticator_type"}},
+		{"/totps/authenticate", map[string]string{"totp_code": "1"}, []string{"totp_code"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			resp := b.post(t, tt.path, tt.body)
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("status = %d, want %d (%s)", resp.StatusCode, http.StatusBadRequest, resp.Error)
+			}
+			for _, field := range tt.wantFields {
+				if _, ok := resp.ErrorDetails.Fields[field]; !ok {
+					t.Errorf("errorDetails.fields = %v, want an error for %s", resp.ErrorDetails.Fields, field)
+				}
+			}
+		})
+	}
+}
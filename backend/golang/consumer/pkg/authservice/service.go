@@ -7,8 +7,14 @@ import (
 
 	"backend/golang/consumer/pkg/internal"
 	"backend/golang/consumer/pkg/magiclinks"
+	"backend/golang/consumer/pkg/mfa"
 	"backend/golang/consumer/pkg/oauth"
+	"backend/golang/consumer/pkg/otp"
+	"backend/golang/consumer/pkg/passwords"
 	"backend/golang/consumer/pkg/session"
+	"backend/golang/consumer/pkg/totp"
+	"backend/golang/consumer/pkg/users"
+	"backend/golang/consumer/pkg/webauthn"
 )
 
 type Service struct {
@@ -18,19 +24,130 @@ type Service struct {
 	MagicLinksController *magiclinks.Controller
 	SessionsController   *session.Controller
 	OAuthController      *oauth.Controller
+	OTPController        *otp.Controller
+	PasswordsController  *passwords.Controller
+	WebAuthnController   *webauthn.Controller
+	TOTPController       *totp.Controller
+	UsersController      *users.Controller
+
+	// MFA requires two-factor sessions on the routes marked with RequireMFA.
+	MFA *mfa.Middleware
+
+	// CSRF protects cookie-authenticated, state-changing requests from cross-site
+	// request forgery.
+	CSRF *internal.CSRFProtection
+
+	// Redirects decides which frontend page the browser is sent to once a flow completes.
+	Redirects *internal.Redirector
 }
 
-func New(stytchAPI *stytchapi.API) *Service {
-	cookieStore := internal.NewCookieStore()
+// Options holds the configuration of the Service that is loaded from the .env file.
+type Options struct {
+	// SessionDurationMinutes is the lifetime of new sessions and the extension applied to
+	// existing sessions whenever their session JWT has expired.
+	SessionDurationMinutes int32
+
+	// MagicLinkURLs lists the login and signup magic link URLs that clients may request, and
+	// MagicLinkTemplates maps locales to the email templates that magic links are sent with.
+	MagicLinkURLs      []string
+	MagicLinkTemplates map[string]magiclinks.Templates
+
+	// StytchAPIURL is the Stytch API that browsers start OAuth flows at, PublicToken
+	// identifies the project there, and OAuthRedirectURL is the backend URL that OAuth
+	// flows return to.
+	StytchAPIURL     string
+	PublicToken      string
+	OAuthRedirectURL string
+
+	// PasswordResetLinkURL is the backend URL that password reset emails link to, and
+	// PasswordMigrationKey authorizes password migrations, which are disabled without it.
+	PasswordResetLinkURL string
+	PasswordMigrationKey string
+
+	// WebAuthnDomain is the domain of the frontend that passkeys are registered for.
+	WebAuthnDomain string
+
+	// CookieKeyPairs holds alternating authentication and encryption keys for cookies,
+	// ordered from newest to oldest.
+	CookieKeyPairs [][]byte
+	CookieDomain   string
+	CookieSecure   bool
+	CookieHTTPOnly bool
+	CookieSameSite http.SameSite
+
+	// SessionStore selects whether Stytch tokens are kept in cookies ("cookie") or on the
+	// server ("memory" or "file"), and SessionStoreFile is the file used by "file".
+	SessionStore     string
+	SessionStoreFile string
+
+	// OAuthTokenStore selects whether OAuth provider tokens are discarded ("none") or kept
+	// encrypted on the server ("memory" or "file"), and OAuthTokenStoreFile is the file
+	// used by "file".
+	OAuthTokenStore     string
+	OAuthTokenStoreFile string
+
+	// CSRFTrustedOrigins lists the origins that may send state-changing requests, and
+	// CSRFRequireToken makes the X-CSRF-Token header mandatory for them.
+	CSRFTrustedOrigins []string
+	CSRFRequireToken   bool
+
+	// These are the frontend pages that authentication flows redirect to, and
+	// RedirectAllowedHosts lists additional hosts that return_to URLs may point to.
+	RedirectSuccessURL       string
+	RedirectMFARequiredURL   string
+	RedirectErrorURL         string
+	RedirectPasswordResetURL string
+	RedirectAllowedHosts     []string
+}
+
+func New(stytchAPI *stytchapi.API, opts Options) *Service {
+	cookieStore := internal.NewCookieStore(internal.CookieOptions{
+		KeyPairs: opts.CookieKeyPairs,
+		Domain:   opts.CookieDomain,
+		Secure:   opts.CookieSecure,
+		HttpOnly: opts.CookieHTTPOnly,
+		SameSite: opts.CookieSameSite,
+
+		Store:         opts.SessionStore,
+		StoreFilePath: opts.SessionStoreFile,
+	})
+	var providerTokens *internal.ProviderTokenStore
+	switch opts.OAuthTokenStore {
+	case "memory":
+		providerTokens = internal.NewProviderTokenStore("", opts.CookieKeyPairs...)
+	case "file":
+		providerTokens = internal.NewProviderTokenStore(opts.OAuthTokenStoreFile, opts.CookieKeyPairs...)
+	}
+	redirector := internal.NewRedirector(cookieStore, internal.RedirectOptions{
+		SuccessURL:       opts.RedirectSuccessURL,
+		MFARequiredURL:   opts.RedirectMFARequiredURL,
+		ErrorURL:         opts.RedirectErrorURL,
+		PasswordResetURL: opts.RedirectPasswordResetURL,
+		AllowedHosts:     opts.RedirectAllowedHosts,
+	})
 	return &Service{
 		stytchAPI:            stytchAPI,
 		cookieStore:          cookieStore,
-		MagicLinksController: magiclinks.NewController(stytchAPI, cookieStore),
-		SessionsController:   session.NewController(stytchAPI, cookieStore),
-		OAuthController:      oauth.NewController(stytchAPI, cookieStore),
+		MagicLinksController: magiclinks.NewController(magiclinks.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, opts.MagicLinkURLs, opts.MagicLinkTemplates, redirector),
+		SessionsController:   session.NewController(session.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes),
+		OAuthController:      oauth.NewController(oauth.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, opts.StytchAPIURL, opts.PublicToken, opts.OAuthRedirectURL, providerTokens, redirector),
+		OTPController:        otp.NewController(otp.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, redirector),
+		PasswordsController:  passwords.NewController(passwords.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, opts.PasswordResetLinkURL, opts.PasswordMigrationKey, redirector),
+		WebAuthnController:   webauthn.NewController(webauthn.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, opts.WebAuthnDomain, redirector),
+		TOTPController:       totp.NewController(totp.NewAPI(stytchAPI), cookieStore, opts.SessionDurationMinutes, redirector),
+		UsersController:      users.NewController(users.NewAPI(stytchAPI), cookieStore, providerTokens),
+		MFA:                  mfa.NewMiddleware(mfa.NewAPI(stytchAPI), cookieStore, redirector),
+		CSRF:                 internal.NewCSRFProtection(cookieStore, opts.CSRFTrustedOrigins, opts.CSRFRequireToken),
+		Redirects:            redirector,
 	}
 }
 
+// Close stops the background work of the Service, such as writing the server-side session
+// store to its file.
+func (s *Service) Close() {
+	s.cookieStore.Close()
+}
+
 func (s *Service) IndexHandler(w http.ResponseWriter, _ *http.Request) {
 	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
 	_, _ = w.Write([]byte("OK"))
@@ -41,10 +158,13 @@ func (s *Service) IndexHandler(w http.ResponseWriter, _ *http.Request) {
 //
 // For full list of token types, see: https://stytch.com/docs/workspace-management/redirect-urls.
 const (
-	tokenTypeMagicLinks = "magic_links"
-	tokenTypeOAuth      = "oauth"
+	tokenTypeMagicLinks    = "magic_links"
+	tokenTypeOAuth         = "oauth"
+	tokenTypeResetPassword = "reset_password"
 )
 
+const authenticateMethod = "Authenticate"
+
 func (s *Service) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
 	// Retrieve the token type from the query parameter.
 	tokenType := r.URL.Query().Get("stytch_token_type")
@@ -57,7 +177,13 @@ func (s *Service) AuthenticateHandler(w http.ResponseWriter, r *http.Request) {
 		s.MagicLinksController.Authenticate(w, r)
 	case tokenTypeOAuth:
 		s.OAuthController.Authenticate(w, r)
+	case tokenTypeResetPassword:
+		s.PasswordsController.ResetRedirect(w, r)
 	default:
-		http.Error(w, "Authentication for this token type has not been implemented", http.StatusNotImplemented)
+		internal.SendError(w, authenticateMethod, internal.NewError(
+			http.StatusNotImplemented,
+			internal.ErrorTypeNotImplemented,
+			"Authentication for this token type has not been implemented",
+		))
 	}
 }
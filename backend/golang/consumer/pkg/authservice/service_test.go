@@ -0,0 +1,212 @@
+package authservice_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+
+	"backend/golang/consumer/pkg/authservice"
+	"backend/golang/consumer/pkg/router"
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+const (
+	projectID     = "project-test-00000000-0000-0000-0000-000000000000"
+	projectSecret = "secret-test-authservice"
+
+	frontendOrigin = "http://localhost:3001"
+	successURL     = frontendOrigin + "/view-session"
+	mfaURL         = frontendOrigin + "/mfa"
+	errorURL       = frontendOrigin + "/login"
+)
+
+// backend runs the backend against a fake Stytch API, and acts as a browser that keeps
+// the backend's cookies.
+type backend struct {
+	fake   *stytchtest.Server
+	server *httptest.Server
+	client *http.Client
+
+	// stytchCalls counts the requests made to each path of the fake Stytch API.
+	stytchCalls *callCounter
+}
+
+type callCounter struct {
+	mu    sync.Mutex
+	paths map[string]int
+}
+
+// newBackend starts the fake Stytch API and the backend with the same middleware as
+// main.go, except for logging and CORS.
+func newBackend(t *testing.T, opts authservice.Options) *backend {
+	t.Helper()
+	b := &backend{
+		fake:        stytchtest.NewServer(projectID, projectSecret),
+		stytchCalls: &callCounter{paths: map[string]int{}},
+	}
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b.stytchCalls.mu.Lock()
+		b.stytchCalls.paths[r.URL.Path]++
+		b.stytchCalls.mu.Unlock()
+		b.fake.ServeHTTP(w, r)
+	}))
+	t.Cleanup(api.Close)
+
+	client, err := stytchapi.NewClient(projectID, projectSecret, stytchapi.WithBaseURI(api.URL))
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+
+	if opts.StytchAPIURL == "" {
+		opts.StytchAPIURL = api.URL
+	}
+	opts.CookieKeyPairs = [][]byte{[]byte(strings.Repeat("a", 32)), []byte(strings.Repeat("b", 32))}
+	opts.SessionDurationMinutes = 60
+	opts.CSRFTrustedOrigins = []string{frontendOrigin}
+	opts.RedirectSuccessURL = successURL
+	opts.RedirectMFARequiredURL = mfaURL
+	opts.RedirectErrorURL = errorURL
+	service := authservice.New(client, opts)
+	t.Cleanup(service.Close)
+
+	mux := http.NewServeMux()
+	router.Register(mux, service.Routes())
+	b.server = httptest.NewServer(service.CSRF.Middleware(service.SessionsController.Refresh(mux)))
+	t.Cleanup(b.server.Close)
+
+	b.client = newClient(t)
+	return b
+}
+
+// newBrowser returns a browser with its own cookies for the same backend, such as a
+// second device of the user.
+func newBrowser(t *testing.T, b *backend) *backend {
+	t.Helper()
+	return &backend{fake: b.fake, server: b.server, client: newClient(t), stytchCalls: b.stytchCalls}
+}
+
+func newClient(t *testing.T) *http.Client {
+	t.Helper()
+	jar, err := cookiejar.New(nil)
+	if err != nil {
+		t.Fatalf("cookiejar.New() = %v", err)
+	}
+	return &http.Client{
+		Jar: jar,
+		// Redirects lead to the frontend, which is not running.
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// response is the decoded JSON envelope of a backend response.
+type response struct {
+	StatusCode int
+	Location   string
+
+	Method         string         `json:"method"`
+	StytchResponse any            `json:"stytchResponse"`
+	Metadata       map[string]any `json:"metadata"`
+	Error          string         `json:"error"`
+	ErrorDetails   *struct {
+		Type   string            `json:"type"`
+		Fields map[string]string `json:"fields"`
+	} `json:"errorDetails"`
+}
+
+func (b *backend) get(t *testing.T, path string) *response {
+	t.Helper()
+	return b.do(t, http.MethodGet, path, nil)
+}
+
+func (b *backend) post(t *testing.T, path string, body any) *response {
+	t.Helper()
+	return b.do(t, http.MethodPost, path, body)
+}
+
+func (b *backend) do(t *testing.T, method, path string, body any) *response {
+	t.Helper()
+	var reqBody bytes.Buffer
+	if body != nil {
+		if err := json.NewEncoder(&reqBody).Encode(body); err != nil {
+			t.Fatalf("encoding request body: %v", err)
+		}
+	}
+	req, err := http.NewRequest(method, b.server.URL+path, &reqBody)
+	if err != nil {
+		t.Fatalf("http.NewRequest() = %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Origin", frontendOrigin)
+
+	resp, err := b.client.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, path, err)
+	}
+	defer resp.Body.Close()
+
+	r := &response{StatusCode: resp.StatusCode, Location: resp.Header.Get("Location")}
+	if strings.HasPrefix(resp.Header.Get("Content-Type"), "application/json") {
+		if err := json.NewDecoder(resp.Body).Decode(r); err != nil {
+			t.Fatalf("%s %s: decoding response: %v", method, path, err)
+		}
+	}
+	return r
+}
+
+// calls returns how many requests the backend made to a path of the Stytch API.
+func (b *backend) calls(path string) int {
+	b.stytchCalls.mu.Lock()
+	defer b.stytchCalls.mu.Unlock()
+	return b.stytchCalls.paths[path]
+}
+
+// login logs the browser in as the existing user with the email address, using an email
+// magic link.
+func (b *backend) login(t *testing.T, emailAddress string) {
+	t.Helper()
+	resp := b.post(t, "/magic_links/email/send", map[string]string{"email_address": emailAddress})
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /magic_links/email/send = %d %q", resp.StatusCode, resp.Error)
+	}
+	resp = b.authenticate(t, "magic_links", b.lastToken(t, emailAddress))
+	if resp.StatusCode != http.StatusSeeOther || resp.Location != successURL {
+		t.Fatalf("magic link authenticate = %d %q, want a redirect to %q", resp.StatusCode, resp.Location, successURL)
+	}
+}
+
+func (b *backend) authenticate(t *testing.T, tokenType, token string) *response {
+	t.Helper()
+	return b.get(t, "/authenticate?"+url.Values{
+		"stytch_token_type": {tokenType},
+		"token":             {token},
+	}.Encode())
+}
+
+func (b *backend) lastToken(t *testing.T, emailAddress string) string {
+	t.Helper()
+	token, ok := b.fake.LastToken(emailAddress)
+	if !ok {
+		t.Fatalf("no magic link was sent to %s", emailAddress)
+	}
+	return token
+}
+
+func TestIndex(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	if resp := b.get(t, "/"); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET / = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+	if resp := b.get(t, "/unknown"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /unknown = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+}
@@ -0,0 +1,77 @@
+package authservice_test
+
+import (
+	"net/http"
+	"testing"
+
+	"backend/golang/consumer/pkg/authservice"
+)
+
+func TestSessionsListAndRevoke(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	b.fake.CreateUser("ada@example.com")
+
+	// Log in on a second device, then on this one.
+	other := newBrowser(t, b)
+	other.login(t, "ada@example.com")
+	b.login(t, "ada@example.com")
+
+	resp := b.get(t, "/sessions")
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /sessions = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if got := len(resp.StytchResponse.([]any)); got != 2 {
+		t.Fatalf("listed %d sessions, want 2", got)
+	}
+
+	resp = b.post(t, "/sessions/revoke_others", nil)
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /sessions/revoke_others = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if got := len(resp.StytchResponse.([]any)); got != 1 {
+		t.Errorf("revoked %d sessions, want 1", got)
+	}
+	// GET /session checks the session token with Stytch, so it notices the revocation before
+	// the session JWT of the other device expires.
+	if resp := other.get(t, "/session"); resp.StatusCode != http.StatusNotFound {
+		t.Errorf("GET /session on the revoked device = %d, want %d", resp.StatusCode, http.StatusNotFound)
+	}
+	if resp := b.get(t, "/session"); resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /session on the current device = %d, want %d", resp.StatusCode, http.StatusOK)
+	}
+}
+
+func TestSessionsLogout(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	b.fake.CreateUser("ada@example.com")
+	b.login(t, "ada@example.com")
+
+	if resp := b.post(t, "/logout", nil); resp.StatusCode != http.StatusOK {
+		t.Fatalf("POST /logout = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if resp := b.get(t, "/session"); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("GET /session after logout = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+	if resp := b.post(t, "/logout", nil); resp.StatusCode != http.StatusUnauthorized {
+		t.Errorf("POST /logout without a session = %d, want %d", resp.StatusCode, http.StatusUnauthorized)
+	}
+}
+
+// TestSessionJWTVerifiedLocally checks that requests with a fresh session JWT are
+// authenticated with the fake JWKS instead of the Sessions Authenticate endpoint.
+func TestSessionJWTVerifiedLocally(t *testing.T) {
+	b := newBackend(t, authservice.Options{})
+	b.fake.CreateUser("ada@example.com")
+	b.login(t, "ada@example.com")
+
+	before := b.calls("/v1/sessions/authenticate")
+	if resp := b.get(t, "/sessions"); resp.StatusCode != http.StatusOK {
+		t.Fatalf("GET /sessions = %d (%s)", resp.StatusCode, resp.Error)
+	}
+	if got := b.calls("/v1/sessions/authenticate") - before; got != 0 {
+		t.Errorf("made %d Sessions Authenticate calls, want 0", got)
+	}
+	if b.calls("/v1/sessions/jwks/"+projectID) == 0 {
+		t.Error("the JWKS was never fetched")
+	}
+}
@@ -0,0 +1,156 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestMatchOrigin(t *testing.T) {
+	patterns := []string{"http://localhost:3001", "https://*.example.com"}
+	tests := []struct {
+		origin string
+		want   bool
+	}{
+		{"http://localhost:3001", true},
+		{"https://app.example.com", true},
+		{"https://eu.app.example.com", true},
+		{"https://example.com", false},
+		{"http://app.example.com", false},
+		{"http://localhost:3002", false},
+		{"https://app.example.com.evil.test", false},
+		{"https://evil.test/.example.com", false},
+		{"https://user@evil.test.example.com", false},
+		{"https://.example.com", false},
+		{"null", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.origin, func(t *testing.T) {
+			if got := MatchOrigin(patterns, tt.origin); got != tt.want {
+				t.Errorf("MatchOrigin(%q) = %t, want %t", tt.origin, got, tt.want)
+			}
+		})
+	}
+
+	if !MatchOrigin([]string{"*"}, "https://evil.test") {
+		t.Error(`MatchOrigin("*") does not match every origin`)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	h := New(Policy{
+		AllowedOrigins:   []string{"http://localhost:3001", "https://*.example.com"},
+		AllowedMethods:   []string{"GET", "POST"},
+		AllowedHeaders:   []string{"Content-Type"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	})
+	h.Route("/public/{name}", Policy{AllowedOrigins: []string{"*"}, AllowedMethods: []string{"GET"}})
+	handler := h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	tests := []struct {
+		name            string
+		method          string
+		path            string
+		origin          string
+		wantStatus      int
+		wantOrigin      string
+		wantCredentials string
+		wantMethods     string
+		wantMaxAge      string
+	}{
+		{
+			name:            "allowed origin",
+			method:          http.MethodGet,
+			path:            "/session",
+			origin:          "http://localhost:3001",
+			wantStatus:      http.StatusOK,
+			wantOrigin:      "http://localhost:3001",
+			wantCredentials: "true",
+		},
+		{
+			name:            "wildcard subdomain",
+			method:          http.MethodPost,
+			path:            "/logout",
+			origin:          "https://app.example.com",
+			wantStatus:      http.StatusOK,
+			wantOrigin:      "https://app.example.com",
+			wantCredentials: "true",
+		},
+		{
+			name:       "disallowed origin",
+			method:     http.MethodGet,
+			path:       "/session",
+			origin:     "https://evil.test",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:            "preflight",
+			method:          http.MethodOptions,
+			path:            "/logout",
+			origin:          "http://localhost:3001",
+			wantStatus:      http.StatusNoContent,
+			wantOrigin:      "http://localhost:3001",
+			wantCredentials: "true",
+			wantMethods:     "GET, POST",
+			wantMaxAge:      "600",
+		},
+		{
+			name:       "preflight from disallowed origin",
+			method:     http.MethodOptions,
+			path:       "/logout",
+			origin:     "https://evil.test",
+			wantStatus: http.StatusNoContent,
+		},
+		{
+			name:       "route override",
+			method:     http.MethodGet,
+			path:       "/public/openapi.json",
+			origin:     "https://evil.test",
+			wantStatus: http.StatusOK,
+			wantOrigin: "https://evil.test",
+		},
+		{
+			name:        "route override preflight",
+			method:      http.MethodOptions,
+			path:        "/public/openapi.json",
+			origin:      "http://localhost:3001",
+			wantStatus:  http.StatusNoContent,
+			wantOrigin:  "http://localhost:3001",
+			wantMethods: "GET",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, tt.path, nil)
+			r.Header.Set("Origin", tt.origin)
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			for header, want := range map[string]string{
+				"Access-Control-Allow-Origin":      tt.wantOrigin,
+				"Access-Control-Allow-Credentials": tt.wantCredentials,
+				"Access-Control-Allow-Methods":     tt.wantMethods,
+				"Access-Control-Max-Age":           tt.wantMaxAge,
+				"Vary":                             "Origin",
+			} {
+				if got := w.Header().Get(header); got != want {
+					t.Errorf("%s = %q, want %q", header, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWildcardWithCredentials(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error(`New() with "*" and AllowCredentials did not panic`)
+		}
+	}()
+	New(Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true})
+}
@@ -6,10 +6,17 @@ import (
 	"github.com/gorilla/sessions"
 )
 
-// CookieStore wraps an instance of sessions.CookieStore, allowing us to
-// store and retrieve session cookies for the client.
+// CookieStore wraps a gorilla sessions store, allowing us to store and retrieve
+// session cookies for the client. Depending on its options, session values are kept
+// either in the cookies themselves or on the server.
 type CookieStore struct {
-	gorillaSessions *sessions.CookieStore
+	gorillaSessions sessions.Store
+
+	// serverStore is set when session values are kept on the server.
+	serverStore *serverStore
+
+	// options are the cookie attributes of new sessions.
+	options *sessions.Options
 }
 
 // These constants are keys for storing Stytch sessions in cookie jar on the user's device.
@@ -17,21 +24,111 @@ const (
 	stytchSessionKey = "stytch_session_key"
 )
 
-func NewCookieStore() *CookieStore {
-	// Gorilla sessions requires at least one secret key for codecs
-	store := sessions.NewCookieStore([]byte("stytch-example-secret"))
-	return &CookieStore{store}
+// returnToKey is the key for storing the URL that the user returns to once an
+// authentication flow completes.
+const returnToKey = "return_to_key"
+
+// passwordResetKey is the key for storing the token of a password reset email between the
+// redirect from the email and the request that sets the new password.
+const passwordResetKey = "password_reset_key"
+
+// oauthKey is the key for storing the state and PKCE code verifier of an OAuth flow between
+// the start of the flow and the callback.
+const oauthKey = "oauth_key"
+
+// magicLinkKey is the key for storing the PKCE code verifier of a magic link between sending
+// the email and authenticating the link.
+const magicLinkKey = "magic_link_key"
+
+// CookieOptions configures the keys and attributes of the cookies written by a CookieStore.
+type CookieOptions struct {
+	// KeyPairs holds alternating authentication and encryption keys. Cookies are signed
+	// and encrypted with the first pair, and every pair is tried when decoding cookies,
+	// which allows keys to be rotated.
+	KeyPairs [][]byte
+
+	Domain   string
+	Secure   bool
+	HttpOnly bool
+	SameSite http.SameSite
+
+	// Store selects where session values are kept and defaults to StoreCookie.
+	Store string
+	// StoreFilePath is the file that session values are persisted to with StoreFile.
+	StoreFilePath string
+}
+
+// These constants select where a CookieStore keeps session values.
+const (
+	// StoreCookie keeps session values in the signed and encrypted cookies.
+	StoreCookie = "cookie"
+	// StoreMemory keeps session values in server memory. Cookies only hold an opaque ID.
+	StoreMemory = "memory"
+	// StoreFile keeps session values in server memory and persists them to a local file.
+	// Cookies only hold an opaque ID.
+	StoreFile = "file"
+)
+
+func NewCookieStore(opts CookieOptions) *CookieStore {
+	var (
+		store        sessions.Store
+		storeOptions *sessions.Options
+		server       *serverStore
+	)
+	switch opts.Store {
+	case StoreMemory:
+		server = newServerStore("", opts.KeyPairs...)
+		store, storeOptions = server, server.options
+	case StoreFile:
+		server = newServerStore(opts.StoreFilePath, opts.KeyPairs...)
+		store, storeOptions = server, server.options
+	default:
+		cookieStore := sessions.NewCookieStore(opts.KeyPairs...)
+		store, storeOptions = cookieStore, cookieStore.Options
+	}
+
+	storeOptions.Domain = opts.Domain
+	storeOptions.Secure = opts.Secure
+	storeOptions.HttpOnly = opts.HttpOnly
+	storeOptions.SameSite = opts.SameSite
+	return &CookieStore{store, server, storeOptions}
 }
 
+// These constants are the values stored in each cookie. The session cookie holds both the
+// opaque session token and the session JWT, so that sessions can be verified locally using
+// the JWT while the token remains available for checks against the Stytch API. It also holds
+// the CSRF token of the session.
+const (
+	tokenValue        = "token"
+	jwtValue          = "jwt"
+	stateValue        = "state"
+	codeVerifierValue = "code_verifier"
+	csrfValue         = "csrf"
+)
+
 // GetSession retrieves a session token from the cookie in an incoming HTTP request,
 // if one exists.
 func (cs *CookieStore) GetSession(r *http.Request) (token string, exists bool) {
-	return cs.get(r, stytchSessionKey)
+	return cs.get(r, stytchSessionKey, tokenValue)
+}
+
+// GetSessionJWT retrieves the session JWT stored alongside the session token in the cookie
+// of an incoming HTTP request, if one exists.
+func (cs *CookieStore) GetSessionJWT(r *http.Request) (jwt string, exists bool) {
+	return cs.get(r, stytchSessionKey, jwtValue)
 }
 
-// StoreSession instructs the client's browser to store a cookie holding a Stytch session token.
-func (cs *CookieStore) StoreSession(w http.ResponseWriter, r *http.Request, sessionToken string) {
-	cs.store(w, r, stytchSessionKey, sessionToken)
+// StoreSession instructs the client's browser to store a cookie holding a Stytch session token
+// and session JWT. Storing a different session than the cookie held before, such as when the
+// user logs in, discards the CSRF token so that a new one is issued for the new session.
+func (cs *CookieStore) StoreSession(w http.ResponseWriter, r *http.Request, sessionToken string, sessionJWT string) {
+	session, _ := cs.gorillaSessions.Get(r, stytchSessionKey)
+	if session.Values[tokenValue] != sessionToken {
+		delete(session.Values, csrfValue)
+	}
+	session.Values[tokenValue] = sessionToken
+	session.Values[jwtValue] = sessionJWT
+	_ = session.Save(r, w)
 }
 
 // ClearSession instructs the client's browser to clear the session cookie, if one exists.
@@ -39,23 +136,148 @@ func (cs *CookieStore) ClearSession(w http.ResponseWriter, r *http.Request) {
 	cs.clear(w, r, stytchSessionKey)
 }
 
-func (cs *CookieStore) get(r *http.Request, key string) (token string, exists bool) {
+// GetCSRFToken retrieves the CSRF token issued for the client's session, if one exists.
+func (cs *CookieStore) GetCSRFToken(r *http.Request) (token string, exists bool) {
+	return cs.get(r, stytchSessionKey, csrfValue)
+}
+
+// StoreCSRFToken stores a CSRF token in the client's session. Clients without a Stytch
+// session get a session cookie that only holds the CSRF token. The token is discarded
+// together with the session when the user logs in or out.
+func (cs *CookieStore) StoreCSRFToken(w http.ResponseWriter, r *http.Request, csrfToken string) {
+	cs.store(w, r, stytchSessionKey, map[string]string{
+		csrfValue: csrfToken,
+	})
+}
+
+// GetReturnTo retrieves the URL that the user returns to after authenticating, if one exists.
+func (cs *CookieStore) GetReturnTo(r *http.Request) (returnTo string, exists bool) {
+	return cs.get(r, returnToKey, tokenValue)
+}
+
+// StoreReturnTo instructs the client's browser to store a cookie holding the URL that the
+// user returns to after authenticating.
+func (cs *CookieStore) StoreReturnTo(w http.ResponseWriter, r *http.Request, returnTo string) {
+	cs.store(w, r, returnToKey, map[string]string{
+		tokenValue: returnTo,
+	})
+}
+
+// ClearReturnTo instructs the client's browser to clear the return_to cookie, if one exists.
+func (cs *CookieStore) ClearReturnTo(w http.ResponseWriter, r *http.Request) {
+	cs.clear(w, r, returnToKey)
+}
+
+// GetPasswordResetToken retrieves the token of the password reset email that the user
+// followed, if one exists.
+func (cs *CookieStore) GetPasswordResetToken(r *http.Request) (token string, exists bool) {
+	return cs.get(r, passwordResetKey, tokenValue)
+}
+
+// StorePasswordResetToken instructs the client's browser to store a cookie holding the token
+// of a password reset email.
+func (cs *CookieStore) StorePasswordResetToken(w http.ResponseWriter, r *http.Request, resetToken string) {
+	cs.store(w, r, passwordResetKey, map[string]string{
+		tokenValue: resetToken,
+	})
+}
+
+// ClearPasswordResetToken instructs the client's browser to clear the password reset cookie,
+// if one exists.
+func (cs *CookieStore) ClearPasswordResetToken(w http.ResponseWriter, r *http.Request) {
+	cs.clear(w, r, passwordResetKey)
+}
+
+// GetOAuthState retrieves the state and PKCE code verifier of the OAuth flow that the client
+// started, if one exists.
+func (cs *CookieStore) GetOAuthState(r *http.Request) (state string, codeVerifier string, exists bool) {
+	state, ok := cs.get(r, oauthKey, stateValue)
+	if !ok {
+		return "", "", false
+	}
+	codeVerifier, ok = cs.get(r, oauthKey, codeVerifierValue)
+	return state, codeVerifier, ok
+}
+
+// StoreOAuthState instructs the client's browser to store a cookie holding the state and PKCE
+// code verifier of an OAuth flow.
+func (cs *CookieStore) StoreOAuthState(w http.ResponseWriter, r *http.Request, state string, codeVerifier string) {
+	cs.store(w, r, oauthKey, map[string]string{
+		stateValue:        state,
+		codeVerifierValue: codeVerifier,
+	})
+}
+
+// ClearOAuthState instructs the client's browser to clear the OAuth cookie, if one exists.
+func (cs *CookieStore) ClearOAuthState(w http.ResponseWriter, r *http.Request) {
+	cs.clear(w, r, oauthKey)
+}
+
+// GetMagicLinkCodeVerifier retrieves the PKCE code verifier of the magic link that was sent
+// from the client's browser, if one exists.
+func (cs *CookieStore) GetMagicLinkCodeVerifier(r *http.Request) (codeVerifier string, exists bool) {
+	return cs.get(r, magicLinkKey, codeVerifierValue)
+}
+
+// StoreMagicLinkCodeVerifier instructs the client's browser to store a cookie holding the PKCE
+// code verifier of a magic link.
+func (cs *CookieStore) StoreMagicLinkCodeVerifier(w http.ResponseWriter, r *http.Request, codeVerifier string) {
+	cs.store(w, r, magicLinkKey, map[string]string{
+		codeVerifierValue: codeVerifier,
+	})
+}
+
+// ClearMagicLinkCodeVerifier instructs the client's browser to clear the magic link cookie, if
+// one exists.
+func (cs *CookieStore) ClearMagicLinkCodeVerifier(w http.ResponseWriter, r *http.Request) {
+	cs.clear(w, r, magicLinkKey)
+}
+
+// ClearAllSessions deletes the server-side session values of every device that the given
+// Stytch user or member is logged in on. It has no effect when session values are kept in
+// cookies, in which case the sessions must be revoked through Stytch instead.
+func (cs *CookieStore) ClearAllSessions(subject string) {
+	if cs.serverStore != nil {
+		cs.serverStore.deleteSubject(subject)
+	}
+}
+
+// Close stops the background work of server-side stores and writes their pending changes to
+// the store file. It has no effect when session values are kept in cookies.
+func (cs *CookieStore) Close() {
+	if cs.serverStore != nil {
+		cs.serverStore.Close()
+	}
+}
+
+func (cs *CookieStore) get(r *http.Request, key string, value string) (token string, exists bool) {
 	session, err := cs.gorillaSessions.Get(r, key)
 	if session == nil || err != nil {
 		return "", false
 	}
-	token, ok := session.Values["token"].(string)
+	token, ok := session.Values[value].(string)
 	return token, ok && token != ""
 }
 
-func (cs *CookieStore) store(w http.ResponseWriter, r *http.Request, key string, token string) {
+func (cs *CookieStore) store(w http.ResponseWriter, r *http.Request, key string, values map[string]string) {
 	session, _ := cs.gorillaSessions.Get(r, key)
-	session.Values["token"] = token
+	for value, token := range values {
+		session.Values[value] = token
+	}
 	_ = session.Save(r, w)
 }
 
 func (cs *CookieStore) clear(w http.ResponseWriter, r *http.Request, key string) {
 	session, _ := cs.gorillaSessions.Get(r, key)
+	// The session is cached for the rest of the request, so dropping its values keeps later
+	// reads from finding the cleared cookie that the request still carries.
+	clear(session.Values)
 	session.Options.MaxAge = -1
 	_ = session.Save(r, w)
+
+	// Values stored later in the same request, such as a new session after an expired one
+	// was cleared, start a new cookie.
+	opts := *cs.options
+	session.Options = &opts
+	session.ID = ""
 }
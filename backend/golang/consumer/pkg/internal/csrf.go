@@ -0,0 +1,115 @@
+package internal
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+
+	"backend/golang/consumer/pkg/cors"
+)
+
+// CSRFHeader is the request header that carries the CSRF token issued by TokenHandler.
+const CSRFHeader = "X-CSRF-Token"
+
+const csrfMethod = "CSRF.Verify"
+
+// CSRFProtection protects cookie-authenticated, state-changing requests from cross-site
+// request forgery.
+//
+// The Origin header of every POST, PUT, PATCH and DELETE request, or the Referer header if
+// Origin is missing, must match a trusted origin or wildcard subdomain pattern. Requests
+// without either header are rejected unless they carry an Authorization header, which
+// browsers do not add to forged requests. In addition, clients can opt in to synchronizer
+// tokens by fetching a token from TokenHandler and sending it in the X-CSRF-Token header.
+// The token is kept in the client's session, which discards it when the user logs in or
+// out, and whenever the header is present it must match. Setting requireToken makes the
+// header mandatory for every state-changing request.
+type CSRFProtection struct {
+	cookieStore    *CookieStore
+	trustedOrigins []string
+	requireToken   bool
+}
+
+func NewCSRFProtection(cookieStore *CookieStore, trustedOrigins []string, requireToken bool) *CSRFProtection {
+	return &CSRFProtection{cookieStore, trustedOrigins, requireToken}
+}
+
+// Middleware rejects state-changing requests that fail the origin or token checks with
+// a 403 response.
+func (p *CSRFProtection) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions:
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		if reason := p.verify(r); reason != "" {
+			log.Printf("CSRF check failed for %s %s: %s", r.Method, r.URL.Path, reason)
+			SendErrorResponse(w, http.StatusForbidden, &Response{
+				Method: csrfMethod,
+				Error:  reason,
+			})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// verify returns the reason the request fails the CSRF checks, or an empty string if it
+// passes them.
+func (p *CSRFProtection) verify(r *http.Request) string {
+	// Browsers send an Origin header with cross-origin requests, and a Referer header unless
+	// a referrer policy strips it. Requests authenticated with an Authorization header, such
+	// as those of migration scripts, cannot be forged by a browser page.
+	origin := r.Header.Get("Origin")
+	if origin == "" {
+		if referer, err := url.Parse(r.Header.Get("Referer")); err == nil && referer.Host != "" {
+			origin = referer.Scheme + "://" + referer.Host
+		}
+	}
+	switch {
+	case origin == "" && r.Header.Get("Authorization") == "":
+		return "Request has no Origin or Referer header"
+	case origin != "" && !cors.MatchOrigin(p.trustedOrigins, origin):
+		return "Request origin is not trusted"
+	}
+
+	header := r.Header.Get(CSRFHeader)
+	if header == "" {
+		if p.requireToken {
+			return "Missing CSRF token"
+		}
+		return ""
+	}
+
+	token, ok := p.cookieStore.GetCSRFToken(r)
+	if !ok || subtle.ConstantTimeCompare([]byte(header), []byte(token)) != 1 {
+		return "Invalid CSRF token"
+	}
+	return ""
+}
+
+// TokenHandler returns the CSRF token of the client's session, issuing a new one if the
+// session does not have one yet. Frontends send the token back in the X-CSRF-Token header
+// and fetch a new one after the user logs in or out.
+func (p *CSRFProtection) TokenHandler(w http.ResponseWriter, r *http.Request) {
+	token, ok := p.cookieStore.GetCSRFToken(r)
+	if !ok {
+		b := make([]byte, 32)
+		if _, err := rand.Read(b); err != nil {
+			SendError(w, csrfMethod, err)
+			return
+		}
+		token = base64.RawURLEncoding.EncodeToString(b)
+		p.cookieStore.StoreCSRFToken(w, r, token)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	_ = json.NewEncoder(w).Encode(map[string]string{"csrfToken": token})
+}
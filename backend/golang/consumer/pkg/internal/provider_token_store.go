@@ -0,0 +1,208 @@
+package internal
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+)
+
+// ProviderTokens are the tokens that an OAuth provider issued for a user, as returned by
+// Stytch's OAuth Authenticate endpoint.
+type ProviderTokens struct {
+	// Provider is the lowercase provider name, such as "google".
+	Provider        string     `json:"provider"`
+	ProviderSubject string     `json:"provider_subject"`
+	AccessToken     string     `json:"access_token"`
+	RefreshToken    string     `json:"refresh_token,omitempty"`
+	IDToken         string     `json:"id_token,omitempty"`
+	Scopes          []string   `json:"scopes"`
+	ExpiresAt       *time.Time `json:"expires_at,omitempty"`
+	UpdatedAt       time.Time  `json:"updated_at"`
+}
+
+// Expired reports whether the access token has expired. Tokens without an expiry, which
+// some providers such as GitHub issue, never expire.
+func (t ProviderTokens) Expired() bool {
+	return t.ExpiresAt != nil && time.Now().After(*t.ExpiresAt)
+}
+
+// ProviderTokenStore keeps the OAuth provider tokens of each user on the server, so that the
+// backend can call provider APIs on the user's behalf. Tokens are signed and encrypted with
+// the cookie keys before they are stored, and rotating the keys keeps them readable as long
+// as the old pair is still listed.
+//
+// Tokens are kept in memory and, if a file path is configured, written to that file after
+// every change so that they survive restarts.
+type ProviderTokenStore struct {
+	codecs []securecookie.Codec
+	path   string
+
+	mu sync.Mutex
+	// entries maps user IDs to providers to encrypted ProviderTokens.
+	entries map[string]map[string]string
+	// version counts the changes to the entries.
+	version uint64
+
+	// fileMu serializes writes to the file, and saved is the version that was last written.
+	fileMu sync.Mutex
+	saved  uint64
+}
+
+// providerTokensName is the name that encrypted tokens are bound to, so that they cannot be
+// swapped with cookie values encrypted under the same keys.
+const providerTokensName = "provider_tokens"
+
+// NewProviderTokenStore creates a provider token store. If path is empty, tokens are only
+// kept in memory and are lost when the server restarts.
+func NewProviderTokenStore(path string, keyPairs ...[]byte) *ProviderTokenStore {
+	codecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range codecs {
+		// Tokens do not expire with cookies, and ID tokens can exceed the cookie size limit.
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(0)
+			sc.MaxLength(0)
+			sc.SetSerializer(securecookie.JSONEncoder{})
+		}
+	}
+	s := &ProviderTokenStore{
+		codecs:  codecs,
+		path:    path,
+		entries: map[string]map[string]string{},
+	}
+	if path != "" {
+		s.loadFile()
+	}
+	return s
+}
+
+// Store saves the tokens of a provider for the user, replacing any earlier tokens of the
+// same provider.
+func (s *ProviderTokenStore) Store(userID string, tokens ProviderTokens) error {
+	encoded, err := securecookie.EncodeMulti(providerTokensName, tokens, s.codecs...)
+	if err != nil {
+		return err
+	}
+	s.update(func(entries map[string]map[string]string) {
+		if entries[userID] == nil {
+			entries[userID] = map[string]string{}
+		}
+		entries[userID][tokens.Provider] = encoded
+	})
+	return nil
+}
+
+// Get returns the tokens of a provider for the user, if any are stored.
+func (s *ProviderTokenStore) Get(userID string, provider string) (ProviderTokens, bool) {
+	s.mu.Lock()
+	encoded, ok := s.entries[userID][provider]
+	s.mu.Unlock()
+	if !ok {
+		return ProviderTokens{}, false
+	}
+
+	var tokens ProviderTokens
+	if err := securecookie.DecodeMulti(providerTokensName, encoded, &tokens, s.codecs...); err != nil {
+		log.Printf("Unable to decrypt %s tokens of %s: %v", provider, userID, err)
+		return ProviderTokens{}, false
+	}
+	return tokens, true
+}
+
+// Providers returns the sorted names of the providers that tokens are stored for.
+func (s *ProviderTokenStore) Providers(userID string) []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	providers := make([]string, 0, len(s.entries[userID]))
+	for provider := range s.entries[userID] {
+		providers = append(providers, provider)
+	}
+	slices.Sort(providers)
+	return providers
+}
+
+// Delete removes the tokens of a provider for the user.
+func (s *ProviderTokenStore) Delete(userID string, provider string) {
+	s.update(func(entries map[string]map[string]string) {
+		delete(entries[userID], provider)
+		if len(entries[userID]) == 0 {
+			delete(entries, userID)
+		}
+	})
+}
+
+// DeleteUser removes the tokens of every provider for the user.
+func (s *ProviderTokenStore) DeleteUser(userID string) {
+	s.update(func(entries map[string]map[string]string) {
+		delete(entries, userID)
+	})
+}
+
+// update applies a change to the entries and persists them to the file, if one is configured.
+func (s *ProviderTokenStore) update(change func(entries map[string]map[string]string)) {
+	s.mu.Lock()
+	change(s.entries)
+	s.version++
+	version := s.version
+	var snapshot map[string]map[string]string
+	if s.path != "" {
+		snapshot = make(map[string]map[string]string, len(s.entries))
+		for userID, providers := range s.entries {
+			snapshot[userID] = maps.Clone(providers)
+		}
+	}
+	s.mu.Unlock()
+
+	if snapshot != nil {
+		s.saveFile(version, snapshot)
+	}
+}
+
+func (s *ProviderTokenStore) loadFile() {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("Unable to read provider token store file: %v", err)
+		return
+	}
+	if err := json.Unmarshal(b, &s.entries); err != nil {
+		log.Printf("Unable to parse provider token store file, starting with an empty store: %v", err)
+		s.entries = map[string]map[string]string{}
+	}
+}
+
+// saveFile writes a snapshot of the entries to a temporary file and renames it, so that the
+// store file is never left partially written. Only the snapshot is taken under the lock of
+// the entries, so requests are not blocked by the write. Snapshots that are older than the
+// last written one are skipped, so concurrent changes cannot overwrite a newer file.
+func (s *ProviderTokenStore) saveFile(version uint64, snapshot map[string]map[string]string) {
+	s.fileMu.Lock()
+	defer s.fileMu.Unlock()
+	if version <= s.saved {
+		return
+	}
+
+	b, err := json.Marshal(snapshot)
+	if err != nil {
+		log.Printf("Unable to encode provider token store: %v", err)
+		return
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		log.Printf("Unable to write provider token store file: %v", err)
+		return
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		log.Printf("Unable to write provider token store file: %v", err)
+		return
+	}
+	s.saved = version
+}
@@ -0,0 +1,54 @@
+package internal
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestProviderTokenStoreFile(t *testing.T) {
+	key := []byte("0123456789abcdef0123456789abcdef")
+	path := filepath.Join(t.TempDir(), "provider_tokens.json")
+	s := NewProviderTokenStore(path, key)
+
+	var wg sync.WaitGroup
+	for i := range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			userID := fmt.Sprintf("user-test-%d", i)
+			if err := s.Store(userID, ProviderTokens{Provider: "google", AccessToken: "access-" + userID}); err != nil {
+				t.Errorf("Store(%s) = %v", userID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	s.Store("user-test-0", ProviderTokens{Provider: "github", AccessToken: "access-github"})
+	s.Delete("user-test-1", "google")
+
+	// Every change must reach the file, even when concurrent writes finish out of order.
+	reopened := NewProviderTokenStore(path, key)
+	for i := range 20 {
+		userID := fmt.Sprintf("user-test-%d", i)
+		tokens, ok := reopened.Get(userID, "google")
+		if i == 1 {
+			if ok {
+				t.Errorf("Get(%s) after Delete = %+v, want no tokens", userID, tokens)
+			}
+			continue
+		}
+		if !ok || tokens.AccessToken != "access-"+userID {
+			t.Errorf("Get(%s) = %+v, %t, want the stored tokens", userID, tokens, ok)
+		}
+	}
+	if got, want := reopened.Providers("user-test-0"), []string{"github", "google"}; !slices.Equal(got, want) {
+		t.Errorf("Providers() = %v, want %v", got, want)
+	}
+
+	reopened.DeleteUser("user-test-0")
+	if got := NewProviderTokenStore(path, key).Providers("user-test-0"); len(got) != 0 {
+		t.Errorf("Providers() after DeleteUser = %v, want none", got)
+	}
+}
@@ -0,0 +1,150 @@
+package internal
+
+import (
+	"errors"
+	"log"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+)
+
+const returnToMethod = "Redirects.ReturnTo"
+
+// RedirectOptions configures the frontend pages that the backend redirects to once an
+// authentication flow completes.
+type RedirectOptions struct {
+	SuccessURL       string
+	MFARequiredURL   string
+	ErrorURL         string
+	PasswordResetURL string
+
+	// AllowedHosts lists the hosts that return_to URLs may point to, in addition to the
+	// hosts of the pages above.
+	AllowedHosts []string
+}
+
+// Redirector decides where to send the browser after an authentication flow. A return_to
+// URL can be stored before the flow starts, so that deep links survive the login.
+type Redirector struct {
+	cookieStore *CookieStore
+	opts        RedirectOptions
+}
+
+func NewRedirector(cookieStore *CookieStore, opts RedirectOptions) *Redirector {
+	for _, page := range []string{opts.SuccessURL, opts.MFARequiredURL, opts.ErrorURL, opts.PasswordResetURL} {
+		if u, err := url.Parse(page); err == nil && u.Host != "" {
+			opts.AllowedHosts = append(opts.AllowedHosts, u.Host)
+		}
+	}
+	return &Redirector{cookieStore, opts}
+}
+
+// ValidateReturnTo resolves a return_to URL against the success page and rejects it unless
+// it is an http or https URL on an allowed host. Relative paths such as "/settings" are
+// resolved to the frontend serving the success page.
+func (rd *Redirector) ValidateReturnTo(returnTo string) (string, error) {
+	base, err := url.Parse(rd.opts.SuccessURL)
+	if err != nil {
+		return "", err
+	}
+	ref, err := url.Parse(returnTo)
+	if err != nil {
+		return "", errors.New("return_to is not a valid URL")
+	}
+	u := base.ResolveReference(ref)
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return "", errors.New("return_to must be an http or https URL")
+	}
+	if u.User != nil || !slices.Contains(rd.opts.AllowedHosts, u.Host) {
+		return "", errors.New("return_to host is not allowed")
+	}
+	return u.String(), nil
+}
+
+// StoreReturnTo validates the return_to URL and stores it until the flow completes. An
+// empty return_to leaves any previously stored URL in place.
+func (rd *Redirector) StoreReturnTo(w http.ResponseWriter, r *http.Request, returnTo string) error {
+	if returnTo == "" {
+		return nil
+	}
+	u, err := rd.ValidateReturnTo(returnTo)
+	if err != nil {
+		return err
+	}
+	rd.cookieStore.StoreReturnTo(w, r, u)
+	return nil
+}
+
+// Next returns the page to continue with after authentication. Authenticated users go to
+// the stored return_to URL, which is then cleared, or to the success page. Users that still
+// have to complete MFA go to the MFA page, and keep their return_to URL for later.
+func (rd *Redirector) Next(w http.ResponseWriter, r *http.Request, authenticated bool) string {
+	if !authenticated {
+		return rd.opts.MFARequiredURL
+	}
+	returnTo, ok := rd.cookieStore.GetReturnTo(r)
+	if !ok {
+		return rd.opts.SuccessURL
+	}
+	rd.cookieStore.ClearReturnTo(w, r)
+
+	// The URL was validated when it was stored, but the allowlist may have changed since.
+	if u, err := rd.ValidateReturnTo(returnTo); err == nil {
+		return u
+	}
+	return rd.opts.SuccessURL
+}
+
+// Redirect sends the browser to the page returned by Next.
+func (rd *Redirector) Redirect(w http.ResponseWriter, r *http.Request, authenticated bool) {
+	http.Redirect(w, r, rd.Next(w, r, authenticated), http.StatusSeeOther)
+}
+
+// PasswordReset sends the browser to the page where users who followed a password reset
+// email choose their new password.
+func (rd *Redirector) PasswordReset(w http.ResponseWriter, r *http.Request) {
+	http.Redirect(w, r, rd.opts.PasswordResetURL, http.StatusSeeOther)
+}
+
+// Error sends the browser to the error page, passing the failed method, error message and
+// error type as query parameters.
+func (rd *Redirector) Error(w http.ResponseWriter, r *http.Request, method string, err error) {
+	log.Println(err)
+	e := AsError(err)
+	u, _ := url.Parse(rd.opts.ErrorURL)
+	q := u.Query()
+	q.Set("method", method)
+	q.Set("error", e.Message)
+	q.Set("error_type", e.Type)
+	u.RawQuery = q.Encode()
+	http.Redirect(w, r, u.String(), http.StatusSeeOther)
+}
+
+// ReturnToRequest is the request body of ReturnToHandler.
+type ReturnToRequest struct {
+	ReturnTo string `json:"return_to" validate:"max=2048"`
+}
+
+// ReturnToHandler stores a return_to URL for flows that start in the frontend, such as
+// OAuth, so that the user lands on it once the flow completes.
+func (rd *Redirector) ReturnToHandler(w http.ResponseWriter, r *http.Request) {
+	var req ReturnToRequest
+	if err := DecodeJSON(r, &req); err != nil {
+		SendError(w, returnToMethod, err)
+		return
+	}
+	if strings.TrimSpace(req.ReturnTo) == "" {
+		rd.cookieStore.ClearReturnTo(w, r)
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	if err := rd.StoreReturnTo(w, r, req.ReturnTo); err != nil {
+		SendErrorResponse(w, http.StatusBadRequest, &Response{
+			Method: returnToMethod,
+			Error:  err.Error(),
+		})
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
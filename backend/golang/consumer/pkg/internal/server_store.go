@@ -0,0 +1,317 @@
+package internal
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"maps"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/securecookie"
+	"github.com/gorilla/sessions"
+)
+
+// serverStore implements sessions.Store by keeping session values on the server. The
+// client's cookie only holds a signed, opaque ID, so Stytch tokens never leave the server.
+//
+// Entries are kept in memory and, if a file path is configured, encrypted with the cookie
+// keys and written to that file shortly after they change, so that they survive restarts.
+// A background goroutine writes the file and sweeps expired entries until Close is called.
+type serverStore struct {
+	codecs  []securecookie.Codec
+	options *sessions.Options
+	path    string
+
+	// fileCodecs encrypt the entries written to the file. Unlike the cookie codecs, they
+	// have no maximum age or length.
+	fileCodecs []securecookie.Codec
+
+	mu      sync.Mutex
+	entries map[string]serverEntry
+	// dirty is set when the entries changed since they were last written to the file.
+	dirty bool
+
+	stop      chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// serverEntry holds the values of a single session along with the subject of its session
+// JWT, which is the Stytch user or member that the session belongs to.
+type serverEntry struct {
+	Values    map[string]string `json:"values"`
+	Subject   string            `json:"subject,omitempty"`
+	ExpiresAt time.Time         `json:"expires_at"`
+}
+
+const (
+	// sweepInterval is how often expired entries are removed from the store.
+	sweepInterval = time.Minute
+	// saveInterval is how often changed entries are written to the file, which batches the
+	// changes of many requests into a single write.
+	saveInterval = time.Second
+)
+
+// serverStoreFileName is the name that the encrypted file contents are bound to, so that they
+// cannot be swapped with cookie values encrypted under the same keys.
+const serverStoreFileName = "server_sessions"
+
+// newServerStore creates a server-side store. If path is empty, entries are only kept in
+// memory and are lost when the server restarts.
+func newServerStore(path string, keyPairs ...[]byte) *serverStore {
+	fileCodecs := securecookie.CodecsFromPairs(keyPairs...)
+	for _, codec := range fileCodecs {
+		if sc, ok := codec.(*securecookie.SecureCookie); ok {
+			sc.MaxAge(0)
+			sc.MaxLength(0)
+			sc.SetSerializer(securecookie.JSONEncoder{})
+		}
+	}
+	s := &serverStore{
+		codecs: securecookie.CodecsFromPairs(keyPairs...),
+		options: &sessions.Options{
+			Path:   "/",
+			MaxAge: 86400 * 30,
+		},
+		path:       path,
+		fileCodecs: fileCodecs,
+		entries:    map[string]serverEntry{},
+		stop:       make(chan struct{}),
+		done:       make(chan struct{}),
+	}
+	if path != "" {
+		s.loadFile()
+	}
+	go s.run()
+	return s
+}
+
+// Close stops the background goroutine and writes pending changes to the file.
+func (s *serverStore) Close() {
+	s.closeOnce.Do(func() {
+		close(s.stop)
+		<-s.done
+	})
+}
+
+// Get returns a session for the given name after adding it to the registry.
+func (s *serverStore) Get(r *http.Request, name string) (*sessions.Session, error) {
+	return sessions.GetRegistry(r).Get(s, name)
+}
+
+// New returns a session for the given name, loading its values from the store if the
+// request carries a cookie with a known ID.
+func (s *serverStore) New(r *http.Request, name string) (*sessions.Session, error) {
+	session := sessions.NewSession(s, name)
+	opts := *s.options
+	session.Options = &opts
+	session.IsNew = true
+
+	c, err := r.Cookie(name)
+	if err != nil {
+		return session, nil
+	}
+	if err := securecookie.DecodeMulti(name, c.Value, &session.ID, s.codecs...); err != nil {
+		return session, err
+	}
+
+	s.mu.Lock()
+	entry, ok := s.entries[session.ID]
+	s.mu.Unlock()
+	if !ok || time.Now().After(entry.ExpiresAt) {
+		session.ID = ""
+		return session, nil
+	}
+
+	for key, value := range entry.Values {
+		session.Values[key] = value
+	}
+	session.IsNew = false
+	return session, nil
+}
+
+// Save stores the session values on the server and writes the session ID to the cookie.
+// Sessions with a negative MaxAge are deleted from the store.
+func (s *serverStore) Save(_ *http.Request, w http.ResponseWriter, session *sessions.Session) error {
+	if session.Options.MaxAge < 0 {
+		if session.ID != "" {
+			s.update(func(entries map[string]serverEntry) {
+				delete(entries, session.ID)
+			})
+		}
+		http.SetCookie(w, sessions.NewCookie(session.Name(), "", session.Options))
+		return nil
+	}
+
+	if session.ID == "" {
+		id, err := newSessionID()
+		if err != nil {
+			return err
+		}
+		session.ID = id
+	}
+
+	values := map[string]string{}
+	for key, value := range session.Values {
+		k, kok := key.(string)
+		v, vok := value.(string)
+		if kok && vok {
+			values[k] = v
+		}
+	}
+	s.update(func(entries map[string]serverEntry) {
+		entries[session.ID] = serverEntry{
+			Values:    values,
+			Subject:   jwtSubject(values[jwtValue]),
+			ExpiresAt: time.Now().Add(time.Duration(session.Options.MaxAge) * time.Second),
+		}
+	})
+
+	encoded, err := securecookie.EncodeMulti(session.Name(), session.ID, s.codecs...)
+	if err != nil {
+		return err
+	}
+	http.SetCookie(w, sessions.NewCookie(session.Name(), encoded, session.Options))
+	return nil
+}
+
+// deleteSubject removes every entry that belongs to the given Stytch user or member.
+func (s *serverStore) deleteSubject(subject string) {
+	if subject == "" {
+		return
+	}
+	s.update(func(entries map[string]serverEntry) {
+		for id, entry := range entries {
+			if entry.Subject == subject {
+				delete(entries, id)
+			}
+		}
+	})
+}
+
+// run sweeps expired entries and writes changed entries to the file until the store is
+// closed.
+func (s *serverStore) run() {
+	defer close(s.done)
+
+	sweep := time.NewTicker(sweepInterval)
+	defer sweep.Stop()
+
+	var save <-chan time.Time
+	if s.path != "" {
+		ticker := time.NewTicker(saveInterval)
+		defer ticker.Stop()
+		save = ticker.C
+	}
+
+	for {
+		select {
+		case now := <-sweep.C:
+			s.update(func(entries map[string]serverEntry) {
+				for id, entry := range entries {
+					if now.After(entry.ExpiresAt) {
+						delete(entries, id)
+					}
+				}
+			})
+		case <-save:
+			s.saveFile()
+		case <-s.stop:
+			if s.path != "" {
+				s.saveFile()
+			}
+			return
+		}
+	}
+}
+
+// update applies a change to the entries and marks them to be written to the file.
+func (s *serverStore) update(change func(entries map[string]serverEntry)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	change(s.entries)
+	s.dirty = true
+}
+
+func (s *serverStore) loadFile() {
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	if err != nil {
+		log.Printf("Unable to read session store file: %v", err)
+		return
+	}
+	if err := securecookie.DecodeMulti(serverStoreFileName, string(b), &s.entries, s.fileCodecs...); err != nil {
+		log.Printf("Unable to decrypt session store file, starting with an empty store: %v", err)
+		s.entries = map[string]serverEntry{}
+	}
+}
+
+// saveFile encrypts a snapshot of the entries, if they changed, and writes it to a temporary
+// file that is then renamed, so that the store file is never left partially written. Only
+// the snapshot is taken under the lock, so requests are not blocked by the write.
+func (s *serverStore) saveFile() {
+	s.mu.Lock()
+	if !s.dirty {
+		s.mu.Unlock()
+		return
+	}
+	snapshot := maps.Clone(s.entries)
+	s.dirty = false
+	s.mu.Unlock()
+
+	if err := s.writeFile(snapshot); err != nil {
+		log.Printf("Unable to write session store file: %v", err)
+		// Retry with the next save.
+		s.mu.Lock()
+		s.dirty = true
+		s.mu.Unlock()
+	}
+}
+
+func (s *serverStore) writeFile(entries map[string]serverEntry) error {
+	encoded, err := securecookie.EncodeMulti(serverStoreFileName, entries, s.fileCodecs...)
+	if err != nil {
+		return err
+	}
+	tmp := s.path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(encoded), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp, s.path)
+}
+
+func newSessionID() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+// jwtSubject returns the "sub" claim of a session JWT without verifying it. It is only used
+// to group entries by user, and the JWT was received directly from the Stytch API.
+func jwtSubject(jwt string) string {
+	parts := strings.Split(jwt, ".")
+	if len(parts) != 3 {
+		return ""
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return ""
+	}
+	var claims struct {
+		Subject string `json:"sub"`
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return ""
+	}
+	return claims.Subject
+}
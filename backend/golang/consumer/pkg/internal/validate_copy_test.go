@@ -0,0 +1,43 @@
+package internal
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+//go:generate go test -run TestValidateCopy -update
+
+var update = flag.Bool("update", false, "copy the request validation files from the B2B backend")
+
+// validateFiles are the request validation files, which are shared with the B2B backend.
+// They are edited there and copied here with go generate.
+var validateFiles = []string{"validate.go", "validate_test.go"}
+
+// TestValidateCopy checks that the request validation files are unchanged copies of the B2B
+// backend's, so that both backends validate requests in the same way.
+func TestValidateCopy(t *testing.T) {
+	for _, name := range validateFiles {
+		src, err := os.ReadFile(filepath.Join("..", "..", "..", "b2b", "pkg", "internal", name))
+		if err != nil {
+			t.Fatalf("reading the B2B copy of %s: %v", name, err)
+		}
+		want := append([]byte("// Code generated by go generate from backend/golang/b2b/pkg/internal/"+name+". DO NOT EDIT.\n\n"), src...)
+
+		if *update {
+			if err := os.WriteFile(name, want, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			continue
+		}
+		got, err := os.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("%s differs from the B2B copy; edit the B2B copy and run go generate ./pkg/internal", name)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package magiclinks
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	MagicLinks      MagicLinksClient
+	MagicLinksEmail MagicLinksEmailClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		MagicLinks:      client.MagicLinks,
+		MagicLinksEmail: client.MagicLinks.Email,
+	}
+}
+
+// MagicLinksClient authenticates email magic links.
+// It is implemented by stytchapi.API.MagicLinks.
+type MagicLinksClient interface {
+	Authenticate(ctx context.Context, body *magiclinks.AuthenticateParams) (*magiclinks.AuthenticateResponse, error)
+}
+
+// MagicLinksEmailClient sends email magic links.
+// It is implemented by stytchapi.API.MagicLinks.Email.
+type MagicLinksEmailClient interface {
+	Send(ctx context.Context, body *email.SendParams) (*email.SendResponse, error)
+}
@@ -1,16 +1,23 @@
 package magiclinks
 
-import (
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
-
-	"backend/golang/consumer/pkg/internal"
-)
+import "backend/golang/consumer/pkg/internal"
 
 type Controller struct {
-	api         *stytchapi.API
-	cookieStore *internal.CookieStore
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// magicLinkURLs lists the URLs that SendEmail accepts as login and signup magic link URLs.
+	magicLinkURLs []string
+
+	// templates maps locales to the email templates that SendEmail uses for them. SendEmail
+	// accepts any template and locale when it is empty.
+	templates map[string]Templates
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
 }
 
-func NewController(api *stytchapi.API, cookieStore *internal.CookieStore) *Controller {
-	return &Controller{api, cookieStore}
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, magicLinkURLs []string, templates map[string]Templates, redirector *internal.Redirector) *Controller {
+	return &Controller{api, cookieStore, sessionDurationMinutes, magicLinkURLs, templates, redirector}
 }
@@ -1,10 +1,9 @@
 package magiclinks
 
 import (
-	"encoding/json"
-	"log"
 	"net/http"
 
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/attribute"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks"
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks/email"
 
@@ -14,35 +13,111 @@ import (
 const sendEmailMethod = "MagicLinks.Email.Send"
 
 type sendEmailRequest struct {
-	EmailAddress            string `json:"email_address"`
-	LoginMagicLinkURL       string `json:"login_magic_link_url"`
-	LoginExpirationMinutes  int32  `json:"login_expiration_minutes"`
-	SignupMagicLinkURL      string `json:"signup_magic_link_url"`
-	SignupExpirationMinutes int32  `json:"signup_expiration_minutes"`
+	EmailAddress            string `json:"email_address" validate:"required,email"`
+	LoginMagicLinkURL       string `json:"login_magic_link_url" validate:"omitempty,url"`
+	LoginExpirationMinutes  int32  `json:"login_expiration_minutes" validate:"omitempty,min=5,max=10080"`
+	SignupMagicLinkURL      string `json:"signup_magic_link_url" validate:"omitempty,url"`
+	SignupExpirationMinutes int32  `json:"signup_expiration_minutes" validate:"omitempty,min=5,max=10080"`
+
+	// LoginTemplateID and SignupTemplateID select the email templates. When templates are
+	// configured, they must be the templates of the locale and default to them.
+	LoginTemplateID  string `json:"login_template_id" validate:"max=128"`
+	SignupTemplateID string `json:"signup_template_id" validate:"max=128"`
+	// Locale is the language of the email, one of Locales. Stytch sends English emails by
+	// default.
+	Locale string `json:"locale"`
+	// PKCE binds the magic link to the browser that requested it. The backend keeps a PKCE
+	// code verifier in a cookie, and the link only authenticates together with it.
+	PKCE bool `json:"pkce"`
+	// Attributes optionally describe the device of the user, which Stytch records with the
+	// magic link.
+	Attributes *sendEmailAttributes `json:"attributes"`
+	// ReturnTo is an optional frontend URL that the user returns to after logging in.
+	ReturnTo string `json:"return_to" validate:"max=2048"`
+}
+
+type sendEmailAttributes struct {
+	IPAddress string `json:"ip_address" validate:"max=64"`
+	UserAgent string `json:"user_agent" validate:"max=512"`
 }
 
 // SendEmail wraps Stytch's Email Magic Links Send endpoint and sends an email to the specified
-// email address that can be used to login or create an account.
+// email address that can be used to login or create an account. The email is sent in the
+// requested locale with the templates configured for it. With PKCE, a code verifier is kept
+// in a cookie and its code challenge is sent to Stytch.
 func (c *Controller) SendEmail(w http.ResponseWriter, r *http.Request) {
 	var req sendEmailRequest
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		log.Println(err)
-		w.WriteHeader(http.StatusBadRequest)
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, sendEmailMethod, err)
+		return
+	}
+
+	templates, err := c.templatesFor(req.Locale, Templates{
+		LoginTemplateID:  req.LoginTemplateID,
+		SignupTemplateID: req.SignupTemplateID,
+	})
+	if err != nil {
+		internal.SendError(w, sendEmailMethod, err)
 		return
 	}
 
-	resp, err := c.api.MagicLinks.Email.Send(r.Context(), &email.SendParams{
+	// Magic link URLs receive the user's token, so they must point to this backend.
+	if err := internal.CheckAllowedURL("login_magic_link_url", req.LoginMagicLinkURL, c.magicLinkURLs); err != nil {
+		internal.SendError(w, sendEmailMethod, err)
+		return
+	}
+	if err := internal.CheckAllowedURL("signup_magic_link_url", req.SignupMagicLinkURL, c.magicLinkURLs); err != nil {
+		internal.SendError(w, sendEmailMethod, err)
+		return
+	}
+
+	if err := c.redirector.StoreReturnTo(w, r, req.ReturnTo); err != nil {
+		internal.SendErrorResponse(w, http.StatusBadRequest, &internal.Response{
+			Method: sendEmailMethod,
+			Error:  err.Error(),
+		})
+		return
+	}
+
+	var locale *email.SendRequestLocale
+	if req.Locale != "" {
+		l := email.SendRequestLocale(req.Locale)
+		locale = &l
+	}
+
+	var codeChallenge string
+	if req.PKCE {
+		codeVerifier, err := internal.RandomString()
+		if err != nil {
+			internal.SendError(w, sendEmailMethod, err)
+			return
+		}
+		c.cookieStore.StoreMagicLinkCodeVerifier(w, r, codeVerifier)
+		codeChallenge = internal.CodeChallenge(codeVerifier)
+	}
+
+	var attributes *attribute.Attributes
+	if req.Attributes != nil {
+		attributes = &attribute.Attributes{
+			IPAddress: req.Attributes.IPAddress,
+			UserAgent: req.Attributes.UserAgent,
+		}
+	}
+
+	resp, err := c.api.MagicLinksEmail.Send(r.Context(), &email.SendParams{
 		Email:                   req.EmailAddress,
 		LoginMagicLinkURL:       req.LoginMagicLinkURL,
 		LoginExpirationMinutes:  req.LoginExpirationMinutes,
+		LoginTemplateID:         templates.LoginTemplateID,
 		SignupMagicLinkURL:      req.SignupMagicLinkURL,
 		SignupExpirationMinutes: req.SignupExpirationMinutes,
+		SignupTemplateID:        templates.SignupTemplateID,
+		Locale:                  locale,
+		CodeChallenge:           codeChallenge,
+		Attributes:              attributes,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: sendEmailMethod,
-			Error:  err.Error(),
-		})
+		internal.SendError(w, sendEmailMethod, err)
 		return
 	}
 
@@ -55,8 +130,13 @@ func (c *Controller) SendEmail(w http.ResponseWriter, r *http.Request) {
 		Email:                   req.EmailAddress,
 		LoginMagicLinkURL:       req.LoginMagicLinkURL,
 		LoginExpirationMinutes:  req.LoginExpirationMinutes,
+		LoginTemplateID:         templates.LoginTemplateID,
 		SignupMagicLinkURL:      req.SignupMagicLinkURL,
 		SignupExpirationMinutes: req.SignupExpirationMinutes,
+		SignupTemplateID:        templates.SignupTemplateID,
+		Locale:                  locale,
+		CodeChallenge:           codeChallenge,
+		Attributes:              attributes,
 	},
 )`,
 	})
@@ -66,25 +146,28 @@ const authenticateMethod = "MagicLinks.Authenticate"
 
 // Authenticate is the final step in Email Magic Links flows where the Magic Links token
 // (retrieved when the user clicks the link in the email they received) can be exchanged
-// for a full session.
+// for a full session. Magic links that were sent with PKCE are authenticated together with
+// the code verifier that SendEmail stored in the browser's cookie.
 func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
-	// Retrieve the token from the query parameter.
+	// Retrieve the token from the query parameters.
 	token := r.URL.Query().Get("token")
+
+	// The code verifier can only be used once.
+	codeVerifier, _ := c.cookieStore.GetMagicLinkCodeVerifier(r)
+	c.cookieStore.ClearMagicLinkCodeVerifier(w, r)
 	resp, err := c.api.MagicLinks.Authenticate(r.Context(), &magiclinks.AuthenticateParams{
 		Token:                  token,
-		SessionDurationMinutes: 60,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+		CodeVerifier:           codeVerifier,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: authenticateMethod,
-			Error:  err.Error(),
-		})
+		c.redirector.Error(w, r, authenticateMethod, err)
 		return
 	}
 
-	// Store the session token in a cookie
-	c.cookieStore.StoreSession(w, r, resp.SessionToken)
+	// Store the session token and JWT in a cookie
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
 
-	// Redirect to the frontend after successful authentication
-	http.Redirect(w, r, "http://localhost:3001/view-session", http.StatusSeeOther)
+	// Redirect to the return_to URL or the success page after successful authentication
+	c.redirector.Redirect(w, r, true)
 }
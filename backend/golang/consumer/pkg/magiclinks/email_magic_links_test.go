@@ -0,0 +1,220 @@
+package magiclinks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+const magicLinkURL = "http://localhost:3000/magic_links/authenticate"
+
+func TestSendEmail(t *testing.T) {
+	templates := map[string]Templates{
+		"en": {LoginTemplateID: "login-en", SignupTemplateID: "signup-en"},
+		"fr": {LoginTemplateID: "login-fr", SignupTemplateID: "signup-fr"},
+	}
+	tests := []struct {
+		name          string
+		body          string
+		templates     map[string]Templates
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantField     string
+		wantTemplates Templates
+		wantPKCE      bool
+		wantReturnTo  string
+	}{
+		{
+			name:       "success",
+			body:       `{"email_address": "user@example.com", "login_magic_link_url": "` + magicLinkURL + `"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:       "PKCE",
+			body:       `{"email_address": "user@example.com", "pkce": true}`,
+			wantStatus: http.StatusOK,
+			wantPKCE:   true,
+		},
+		{
+			name:          "templates of the locale",
+			body:          `{"email_address": "user@example.com", "locale": "fr"}`,
+			templates:     templates,
+			wantStatus:    http.StatusOK,
+			wantTemplates: templates["fr"],
+		},
+		{
+			name:          "unsupported locale",
+			body:          `{"email_address": "user@example.com", "locale": "xx"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+			wantField:     "locale",
+		},
+		{
+			name:          "template of another locale",
+			body:          `{"email_address": "user@example.com", "locale": "fr", "login_template_id": "login-en"}`,
+			templates:     templates,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+			wantField:     "login_template_id",
+		},
+		{
+			name:          "magic link URL of another backend",
+			body:          `{"email_address": "user@example.com", "signup_magic_link_url": "https://evil.example.com/authenticate"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+			wantField:     "signup_magic_link_url",
+		},
+		{
+			name:         "return_to",
+			body:         `{"email_address": "user@example.com", "return_to": "/settings"}`,
+			wantStatus:   http.StatusOK,
+			wantReturnTo: "http://localhost:3001/settings",
+		},
+		{
+			name:          "return_to on another host",
+			body:          `{"email_address": "user@example.com", "return_to": "https://evil.example.com/"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+		{
+			name:          "API error",
+			body:          `{"email_address": "user@example.com"}`,
+			apiErr:        testutil.StytchError(http.StatusBadRequest, "inactive_email"),
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: "inactive_email",
+		},
+		{
+			name:          "bad body",
+			body:          `{"email_address": "user@example.com", "attributes": {"ip_address": "` + strings.Repeat("1", 65) + `"}}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+			wantField:     "attributes.ip_address",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, []string{magicLinkURL}, tt.templates, env.Redirector)
+			rec := httptest.NewRecorder()
+			c.SendEmail(rec, testutil.NewRequest(http.MethodPost, "/magic_links/email/send", tt.body))
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.Method != sendEmailMethod {
+				t.Errorf("method = %q, want %q", resp.Method, sendEmailMethod)
+			}
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantField != "" && resp.ErrorDetails.Fields[tt.wantField] == "" {
+				t.Errorf("fields = %v, want an error for %s", resp.ErrorDetails.Fields, tt.wantField)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if f.sendParams != nil && tt.apiErr == nil {
+					t.Errorf("Send() was called for a rejected request")
+				}
+				return
+			}
+
+			if f.sendParams.Email != "user@example.com" {
+				t.Errorf("Send() email = %q, want %q", f.sendParams.Email, "user@example.com")
+			}
+			got := Templates{f.sendParams.LoginTemplateID, f.sendParams.SignupTemplateID}
+			if got != tt.wantTemplates {
+				t.Errorf("Send() templates = %+v, want %+v", got, tt.wantTemplates)
+			}
+			cookies := testutil.ResponseCookies(rec)
+			codeVerifier, ok := env.CookieStore.GetMagicLinkCodeVerifier(cookies)
+			if ok != tt.wantPKCE {
+				t.Errorf("code verifier stored = %t, want %t", ok, tt.wantPKCE)
+			}
+			if want := internal.CodeChallenge(codeVerifier); ok && f.sendParams.CodeChallenge != want {
+				t.Errorf("Send() code challenge = %q, want %q", f.sendParams.CodeChallenge, want)
+			}
+			if returnTo, _ := env.CookieStore.GetReturnTo(cookies); returnTo != tt.wantReturnTo {
+				t.Errorf("stored return_to = %q, want %q", returnTo, tt.wantReturnTo)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name             string
+		cookie           func(http.ResponseWriter, *http.Request, *internal.CookieStore)
+		apiErr           error
+		wantLocation     string
+		wantErrorType    string
+		wantCodeVerifier string
+	}{
+		{
+			name:         "success",
+			wantLocation: testutil.SuccessURL,
+		},
+		{
+			name: "PKCE",
+			cookie: func(w http.ResponseWriter, r *http.Request, cs *internal.CookieStore) {
+				cs.StoreMagicLinkCodeVerifier(w, r, "code-verifier")
+			},
+			wantLocation:     testutil.SuccessURL,
+			wantCodeVerifier: "code-verifier",
+		},
+		{
+			name: "return_to",
+			cookie: func(w http.ResponseWriter, r *http.Request, cs *internal.CookieStore) {
+				cs.StoreReturnTo(w, r, "http://localhost:3001/settings")
+			},
+			wantLocation: "http://localhost:3001/settings",
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "magic_link_not_found"),
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: "magic_link_not_found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, []string{magicLinkURL}, nil, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/magic_links/authenticate?token=magic-link-token", nil)
+			if tt.cookie != nil {
+				testutil.SetCookies(r, func(w http.ResponseWriter, r *http.Request) { tt.cookie(w, r, env.CookieStore) })
+			}
+			rec := httptest.NewRecorder()
+			c.Authenticate(rec, r)
+
+			if f.authenticateParams.Token != "magic-link-token" || f.authenticateParams.CodeVerifier != tt.wantCodeVerifier {
+				t.Errorf("Authenticate() params = %+v, want the token and code verifier %q", f.authenticateParams, tt.wantCodeVerifier)
+			}
+			if rec.Code != http.StatusSeeOther {
+				t.Fatalf("status = %d, want %d", rec.Code, http.StatusSeeOther)
+			}
+			location, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("parsing Location: %v", err)
+			}
+			if q := location.Query(); q.Get("error_type") != tt.wantErrorType {
+				t.Errorf("error_type = %q, want %q", q.Get("error_type"), tt.wantErrorType)
+			}
+			location.RawQuery = ""
+			if location.String() != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			_, ok := env.CookieStore.GetSession(testutil.ResponseCookies(rec))
+			if want := tt.apiErr == nil; ok != want {
+				t.Errorf("session stored = %t, want %t", ok, want)
+			}
+		})
+	}
+}
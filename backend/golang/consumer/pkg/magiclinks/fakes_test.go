@@ -0,0 +1,43 @@
+package magiclinks
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/magiclinks/email"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory. Each call records its parameters and
+// fails with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+
+	sendParams         *email.SendParams
+	authenticateParams *magiclinks.AuthenticateParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{MagicLinks: fakeMagicLinks{f}, MagicLinksEmail: fakeMagicLinksEmail{f}}
+}
+
+type fakeMagicLinks struct{ *fakeAPI }
+
+func (f fakeMagicLinks) Authenticate(_ context.Context, body *magiclinks.AuthenticateParams) (*magiclinks.AuthenticateResponse, error) {
+	f.authenticateParams = body
+	if err := f.Record("MagicLinks.Authenticate " + body.Token); err != nil {
+		return nil, err
+	}
+	return &magiclinks.AuthenticateResponse{SessionToken: "session-token", SessionJWT: "session-jwt", StatusCode: 200}, nil
+}
+
+type fakeMagicLinksEmail struct{ *fakeAPI }
+
+func (f fakeMagicLinksEmail) Send(_ context.Context, body *email.SendParams) (*email.SendResponse, error) {
+	f.sendParams = body
+	if err := f.Record("MagicLinksEmail.Send " + body.Email); err != nil {
+		return nil, err
+	}
+	return &email.SendResponse{UserID: "user-1", StatusCode: 200}, nil
+}
@@ -0,0 +1,34 @@
+package magiclinks
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the Email Magic Links routes.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/magic_links/email/send",
+			Summary: "Send a login or signup magic link",
+			Handler: c.SendEmail,
+			Request: sendEmailRequest{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/magic_links/authenticate",
+			Summary: "Authenticate a magic link token and redirect to the frontend",
+			Handler: c.Authenticate,
+			Query:   []string{"token"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/magic_links/authenticate",
+			Summary: "Authenticate a magic link token and redirect to the frontend",
+			Handler: c.Authenticate,
+			Query:   []string{"token"},
+		},
+	}
+}
@@ -0,0 +1,52 @@
+package magiclinks
+
+import (
+	"slices"
+	"strings"
+
+	"backend/golang/consumer/pkg/internal"
+)
+
+// Locales lists the locales that Stytch sends magic link emails in. Requests and the
+// MAGIC_LINK_TEMPLATES configuration are both checked against it.
+var Locales = []string{"en", "es", "pt-br", "fr", "it", "de-DE", "zh-Hans", "ca-ES"}
+
+// defaultLocale is the locale that Stytch uses when a request names none.
+const defaultLocale = "en"
+
+// Templates are the IDs of the email templates, created in the Stytch Dashboard, that login
+// and signup magic links are sent with in one locale. An empty ID selects Stytch's default
+// template.
+type Templates struct {
+	LoginTemplateID  string
+	SignupTemplateID string
+}
+
+// templatesFor validates the locale of a request against Locales, and its template IDs against
+// the configured templates, and returns the templates to send the email with. Omitted
+// template IDs are taken from the configured templates of the locale, and other template IDs
+// are rejected, so that clients cannot send emails with templates that were not configured
+// for their language.
+func (c *Controller) templatesFor(locale string, req Templates) (Templates, error) {
+	if locale != "" && !slices.Contains(Locales, locale) {
+		return Templates{}, internal.NewFieldError("locale", "must be one of "+strings.Join(Locales, ", "))
+	}
+	if len(c.templates) == 0 {
+		return req, nil
+	}
+	if locale == "" {
+		locale = defaultLocale
+	}
+
+	configured, ok := c.templates[locale]
+	if !ok {
+		return Templates{}, internal.NewFieldError("locale", "has no configured email templates")
+	}
+	if req.LoginTemplateID != "" && req.LoginTemplateID != configured.LoginTemplateID {
+		return Templates{}, internal.NewFieldError("login_template_id", "is not the login template of the locale")
+	}
+	if req.SignupTemplateID != "" && req.SignupTemplateID != configured.SignupTemplateID {
+		return Templates{}, internal.NewFieldError("signup_template_id", "is not the signup template of the locale")
+	}
+	return configured, nil
+}
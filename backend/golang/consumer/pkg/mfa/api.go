@@ -0,0 +1,27 @@
+package mfa
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+)
+
+// API holds the clients of the Stytch Consumer API that the middleware uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	Sessions SessionsClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the middleware uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		Sessions: client.Sessions,
+	}
+}
+
+// SessionsClient authenticates user sessions.
+// It is implemented by stytchapi.API.Sessions.
+type SessionsClient interface {
+	Authenticate(ctx context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error)
+}
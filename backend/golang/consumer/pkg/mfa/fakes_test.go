@@ -0,0 +1,29 @@
+package mfa
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeSessions implements SessionsClient in memory for a session of user-1 that holds
+// factors. Each call records the session token and fails with Err when it is set.
+type fakeSessions struct {
+	testutil.Fake
+
+	factors []sessions.AuthenticationFactor
+}
+
+func (f *fakeSessions) Authenticate(_ context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error) {
+	if err := f.Record("Authenticate " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &sessions.AuthenticateResponse{
+		Session:    sessions.Session{SessionID: "session-1", UserID: "user-1", AuthenticationFactors: f.factors},
+		User:       users.User{UserID: "user-1"},
+		StatusCode: 200,
+	}, nil
+}
@@ -0,0 +1,123 @@
+// Package mfa requires sessions with two authentication factors on the routes that are
+// marked with RequireMFA in the route table.
+package mfa
+
+import (
+	"context"
+	"log"
+	"net/http"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+
+	"backend/golang/consumer/pkg/internal"
+)
+
+// Middleware authenticates the Stytch session attached to incoming requests and checks
+// that it was authenticated with two factors.
+type Middleware struct {
+	api         API
+	cookieStore *internal.CookieStore
+
+	// redirector provides the page where users add a second factor to their session.
+	redirector *internal.Redirector
+}
+
+func NewMiddleware(api API, cookieStore *internal.CookieStore, redirector *internal.Redirector) *Middleware {
+	return &Middleware{api, cookieStore, redirector}
+}
+
+type contextKey string
+
+const sessionKey contextKey = "userSession"
+
+const requireMethod = "MFA.Require"
+
+// requiredFactors is the number of distinct factors that a two-factor session holds.
+const requiredFactors = 2
+
+type authenticatedSession struct {
+	token string
+	resp  *sessions.AuthenticateResponse
+}
+
+// WithSession returns a copy of the context that carries an already authenticated session,
+// allowing middleware that authenticates sessions earlier in the chain to share the result.
+func WithSession(ctx context.Context, token string, session *sessions.AuthenticateResponse) context.Context {
+	return context.WithValue(ctx, sessionKey, authenticatedSession{token: token, resp: session})
+}
+
+// FromContext returns the session token and the authenticated user session that the session
+// middleware or Require attached to the request context. Sessions that were verified from
+// their JWT only hold the ID of their user.
+func FromContext(ctx context.Context) (token string, session *sessions.AuthenticateResponse, ok bool) {
+	s, ok := ctx.Value(sessionKey).(authenticatedSession)
+	if !ok {
+		return "", nil, false
+	}
+	return s.token, s.resp, true
+}
+
+// Require authenticates the session on the request and only calls next if the session holds
+// at least two distinct authentication factors, such as an email magic link and a TOTP
+// code. Requests without a session receive a 401 response. Single-factor sessions receive
+// a 403 response whose metadata.redirectURL is the page where the user adds a second
+// factor.
+func (m *Middleware) Require(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		st, resp, ok := m.authenticate(w, r)
+		if !ok {
+			return
+		}
+
+		if countFactors(resp.Session) < requiredFactors {
+			internal.SendErrorResponse(w, http.StatusForbidden, &internal.Response{
+				Method: requireMethod,
+				Error:  "This route requires a session with two authentication factors",
+				Metadata: map[string]any{
+					"redirectURL": m.redirector.Next(w, r, false),
+				},
+			})
+			return
+		}
+
+		next(w, r.WithContext(WithSession(r.Context(), st, resp)))
+	}
+}
+
+// authenticate returns the session of the request. If the request context already holds a
+// session, it is reused. Otherwise the session cookie is authenticated against the Stytch API.
+func (m *Middleware) authenticate(w http.ResponseWriter, r *http.Request) (string, *sessions.AuthenticateResponse, bool) {
+	if st, resp, ok := FromContext(r.Context()); ok {
+		return st, resp, true
+	}
+
+	st, ok := m.cookieStore.GetSession(r)
+	if !ok {
+		internal.SendError(w, requireMethod, internal.ErrNoSession)
+		return "", nil, false
+	}
+
+	resp, err := m.api.Sessions.Authenticate(r.Context(), &sessions.AuthenticateParams{
+		SessionToken: st,
+	})
+	if err != nil {
+		log.Println(err)
+		internal.SendErrorResponse(w, http.StatusUnauthorized, &internal.Response{
+			Method: requireMethod,
+			Error:  "Session is invalid or has expired",
+		})
+		return "", nil, false
+	}
+	return st, resp, true
+}
+
+// countFactors returns the number of distinct factors of the session. Factors are told
+// apart by their delivery method, so a magic link and a passcode that were both sent by
+// email count once, while an email passcode and an authenticator app code count twice.
+func countFactors(session sessions.Session) int {
+	seen := map[string]bool{}
+	for _, factor := range session.AuthenticationFactors {
+		seen[string(factor.DeliveryMethod)] = true
+	}
+	return len(seen)
+}
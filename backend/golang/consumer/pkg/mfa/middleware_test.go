@@ -0,0 +1,131 @@
+package mfa
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+var (
+	emailMagicLink = sessions.AuthenticationFactor{
+		Type:           sessions.AuthenticationFactorTypeMagicLink,
+		DeliveryMethod: sessions.AuthenticationFactorDeliveryMethodEmail,
+	}
+	emailOTP = sessions.AuthenticationFactor{
+		Type:           sessions.AuthenticationFactorTypeOTP,
+		DeliveryMethod: sessions.AuthenticationFactorDeliveryMethodEmail,
+	}
+	totpCode = sessions.AuthenticationFactor{
+		Type:           sessions.AuthenticationFactorTypeTOTP,
+		DeliveryMethod: sessions.AuthenticationFactorDeliveryMethodAuthenticatorApp,
+	}
+)
+
+func TestRequire(t *testing.T) {
+	tests := []struct {
+		name string
+		// factors are the factors of the session that Stytch authenticates.
+		factors  []sessions.AuthenticationFactor
+		noCookie bool
+		// contextFactors, when set, are the factors of a session already in the context.
+		contextFactors []sessions.AuthenticationFactor
+		apiErr         error
+		wantStatus     int
+		wantErrorType  string
+		wantCalls      []string
+	}{
+		{
+			name:       "two factors",
+			factors:    []sessions.AuthenticationFactor{emailMagicLink, totpCode},
+			wantStatus: http.StatusOK,
+			wantCalls:  []string{"Authenticate session-token"},
+		},
+		{
+			name:           "two factors in context",
+			contextFactors: []sessions.AuthenticationFactor{emailMagicLink, totpCode},
+			wantStatus:     http.StatusOK,
+		},
+		{
+			name:          "one factor",
+			factors:       []sessions.AuthenticationFactor{emailMagicLink},
+			wantStatus:    http.StatusForbidden,
+			wantErrorType: internal.ErrorTypeForbidden,
+			wantCalls:     []string{"Authenticate session-token"},
+		},
+		{
+			name:          "two factors by email",
+			factors:       []sessions.AuthenticationFactor{emailMagicLink, emailOTP},
+			wantStatus:    http.StatusForbidden,
+			wantErrorType: internal.ErrorTypeForbidden,
+			wantCalls:     []string{"Authenticate session-token"},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+			wantCalls:     []string{"Authenticate session-token"},
+		},
+		{
+			name:          "no cookie",
+			noCookie:      true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeSessions{Fake: testutil.Fake{Err: tt.apiErr}, factors: tt.factors}
+			env := testutil.NewEnv(t)
+			m := NewMiddleware(API{Sessions: f}, env.CookieStore, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/totps/recovery_codes", nil)
+			if !tt.noCookie {
+				testutil.AddSession(r, env.CookieStore)
+			}
+			if tt.contextFactors != nil {
+				r = r.WithContext(WithSession(r.Context(), "session-token", &sessions.AuthenticateResponse{
+					Session: sessions.Session{SessionID: "session-1", UserID: "user-1", AuthenticationFactors: tt.contextFactors},
+					User:    users.User{UserID: "user-1"},
+				}))
+			}
+
+			var gotUserID string
+			rec := httptest.NewRecorder()
+			m.Require(func(w http.ResponseWriter, r *http.Request) {
+				if _, session, ok := FromContext(r.Context()); ok {
+					gotUserID = session.User.UserID
+				}
+			})(rec, r)
+
+			if !slices.Equal(f.Calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", f.Calls, tt.wantCalls)
+			}
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus == http.StatusOK {
+				if gotUserID != "user-1" {
+					t.Errorf("user in context = %q, want %q", gotUserID, "user-1")
+				}
+				return
+			}
+			if gotUserID != "" {
+				t.Errorf("next handler called for a rejected request")
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus == http.StatusForbidden && resp.Metadata["redirectURL"] != testutil.MFARequiredURL {
+				t.Errorf("redirectURL = %v, want %q", resp.Metadata["redirectURL"], testutil.MFARequiredURL)
+			}
+		})
+	}
+}
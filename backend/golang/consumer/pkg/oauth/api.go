@@ -0,0 +1,36 @@
+package oauth
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/oauth"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	OAuth OAuthClient
+	Users UsersClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		OAuth: client.OAuth,
+		Users: client.Users,
+	}
+}
+
+// OAuthClient authenticates OAuth tokens.
+// It is implemented by stytchapi.API.OAuth.
+type OAuthClient interface {
+	Authenticate(ctx context.Context, body *oauth.AuthenticateParams) (*oauth.AuthenticateResponse, error)
+}
+
+// UsersClient reads the OAuth providers of users.
+// It is implemented by stytchapi.API.Users.
+type UsersClient interface {
+	Get(ctx context.Context, body *users.GetParams) (*users.GetResponse, error)
+}
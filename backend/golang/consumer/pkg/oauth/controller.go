@@ -1,16 +1,28 @@
 package oauth
 
-import (
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
-
-	"backend/golang/consumer/pkg/internal"
-)
+import "backend/golang/consumer/pkg/internal"
 
 type Controller struct {
-	api         *stytchapi.API
-	cookieStore *internal.CookieStore
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// stytchAPIURL is the Stytch API that browsers start OAuth flows at, and publicToken
+	// identifies the project there.
+	stytchAPIURL string
+	publicToken  string
+	// redirectURL is the backend URL that OAuth flows return to. It must be one of the
+	// redirect URLs configured in the Stytch Dashboard.
+	redirectURL string
+
+	// providerTokens keeps the provider tokens returned by OAuth flows. It is nil when
+	// provider tokens are not stored.
+	providerTokens *internal.ProviderTokenStore
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
 }
 
-func NewController(api *stytchapi.API, cookieStore *internal.CookieStore) *Controller {
-	return &Controller{api, cookieStore}
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, stytchAPIURL string, publicToken string, redirectURL string, providerTokens *internal.ProviderTokenStore, redirector *internal.Redirector) *Controller {
+	return &Controller{api, cookieStore, sessionDurationMinutes, stytchAPIURL, publicToken, redirectURL, providerTokens, redirector}
 }
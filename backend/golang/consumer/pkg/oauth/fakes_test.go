@@ -0,0 +1,58 @@
+package oauth
+
+import (
+	"context"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/oauth"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory. Each call records its parameters and
+// fails with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+
+	authenticateParams *oauth.AuthenticateParams
+	getParams          *users.GetParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{OAuth: f, Users: f}
+}
+
+func (f *fakeAPI) Authenticate(_ context.Context, body *oauth.AuthenticateParams) (*oauth.AuthenticateResponse, error) {
+	f.authenticateParams = body
+	if err := f.Record("Authenticate " + body.Token); err != nil {
+		return nil, err
+	}
+	expiresAt := time.Now().Add(time.Hour)
+	return &oauth.AuthenticateResponse{
+		UserID:          "user-1",
+		ProviderType:    "Google",
+		ProviderSubject: "google-subject",
+		SessionToken:    "new-session-token",
+		SessionJWT:      "new-session-jwt",
+		ProviderValues: oauth.ProviderValues{
+			AccessToken:  "access-token",
+			RefreshToken: "refresh-token",
+			Scopes:       []string{"email"},
+			ExpiresAt:    &expiresAt,
+		},
+		StatusCode: 200,
+	}, nil
+}
+
+func (f *fakeAPI) Get(_ context.Context, body *users.GetParams) (*users.GetResponse, error) {
+	f.getParams = body
+	if err := f.Record("Users.Get " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &users.GetResponse{
+		UserID:     body.UserID,
+		Providers:  []users.OAuthProvider{{ProviderType: "Google", ProviderSubject: "google-subject"}},
+		StatusCode: 200,
+	}, nil
+}
@@ -1,35 +1,133 @@
 package oauth
 
 import (
+	"crypto/subtle"
 	"net/http"
+	"net/url"
+	"slices"
 
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/oauth"
 
 	"backend/golang/consumer/pkg/internal"
 )
 
-const authenticateMethod = "OAuth.Authenticate"
+const (
+	startMethod        = "OAuth.Start"
+	authenticateMethod = "OAuth.Authenticate"
+)
+
+// providers lists the OAuth providers that Stytch supports for consumer projects. Each
+// provider must also be enabled in the Stytch Dashboard.
+var providers = []string{
+	"amazon", "apple", "bitbucket", "coinbase", "discord", "facebook", "figma", "github",
+	"gitlab", "google", "linkedin", "microsoft", "salesforce", "slack", "snapchat", "tiktok",
+	"twitch", "twitter", "yahoo",
+}
+
+var (
+	errUnknownProvider = internal.NewError(http.StatusNotFound, internal.ErrorTypeNotFound,
+		"Unknown OAuth provider")
+	errNoPublicToken = internal.NewError(http.StatusNotImplemented, internal.ErrorTypeNotImplemented,
+		"STYTCH_PUBLIC_TOKEN must be set to start OAuth flows")
+	errInvalidState = internal.NewError(http.StatusBadRequest, internal.ErrorTypeInvalidRequest,
+		"The OAuth state does not match the flow started by this browser")
+)
+
+// Start begins an OAuth flow with the provider in the path, such as google or github, and
+// redirects the browser to Stytch's OAuth start URL. A random state and a PKCE code
+// verifier are kept in a cookie, so that Authenticate only completes flows that were
+// started by the same browser. The optional return_to query parameter is the frontend URL
+// that the user returns to after logging in.
+func (c *Controller) Start(w http.ResponseWriter, r *http.Request) {
+	provider := r.PathValue("provider")
+	if !slices.Contains(providers, provider) {
+		c.redirector.Error(w, r, startMethod, errUnknownProvider)
+		return
+	}
+	if c.publicToken == "" {
+		c.redirector.Error(w, r, startMethod, errNoPublicToken)
+		return
+	}
+	if err := c.redirector.StoreReturnTo(w, r, r.URL.Query().Get("return_to")); err != nil {
+		c.redirector.Error(w, r, startMethod, internal.NewError(http.StatusBadRequest, internal.ErrorTypeInvalidRequest, err.Error()))
+		return
+	}
+
+	state, err := internal.RandomString()
+	if err != nil {
+		c.redirector.Error(w, r, startMethod, err)
+		return
+	}
+	codeVerifier, err := internal.RandomString()
+	if err != nil {
+		c.redirector.Error(w, r, startMethod, err)
+		return
+	}
+	c.cookieStore.StoreOAuthState(w, r, state, codeVerifier)
+
+	// Stytch returns to the redirect URL with its query parameters intact, so the state
+	// comes back to Authenticate alongside the OAuth token.
+	redirectURL, err := url.Parse(c.redirectURL)
+	if err != nil {
+		c.redirector.Error(w, r, startMethod, err)
+		return
+	}
+	q := redirectURL.Query()
+	q.Set("state", state)
+	redirectURL.RawQuery = q.Encode()
+
+	startURL, err := url.Parse(c.stytchAPIURL)
+	if err != nil {
+		c.redirector.Error(w, r, startMethod, err)
+		return
+	}
+	startURL = startURL.JoinPath("v1", "public", "oauth", provider, "start")
+	startURL.RawQuery = url.Values{
+		"public_token":        {c.publicToken},
+		"login_redirect_url":  {redirectURL.String()},
+		"signup_redirect_url": {redirectURL.String()},
+		"code_challenge":      {internal.CodeChallenge(codeVerifier)},
+	}.Encode()
+
+	http.Redirect(w, r, startURL.String(), http.StatusSeeOther)
+}
 
 // Authenticate completes an OAuth flow by exchanging the OAuth token received from the IdP
-// for a full session.
+// for a full session. The state in the query must match the one stored by Start, and the
+// PKCE code verifier is passed to Stytch, which rejects tokens from flows that were started
+// with a different verifier. When provider tokens are stored, the access and refresh tokens
+// issued by the provider are kept for the user.
 func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
-	// Retrieve the token from the query parameter.
+	// Retrieve the token and state from the query parameters.
 	token := r.URL.Query().Get("token")
+	state := r.URL.Query().Get("state")
+
+	// The state and code verifier can only be used once.
+	storedState, codeVerifier, ok := c.cookieStore.GetOAuthState(r)
+	c.cookieStore.ClearOAuthState(w, r)
+	if !ok || subtle.ConstantTimeCompare([]byte(state), []byte(storedState)) != 1 {
+		c.redirector.Error(w, r, authenticateMethod, errInvalidState)
+		return
+	}
+
 	resp, err := c.api.OAuth.Authenticate(r.Context(), &oauth.AuthenticateParams{
 		Token:                  token,
-		SessionDurationMinutes: 60,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+		CodeVerifier:           codeVerifier,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: authenticateMethod,
-			Error:  err.Error(),
-		})
+		c.redirector.Error(w, r, authenticateMethod, err)
 		return
 	}
 
-	// Store the session token in a cookie
-	c.cookieStore.StoreSession(w, r, resp.SessionToken)
+	// Store the session token and JWT in a cookie
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+
+	// Keep the provider tokens, so that provider APIs can be called on the user's behalf
+	if c.providerTokens != nil {
+		c.storeProviderTokens(resp)
+	}
 
-	// Redirect to the frontend after successful authentication
-	http.Redirect(w, r, "http://localhost:3001/view-session", http.StatusSeeOther)
+	// Redirect to the return_to URL or the success page after successful authentication
+	c.redirector.Redirect(w, r, true)
 }
@@ -0,0 +1,387 @@
+package oauth
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+	"time"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+const (
+	stytchAPIURL = "https://test.stytch.com"
+	redirectURL  = "http://localhost:3000/oauth/authenticate"
+)
+
+func newProviderTokenStore() *internal.ProviderTokenStore {
+	return internal.NewProviderTokenStore("", []byte("0123456789abcdef0123456789abcdef"))
+}
+
+// redirectLocation returns the Location of a redirect with its query removed, along with the
+// error_type in the query.
+func redirectLocation(t *testing.T, rec *httptest.ResponseRecorder) (location string, errorType string) {
+	t.Helper()
+	if rec.Code != http.StatusSeeOther {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusSeeOther, rec.Body)
+	}
+	u, err := url.Parse(rec.Header().Get("Location"))
+	if err != nil {
+		t.Fatalf("parsing Location: %v", err)
+	}
+	errorType = u.Query().Get("error_type")
+	u.RawQuery = ""
+	return u.String(), errorType
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name          string
+		provider      string
+		publicToken   string
+		returnTo      string
+		wantLocation  string
+		wantErrorType string
+	}{
+		{
+			name:         "success",
+			provider:     "google",
+			publicToken:  "public-token",
+			wantLocation: stytchAPIURL + "/v1/public/oauth/google/start",
+		},
+		{
+			name:          "unknown provider",
+			provider:      "myspace",
+			publicToken:   "public-token",
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: internal.ErrorTypeNotFound,
+		},
+		{
+			name:          "no public token",
+			provider:      "google",
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: internal.ErrorTypeNotImplemented,
+		},
+		{
+			name:          "return_to on another host",
+			provider:      "google",
+			publicToken:   "public-token",
+			returnTo:      "https://evil.example.com/",
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testutil.NewEnv(t)
+			c := NewController((&fakeAPI{}).api(), env.CookieStore, 60, stytchAPIURL, tt.publicToken, redirectURL, nil, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/oauth/start/"+tt.provider+"?return_to="+url.QueryEscape(tt.returnTo), nil)
+			r.SetPathValue("provider", tt.provider)
+			rec := httptest.NewRecorder()
+			c.Start(rec, r)
+
+			location, errorType := redirectLocation(t, rec)
+			if location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			if errorType != tt.wantErrorType {
+				t.Errorf("error_type = %q, want %q", errorType, tt.wantErrorType)
+			}
+			state, codeVerifier, ok := env.CookieStore.GetOAuthState(testutil.ResponseCookies(rec))
+			if want := tt.wantErrorType == ""; ok != want {
+				t.Fatalf("OAuth state stored = %t, want %t", ok, want)
+			}
+			if !ok {
+				return
+			}
+
+			q, _ := url.Parse(rec.Header().Get("Location"))
+			query := q.Query()
+			if got := query.Get("public_token"); got != tt.publicToken {
+				t.Errorf("public_token = %q, want %q", got, tt.publicToken)
+			}
+			if got := query.Get("code_challenge"); got != internal.CodeChallenge(codeVerifier) {
+				t.Errorf("code_challenge = %q, want the challenge of the stored verifier", got)
+			}
+			wantRedirect := redirectURL + "?state=" + state
+			if got := query.Get("login_redirect_url"); got != wantRedirect {
+				t.Errorf("login_redirect_url = %q, want %q", got, wantRedirect)
+			}
+			if got := query.Get("signup_redirect_url"); got != wantRedirect {
+				t.Errorf("signup_redirect_url = %q, want %q", got, wantRedirect)
+			}
+		})
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name           string
+		state          string
+		noCookie       bool
+		apiErr         error
+		providerTokens bool
+		wantLocation   string
+		wantErrorType  string
+	}{
+		{
+			name:         "success",
+			state:        "state-1",
+			wantLocation: testutil.SuccessURL,
+		},
+		{
+			name:           "provider tokens stored",
+			state:          "state-1",
+			providerTokens: true,
+			wantLocation:   testutil.SuccessURL,
+		},
+		{
+			name:          "state mismatch",
+			state:         "state-2",
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+		{
+			name:          "no cookie",
+			state:         "state-1",
+			noCookie:      true,
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+		{
+			name:          "API error",
+			state:         "state-1",
+			apiErr:        testutil.StytchError(http.StatusUnauthorized, "oauth_token_not_found"),
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: "oauth_token_not_found",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			var providerTokens *internal.ProviderTokenStore
+			if tt.providerTokens {
+				providerTokens = newProviderTokenStore()
+			}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, stytchAPIURL, "public-token", redirectURL, providerTokens, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/oauth/authenticate?token=oauth-token&state="+tt.state, nil)
+			if !tt.noCookie {
+				testutil.SetCookies(r, func(w http.ResponseWriter, r *http.Request) {
+					env.CookieStore.StoreOAuthState(w, r, "state-1", "code-verifier")
+				})
+			}
+			rec := httptest.NewRecorder()
+			c.Authenticate(rec, r)
+
+			location, errorType := redirectLocation(t, rec)
+			if location != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			if errorType != tt.wantErrorType {
+				t.Errorf("error_type = %q, want %q", errorType, tt.wantErrorType)
+			}
+			cookies := testutil.ResponseCookies(rec)
+			if _, _, ok := env.CookieStore.GetOAuthState(cookies); ok {
+				t.Error("OAuth state was not cleared")
+			}
+			if tt.wantErrorType == internal.ErrorTypeInvalidRequest {
+				if f.authenticateParams != nil {
+					t.Error("Authenticate() was called for an invalid state")
+				}
+				return
+			}
+
+			if f.authenticateParams.Token != "oauth-token" || f.authenticateParams.CodeVerifier != "code-verifier" {
+				t.Errorf("Authenticate() token, code verifier = %q, %q, want %q, %q",
+					f.authenticateParams.Token, f.authenticateParams.CodeVerifier, "oauth-token", "code-verifier")
+			}
+			st, ok := env.CookieStore.GetSession(cookies)
+			if tt.apiErr != nil {
+				if ok {
+					t.Errorf("session %q stored after an API error", st)
+				}
+				return
+			}
+			if st != "new-session-token" {
+				t.Errorf("stored session = %q, want %q", st, "new-session-token")
+			}
+			if providerTokens != nil {
+				tokens, ok := providerTokens.Get("user-1", "google")
+				if !ok || tokens.AccessToken != "access-token" || tokens.RefreshToken != "refresh-token" {
+					t.Errorf("stored provider tokens = %+v, %t, want the Google tokens", tokens, ok)
+				}
+			}
+		})
+	}
+}
+
+func TestProviders(t *testing.T) {
+	tests := []struct {
+		name             string
+		noSession        bool
+		apiErr           error
+		providerTokens   bool
+		wantStatus       int
+		wantErrorType    string
+		wantStoredTokens []any
+	}{
+		{
+			name:             "success",
+			wantStatus:       http.StatusOK,
+			wantStoredTokens: []any{},
+		},
+		{
+			name:             "stored tokens",
+			providerTokens:   true,
+			wantStatus:       http.StatusOK,
+			wantStoredTokens: []any{"google"},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "user_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "user_not_found",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			var providerTokens *internal.ProviderTokenStore
+			if tt.providerTokens {
+				providerTokens = newProviderTokenStore()
+				if err := providerTokens.Store("user-1", internal.ProviderTokens{Provider: "google", AccessToken: "access-token"}); err != nil {
+					t.Fatal(err)
+				}
+			}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, stytchAPIURL, "public-token", redirectURL, providerTokens, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/oauth/providers", nil)
+			if !tt.noSession {
+				r = stytchtest.WithUserSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.Providers(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if f.getParams.UserID != "user-1" {
+				t.Errorf("Get() user = %q, want %q", f.getParams.UserID, "user-1")
+			}
+			stored, _ := resp.Metadata["storedTokens"].([]any)
+			if len(stored) != len(tt.wantStoredTokens) || (len(stored) > 0 && stored[0] != tt.wantStoredTokens[0]) {
+				t.Errorf("storedTokens = %v, want %v", resp.Metadata["storedTokens"], tt.wantStoredTokens)
+			}
+		})
+	}
+}
+
+func TestProviderToken(t *testing.T) {
+	expired := time.Now().Add(-time.Minute)
+	tests := []struct {
+		name            string
+		provider        string
+		noStore         bool
+		noSession       bool
+		wantStatus      int
+		wantErrorType   string
+		wantAccessToken string
+	}{
+		{
+			name:            "success",
+			provider:        "google",
+			wantStatus:      http.StatusOK,
+			wantAccessToken: "access-token",
+		},
+		{
+			name:          "no token stored",
+			provider:      "github",
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: internal.ErrorTypeNotFound,
+		},
+		{
+			name:          "expired token",
+			provider:      "slack",
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: internal.ErrorTypeNotFound,
+		},
+		{
+			name:          "no token store",
+			provider:      "google",
+			noStore:       true,
+			wantStatus:    http.StatusNotImplemented,
+			wantErrorType: internal.ErrorTypeNotImplemented,
+		},
+		{
+			name:          "no session",
+			provider:      "google",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var providerTokens *internal.ProviderTokenStore
+			if !tt.noStore {
+				providerTokens = newProviderTokenStore()
+				for _, tokens := range []internal.ProviderTokens{
+					{Provider: "google", AccessToken: "access-token", RefreshToken: "refresh-token"},
+					{Provider: "slack", AccessToken: "slack-token", ExpiresAt: &expired},
+				} {
+					if err := providerTokens.Store("user-1", tokens); err != nil {
+						t.Fatal(err)
+					}
+				}
+			}
+			env := testutil.NewEnv(t)
+			c := NewController((&fakeAPI{}).api(), env.CookieStore, 60, stytchAPIURL, "public-token", redirectURL, providerTokens, env.Redirector)
+			r := httptest.NewRequest(http.MethodGet, "/oauth/providers/"+tt.provider+"/token", nil)
+			r.SetPathValue("provider", tt.provider)
+			if !tt.noSession {
+				r = stytchtest.WithUserSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.ProviderToken(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var token map[string]any
+			if err := json.Unmarshal(resp.StytchResponse, &token); err != nil {
+				t.Fatal(err)
+			}
+			if token["access_token"] != tt.wantAccessToken {
+				t.Errorf("access_token = %v, want %q", token["access_token"], tt.wantAccessToken)
+			}
+			if _, ok := token["refresh_token"]; ok {
+				t.Error("the refresh token was returned to the client")
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package oauth
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/oauth"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/mfa"
+)
+
+const (
+	providersMethod     = "OAuth.Providers"
+	providerTokenMethod = "OAuth.ProviderToken"
+)
+
+var errNoTokenStore = internal.NewError(http.StatusNotImplemented, internal.ErrorTypeNotImplemented,
+	"OAUTH_TOKEN_STORE must be set to store provider tokens")
+
+// storeProviderTokens keeps the tokens that the provider issued in an OAuth flow. Failures
+// are logged rather than failing the login.
+func (c *Controller) storeProviderTokens(resp *oauth.AuthenticateResponse) {
+	values := resp.ProviderValues
+	err := c.providerTokens.Store(resp.UserID, internal.ProviderTokens{
+		Provider:        strings.ToLower(resp.ProviderType),
+		ProviderSubject: resp.ProviderSubject,
+		AccessToken:     values.AccessToken,
+		RefreshToken:    values.RefreshToken,
+		IDToken:         values.IDToken,
+		Scopes:          values.Scopes,
+		ExpiresAt:       values.ExpiresAt,
+		UpdatedAt:       time.Now(),
+	})
+	if err != nil {
+		log.Printf("Unable to store %s tokens of %s: %v", resp.ProviderType, resp.UserID, err)
+	}
+}
+
+// Providers returns the OAuth providers that the signed-in user has logged in with, along
+// with the profile data that Stytch keeps for each of them. The metadata lists the providers
+// whose tokens are stored by the backend.
+func (c *Controller) Providers(w http.ResponseWriter, r *http.Request) {
+	session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, providersMethod, err)
+		return
+	}
+
+	user, err := c.api.Users.Get(r.Context(), &users.GetParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, providersMethod, err)
+		return
+	}
+
+	storedTokens := []string{}
+	if c.providerTokens != nil {
+		storedTokens = c.providerTokens.Providers(session.User.UserID)
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      providersMethod,
+		APIResponse: user.Providers,
+		CodeSnippet: `user, err := c.api.Users.Get(
+	r.Context(),
+	&users.GetParams{
+		UserID: session.User.UserID,
+	},
+)
+
+providers := user.Providers`,
+		Metadata: map[string]any{
+			"storedTokens": storedTokens,
+		},
+	})
+}
+
+// providerTokenResponse is the access token of a provider as returned to the client. The
+// refresh token never leaves the server.
+type providerTokenResponse struct {
+	Provider    string     `json:"provider"`
+	AccessToken string     `json:"access_token"`
+	IDToken     string     `json:"id_token,omitempty"`
+	Scopes      []string   `json:"scopes"`
+	ExpiresAt   *time.Time `json:"expires_at,omitempty"`
+}
+
+// ProviderToken returns the stored access token that the provider in the path issued to the
+// signed-in user, for calling the provider's APIs such as Google Calendar or GitHub.
+//
+// The token is not refreshed: the Consumer SDK has no call for Stytch's provider-values
+// endpoint, so Stytch only returns provider tokens when an OAuth flow completes. Once the
+// access token expires the user must go through /oauth/start/{provider} again to get a fresh
+// one. The stored refresh token can be used with the provider directly.
+func (c *Controller) ProviderToken(w http.ResponseWriter, r *http.Request) {
+	if c.providerTokens == nil {
+		internal.SendError(w, providerTokenMethod, errNoTokenStore)
+		return
+	}
+
+	session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, providerTokenMethod, err)
+		return
+	}
+
+	provider := r.PathValue("provider")
+	tokens, ok := c.providerTokens.Get(session.User.UserID, provider)
+	if !ok || tokens.Expired() {
+		internal.SendError(w, providerTokenMethod, internal.NewError(http.StatusNotFound, internal.ErrorTypeNotFound,
+			fmt.Sprintf("No unexpired %s access token is stored, start a new OAuth flow at /oauth/start/%s", provider, provider)))
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method: providerTokenMethod,
+		APIResponse: providerTokenResponse{
+			Provider:    tokens.Provider,
+			AccessToken: tokens.AccessToken,
+			IDToken:     tokens.IDToken,
+			Scopes:      tokens.Scopes,
+			ExpiresAt:   tokens.ExpiresAt,
+		},
+		CodeSnippet: `tokens, ok := c.providerTokens.Get(session.User.UserID, provider)`,
+	})
+}
+
+// currentSession returns the session that the session middleware authenticated and attached
+// to the request context.
+func (c *Controller) currentSession(r *http.Request) (*sessions.AuthenticateResponse, error) {
+	_, session, ok := mfa.FromContext(r.Context())
+	if !ok {
+		return nil, internal.ErrNoSession
+	}
+	return session, nil
+}
@@ -0,0 +1,46 @@
+package oauth
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the OAuth routes.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/oauth/start/{provider}",
+			Summary: "Start an OAuth flow and redirect to the provider",
+			Handler: c.Start,
+			Query:   []string{"return_to"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/oauth/authenticate",
+			Summary: "Authenticate an OAuth token and redirect to the frontend",
+			Handler: c.Authenticate,
+			Query:   []string{"token", "state"},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/oauth/authenticate",
+			Summary: "Authenticate an OAuth token and redirect to the frontend",
+			Handler: c.Authenticate,
+			Query:   []string{"token", "state"},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/oauth/providers",
+			Summary: "List the OAuth providers of the signed-in user",
+			Handler: c.Providers,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/oauth/providers/{provider}/token",
+			Summary: "Get the stored provider access token of the signed-in user",
+			Handler: c.ProviderToken,
+		},
+	}
+}
@@ -0,0 +1,131 @@
+package openapi_test
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+
+	"backend/golang/consumer/pkg/authservice"
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/router"
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+const (
+	projectID     = "project-test-00000000-0000-0000-0000-000000000000"
+	projectSecret = "secret-test-openapi"
+)
+
+// recordingMux records the patterns that are registered on it.
+type recordingMux struct {
+	*http.ServeMux
+	patterns []string
+}
+
+func (m *recordingMux) HandleFunc(pattern string, handler func(http.ResponseWriter, *http.Request)) {
+	m.patterns = append(m.patterns, pattern)
+	m.ServeMux.HandleFunc(pattern, handler)
+}
+
+// TestDocumentDescribesRoutes checks that the OpenAPI document served at /openapi.json
+// describes exactly the method patterns that are registered on the mux, each with a summary
+// and, for routes that read a JSON body, a request body schema that is only required when
+// an empty body is rejected.
+func TestDocumentDescribesRoutes(t *testing.T) {
+	fake := stytchtest.NewServer(projectID, projectSecret).Start()
+	defer fake.Close()
+	client, err := stytchapi.NewClient(projectID, projectSecret, stytchapi.WithBaseURI(fake.URL))
+	if err != nil {
+		t.Fatalf("NewClient() = %v", err)
+	}
+	service := authservice.New(client, authservice.Options{
+		CookieKeyPairs: [][]byte{[]byte(strings.Repeat("a", 32)), []byte(strings.Repeat("b", 32))},
+	})
+	defer service.Close()
+
+	mux := &recordingMux{ServeMux: http.NewServeMux()}
+	routes := service.Routes()
+	router.Register(mux, routes)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d: %s", rec.Code, rec.Body)
+	}
+	var doc struct {
+		Paths map[string]map[string]struct {
+			Summary     string `json:"summary"`
+			RequestBody *struct {
+				Required bool `json:"required"`
+			} `json:"requestBody"`
+		} `json:"paths"`
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &doc); err != nil {
+		t.Fatalf("decoding the OpenAPI document: %v", err)
+	}
+
+	// The fallback handlers for unsupported methods and unknown paths have no method.
+	var registered []string
+	for _, pattern := range mux.patterns {
+		method, path, ok := strings.Cut(pattern, " ")
+		if !ok {
+			continue
+		}
+		if path == "/{$}" {
+			path = "/"
+		}
+		registered = append(registered, method+" "+path)
+	}
+	var documented []string
+	for path, ops := range doc.Paths {
+		for method := range ops {
+			documented = append(documented, strings.ToUpper(method)+" "+path)
+		}
+	}
+	slices.Sort(registered)
+	slices.Sort(documented)
+	for _, pattern := range registered {
+		if !slices.Contains(documented, pattern) {
+			t.Errorf("%s is registered but missing from the OpenAPI document", pattern)
+		}
+	}
+	for _, pattern := range documented {
+		if !slices.Contains(registered, pattern) {
+			t.Errorf("%s is in the OpenAPI document but not registered", pattern)
+		}
+	}
+
+	for _, route := range routes {
+		t.Run(route.Pattern(), func(t *testing.T) {
+			op, ok := doc.Paths[route.Path][strings.ToLower(route.Method)]
+			if !ok {
+				return // Reported with the registered patterns above.
+			}
+			if op.Summary == "" {
+				t.Error("route has no summary")
+			}
+
+			if route.Request == nil {
+				if op.RequestBody != nil {
+					t.Error("route reads no body but has a request body schema")
+				}
+				return
+			}
+			if op.RequestBody == nil {
+				t.Fatal("route has no request body schema")
+			}
+			var validationErr *internal.Error
+			emptyRejected := errors.As(internal.Validate(reflect.New(reflect.TypeOf(route.Request)).Interface()), &validationErr)
+			if op.RequestBody.Required != emptyRejected {
+				t.Errorf("request body required = %v, want %v", op.RequestBody.Required, emptyRejected)
+			}
+		})
+	}
+}
@@ -0,0 +1,54 @@
+package otp
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/sms"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/whatsapp"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	OTPs         OTPsClient
+	OTPsSms      OTPsSmsClient
+	OTPsWhatsapp OTPsWhatsappClient
+	OTPsEmail    OTPsEmailClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		OTPs:         client.OTPs,
+		OTPsSms:      client.OTPs.Sms,
+		OTPsWhatsapp: client.OTPs.Whatsapp,
+		OTPsEmail:    client.OTPs.Email,
+	}
+}
+
+// OTPsClient authenticates one-time passcodes.
+// It is implemented by stytchapi.API.OTPs.
+type OTPsClient interface {
+	Authenticate(ctx context.Context, body *otp.AuthenticateParams) (*otp.AuthenticateResponse, error)
+}
+
+// OTPsSmsClient sends one-time passcodes by SMS.
+// It is implemented by stytchapi.API.OTPs.Sms.
+type OTPsSmsClient interface {
+	LoginOrCreate(ctx context.Context, body *sms.LoginOrCreateParams) (*sms.LoginOrCreateResponse, error)
+}
+
+// OTPsWhatsappClient sends one-time passcodes by WhatsApp.
+// It is implemented by stytchapi.API.OTPs.Whatsapp.
+type OTPsWhatsappClient interface {
+	LoginOrCreate(ctx context.Context, body *whatsapp.LoginOrCreateParams) (*whatsapp.LoginOrCreateResponse, error)
+}
+
+// OTPsEmailClient sends one-time passcodes by email.
+// It is implemented by stytchapi.API.OTPs.Email.
+type OTPsEmailClient interface {
+	LoginOrCreate(ctx context.Context, body *email.LoginOrCreateParams) (*email.LoginOrCreateResponse, error)
+}
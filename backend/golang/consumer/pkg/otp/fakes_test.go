@@ -0,0 +1,59 @@
+package otp
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/sms"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/whatsapp"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory. Each call records the phone number,
+// email address or passcode it was made with, and fails with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+
+	authenticateParams *otp.AuthenticateParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{OTPs: f, OTPsSms: fakeSms{f}, OTPsWhatsapp: fakeWhatsapp{f}, OTPsEmail: fakeEmail{f}}
+}
+
+func (f *fakeAPI) Authenticate(_ context.Context, body *otp.AuthenticateParams) (*otp.AuthenticateResponse, error) {
+	f.authenticateParams = body
+	if err := f.Record("Authenticate " + body.MethodID); err != nil {
+		return nil, err
+	}
+	return &otp.AuthenticateResponse{SessionToken: "new-session-token", SessionJWT: "new-session-jwt", StatusCode: 200}, nil
+}
+
+type fakeSms struct{ *fakeAPI }
+
+func (f fakeSms) LoginOrCreate(_ context.Context, body *sms.LoginOrCreateParams) (*sms.LoginOrCreateResponse, error) {
+	if err := f.Record("Sms.LoginOrCreate " + body.PhoneNumber); err != nil {
+		return nil, err
+	}
+	return &sms.LoginOrCreateResponse{PhoneID: "phone-1", StatusCode: 200}, nil
+}
+
+type fakeWhatsapp struct{ *fakeAPI }
+
+func (f fakeWhatsapp) LoginOrCreate(_ context.Context, body *whatsapp.LoginOrCreateParams) (*whatsapp.LoginOrCreateResponse, error) {
+	if err := f.Record("Whatsapp.LoginOrCreate " + body.PhoneNumber); err != nil {
+		return nil, err
+	}
+	return &whatsapp.LoginOrCreateResponse{PhoneID: "phone-1", StatusCode: 200}, nil
+}
+
+type fakeEmail struct{ *fakeAPI }
+
+func (f fakeEmail) LoginOrCreate(_ context.Context, body *email.LoginOrCreateParams) (*email.LoginOrCreateResponse, error) {
+	if err := f.Record("Email.LoginOrCreate " + body.Email); err != nil {
+		return nil, err
+	}
+	return &email.LoginOrCreateResponse{EmailID: "email-1", StatusCode: 200}, nil
+}
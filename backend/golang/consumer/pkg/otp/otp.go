@@ -0,0 +1,211 @@
+package otp
+
+import (
+	"net/http"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/sms"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/whatsapp"
+
+	"backend/golang/consumer/pkg/internal"
+)
+
+const (
+	sendSMSMethod      = "OTPs.Sms.LoginOrCreate"
+	sendWhatsAppMethod = "OTPs.Whatsapp.LoginOrCreate"
+	sendEmailMethod    = "OTPs.Email.LoginOrCreate"
+	authenticateMethod = "OTPs.Authenticate"
+)
+
+type sendPhoneRequest struct {
+	PhoneNumber       string `json:"phone_number" validate:"required,phone"`
+	ExpirationMinutes int32  `json:"expiration_minutes" validate:"omitempty,min=1,max=10"`
+
+	// ReturnTo is an optional frontend URL that the user returns to after logging in.
+	ReturnTo string `json:"return_to" validate:"max=2048"`
+}
+
+// SendSMS wraps Stytch's SMS OTP Login or Create endpoint and texts a one-time passcode to
+// the phone number, creating a user for it if none exists. The phone_id in the response is
+// passed to Authenticate as the method_id together with the code.
+func (c *Controller) SendSMS(w http.ResponseWriter, r *http.Request) {
+	var req sendPhoneRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, sendSMSMethod, err)
+		return
+	}
+	if !c.storeReturnTo(w, r, sendSMSMethod, req.ReturnTo) {
+		return
+	}
+
+	resp, err := c.api.OTPsSms.LoginOrCreate(r.Context(), &sms.LoginOrCreateParams{
+		PhoneNumber:       req.PhoneNumber,
+		ExpirationMinutes: req.ExpirationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, sendSMSMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      sendSMSMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.OTPs.Sms.LoginOrCreate(
+	r.Context(),
+	&sms.LoginOrCreateParams{
+		PhoneNumber:       req.PhoneNumber,
+		ExpirationMinutes: req.ExpirationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"methodID": resp.PhoneID,
+		},
+	})
+}
+
+// SendWhatsApp wraps Stytch's WhatsApp OTP Login or Create endpoint and sends a one-time
+// passcode to the phone number over WhatsApp, creating a user for it if none exists.
+func (c *Controller) SendWhatsApp(w http.ResponseWriter, r *http.Request) {
+	var req sendPhoneRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, sendWhatsAppMethod, err)
+		return
+	}
+	if !c.storeReturnTo(w, r, sendWhatsAppMethod, req.ReturnTo) {
+		return
+	}
+
+	resp, err := c.api.OTPsWhatsapp.LoginOrCreate(r.Context(), &whatsapp.LoginOrCreateParams{
+		PhoneNumber:       req.PhoneNumber,
+		ExpirationMinutes: req.ExpirationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, sendWhatsAppMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      sendWhatsAppMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.OTPs.Whatsapp.LoginOrCreate(
+	r.Context(),
+	&whatsapp.LoginOrCreateParams{
+		PhoneNumber:       req.PhoneNumber,
+		ExpirationMinutes: req.ExpirationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"methodID": resp.PhoneID,
+		},
+	})
+}
+
+type sendEmailRequest struct {
+	EmailAddress      string `json:"email_address" validate:"required,email"`
+	ExpirationMinutes int32  `json:"expiration_minutes" validate:"omitempty,min=1,max=10"`
+
+	// ReturnTo is an optional frontend URL that the user returns to after logging in.
+	ReturnTo string `json:"return_to" validate:"max=2048"`
+}
+
+// SendEmail wraps Stytch's Email OTP Login or Create endpoint and emails a one-time passcode
+// to the email address, creating a user for it if none exists.
+func (c *Controller) SendEmail(w http.ResponseWriter, r *http.Request) {
+	var req sendEmailRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, sendEmailMethod, err)
+		return
+	}
+	if !c.storeReturnTo(w, r, sendEmailMethod, req.ReturnTo) {
+		return
+	}
+
+	resp, err := c.api.OTPsEmail.LoginOrCreate(r.Context(), &email.LoginOrCreateParams{
+		Email:             req.EmailAddress,
+		ExpirationMinutes: req.ExpirationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, sendEmailMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      sendEmailMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.OTPs.Email.LoginOrCreate(
+	r.Context(),
+	&email.LoginOrCreateParams{
+		Email:             req.EmailAddress,
+		ExpirationMinutes: req.ExpirationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"methodID": resp.EmailID,
+		},
+	})
+}
+
+// storeReturnTo remembers the frontend URL that the user returns to once the passcode is
+// verified, responding with an error if the URL is not allowed.
+func (c *Controller) storeReturnTo(w http.ResponseWriter, r *http.Request, method string, returnTo string) bool {
+	if err := c.redirector.StoreReturnTo(w, r, returnTo); err != nil {
+		internal.SendErrorResponse(w, http.StatusBadRequest, &internal.Response{
+			Method: method,
+			Error:  err.Error(),
+		})
+		return false
+	}
+	return true
+}
+
+type authenticateRequest struct {
+	MethodID string `json:"method_id" validate:"required"`
+	Code     string `json:"code" validate:"required,min=6,max=6"`
+}
+
+// Authenticate wraps Stytch's OTP Authenticate endpoint and verifies the passcode sent to
+// the phone number or email address identified by method_id. When the request carries a
+// session, the passcode is added to it as another factor. Otherwise a new session is
+// started. Either way, the resulting session is stored in a cookie.
+func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
+	var req authenticateRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	// An existing session is optional, so a missing cookie simply starts a new session.
+	st, _ := c.cookieStore.GetSession(r)
+
+	resp, err := c.api.OTPs.Authenticate(r.Context(), &otp.AuthenticateParams{
+		MethodID:               req.MethodID,
+		Code:                   req.Code,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+	redirectURL := c.redirector.Next(w, r, true)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      authenticateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.OTPs.Authenticate(
+	r.Context(),
+	&otp.AuthenticateParams{
+		MethodID:               req.MethodID,
+		Code:                   req.Code,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
+	})
+}
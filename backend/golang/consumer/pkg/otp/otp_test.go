@@ -0,0 +1,191 @@
+package otp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+func TestSend(t *testing.T) {
+	handlers := []struct {
+		name         string
+		handler      func(*Controller) http.HandlerFunc
+		method       string
+		body         string
+		badBody      string
+		wantCall     string
+		wantMethodID string
+	}{
+		{
+			name:         "SMS",
+			handler:      func(c *Controller) http.HandlerFunc { return c.SendSMS },
+			method:       sendSMSMethod,
+			body:         `{"phone_number": "+15555550100"}`,
+			badBody:      `{"phone_number": "555-0100"}`,
+			wantCall:     "Sms.LoginOrCreate +15555550100",
+			wantMethodID: "phone-1",
+		},
+		{
+			name:         "WhatsApp",
+			handler:      func(c *Controller) http.HandlerFunc { return c.SendWhatsApp },
+			method:       sendWhatsAppMethod,
+			body:         `{"phone_number": "+15555550100", "expiration_minutes": 5}`,
+			badBody:      `{"phone_number": "+15555550100", "expiration_minutes": 60}`,
+			wantCall:     "Whatsapp.LoginOrCreate +15555550100",
+			wantMethodID: "phone-1",
+		},
+		{
+			name:         "email",
+			handler:      func(c *Controller) http.HandlerFunc { return c.SendEmail },
+			method:       sendEmailMethod,
+			body:         `{"email_address": "user@example.com"}`,
+			badBody:      `{}`,
+			wantCall:     "Email.LoginOrCreate user@example.com",
+			wantMethodID: "email-1",
+		},
+	}
+	tests := []struct {
+		name          string
+		badBody       bool
+		returnTo      string
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "return_to on another host",
+			returnTo:      "https://evil.example.com/",
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusTooManyRequests, "too_many_requests"),
+			wantStatus:    http.StatusTooManyRequests,
+			wantErrorType: "too_many_requests",
+		},
+		{
+			name:          "bad body",
+			badBody:       true,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, h := range handlers {
+		for _, tt := range tests {
+			t.Run(h.name+"/"+tt.name, func(t *testing.T) {
+				body := h.body
+				if tt.badBody {
+					body = h.badBody
+				} else if tt.returnTo != "" {
+					body = body[:len(body)-1] + `, "return_to": "` + tt.returnTo + `"}`
+				}
+				f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+				env := testutil.NewEnv(t)
+				c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+				rec := httptest.NewRecorder()
+				h.handler(c)(rec, testutil.NewRequest(http.MethodPost, "/otps/send", body))
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				resp := testutil.Decode(t, rec)
+				if resp.Method != h.method {
+					t.Errorf("method = %q, want %q", resp.Method, h.method)
+				}
+				if resp.ErrorType() != tt.wantErrorType {
+					t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+				}
+				var wantCalls []string
+				if tt.wantStatus == http.StatusOK || tt.apiErr != nil {
+					wantCalls = []string{h.wantCall}
+				}
+				if !slices.Equal(f.Calls, wantCalls) {
+					t.Errorf("calls = %q, want %q", f.Calls, wantCalls)
+				}
+				if tt.wantStatus == http.StatusOK && resp.Metadata["methodID"] != h.wantMethodID {
+					t.Errorf("methodID = %v, want %q", resp.Metadata["methodID"], h.wantMethodID)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name             string
+		body             string
+		cookie           bool
+		apiErr           error
+		wantStatus       int
+		wantErrorType    string
+		wantSessionToken string
+	}{
+		{
+			name:       "new session",
+			body:       `{"method_id": "phone-1", "code": "123456"}`,
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:             "existing session",
+			body:             `{"method_id": "phone-1", "code": "123456"}`,
+			cookie:           true,
+			wantStatus:       http.StatusOK,
+			wantSessionToken: "session-token",
+		},
+		{
+			name:          "API error",
+			body:          `{"method_id": "phone-1", "code": "000000"}`,
+			apiErr:        testutil.StytchError(http.StatusUnauthorized, "otp_code_not_found"),
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: "otp_code_not_found",
+		},
+		{
+			name:          "bad body",
+			body:          `{"method_id": "phone-1", "code": "1234"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/otps/authenticate", tt.body)
+			if tt.cookie {
+				testutil.AddSession(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.Authenticate(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if f.authenticateParams.SessionToken != tt.wantSessionToken {
+				t.Errorf("Authenticate() session token = %q, want %q", f.authenticateParams.SessionToken, tt.wantSessionToken)
+			}
+			if st, _ := env.CookieStore.GetSession(testutil.ResponseCookies(rec)); st != "new-session-token" {
+				t.Errorf("stored session = %q, want %q", st, "new-session-token")
+			}
+			if got := resp.Metadata["redirectURL"]; got != testutil.SuccessURL {
+				t.Errorf("redirectURL = %v, want %q", got, testutil.SuccessURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package otp
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the One-Time Passcode routes.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/otps/sms/send",
+			Summary: "Send a one-time passcode by SMS",
+			Handler: c.SendSMS,
+			Request: sendPhoneRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/otps/whatsapp/send",
+			Summary: "Send a one-time passcode by WhatsApp",
+			Handler: c.SendWhatsApp,
+			Request: sendPhoneRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/otps/email/send",
+			Summary: "Send a one-time passcode by email",
+			Handler: c.SendEmail,
+			Request: sendEmailRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/otps/authenticate",
+			Summary: "Verify a one-time passcode and store the session",
+			Handler: c.Authenticate,
+			Request: authenticateRequest{},
+		},
+	}
+}
@@ -0,0 +1,49 @@
+package passwords
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/session"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	Passwords         PasswordsClient
+	PasswordsEmail    PasswordsEmailClient
+	PasswordsSessions PasswordsSessionsClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		Passwords:         client.Passwords,
+		PasswordsEmail:    client.Passwords.Email,
+		PasswordsSessions: client.Passwords.Sessions,
+	}
+}
+
+// PasswordsClient creates, checks and migrates passwords and authenticates with them.
+// It is implemented by stytchapi.API.Passwords.
+type PasswordsClient interface {
+	Create(ctx context.Context, body *passwords.CreateParams) (*passwords.CreateResponse, error)
+	Authenticate(ctx context.Context, body *passwords.AuthenticateParams) (*passwords.AuthenticateResponse, error)
+	StrengthCheck(ctx context.Context, body *passwords.StrengthCheckParams) (*passwords.StrengthCheckResponse, error)
+	Migrate(ctx context.Context, body *passwords.MigrateParams) (*passwords.MigrateResponse, error)
+}
+
+// PasswordsEmailClient resets passwords through a link sent by email.
+// It is implemented by stytchapi.API.Passwords.Email.
+type PasswordsEmailClient interface {
+	ResetStart(ctx context.Context, body *email.ResetStartParams) (*email.ResetStartResponse, error)
+	Reset(ctx context.Context, body *email.ResetParams) (*email.ResetResponse, error)
+}
+
+// PasswordsSessionsClient resets the password of a signed-in user.
+// It is implemented by stytchapi.API.Passwords.Sessions.
+type PasswordsSessionsClient interface {
+	Reset(ctx context.Context, body *session.ResetParams) (*session.ResetResponse, error)
+}
@@ -0,0 +1,23 @@
+package passwords
+
+import "backend/golang/consumer/pkg/internal"
+
+type Controller struct {
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// resetLinkURL is the backend URL that password reset emails link to. It must be one
+	// of the redirect URLs configured in the Stytch Dashboard.
+	resetLinkURL string
+	// migrationKey is the bearer token that authorizes password hash migrations. Migration
+	// is disabled while it is empty.
+	migrationKey string
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
+}
+
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, resetLinkURL string, migrationKey string, redirector *internal.Redirector) *Controller {
+	return &Controller{api, cookieStore, sessionDurationMinutes, resetLinkURL, migrationKey, redirector}
+}
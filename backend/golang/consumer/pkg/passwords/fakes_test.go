@@ -0,0 +1,81 @@
+package passwords
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/session"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory. Each call is recorded with the email
+// address, reset token or session token that it was made with, and fails with Err when it
+// is set.
+type fakeAPI struct {
+	testutil.Fake
+
+	migrateParams *passwords.MigrateParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{Passwords: f, PasswordsEmail: fakeEmail{f}, PasswordsSessions: fakeSessions{f}}
+}
+
+func (f *fakeAPI) Create(_ context.Context, body *passwords.CreateParams) (*passwords.CreateResponse, error) {
+	if err := f.Record("Create " + body.Email); err != nil {
+		return nil, err
+	}
+	return &passwords.CreateResponse{SessionToken: "new-session-token", SessionJWT: "new-session-jwt", StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Authenticate(_ context.Context, body *passwords.AuthenticateParams) (*passwords.AuthenticateResponse, error) {
+	if err := f.Record("Authenticate " + body.Email + " " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &passwords.AuthenticateResponse{SessionToken: "new-session-token", SessionJWT: "new-session-jwt", StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) StrengthCheck(_ context.Context, body *passwords.StrengthCheckParams) (*passwords.StrengthCheckResponse, error) {
+	if err := f.Record("StrengthCheck " + body.Email); err != nil {
+		return nil, err
+	}
+	return &passwords.StrengthCheckResponse{
+		Score:    1,
+		Feedback: &passwords.Feedback{Warning: "This is a very common password.", Suggestions: []string{"Add another word or two."}},
+	}, nil
+}
+
+func (f *fakeAPI) Migrate(_ context.Context, body *passwords.MigrateParams) (*passwords.MigrateResponse, error) {
+	f.migrateParams = body
+	if err := f.Record("Migrate " + body.Email); err != nil {
+		return nil, err
+	}
+	return &passwords.MigrateResponse{UserID: "user-1", StatusCode: 200}, nil
+}
+
+type fakeEmail struct{ *fakeAPI }
+
+func (f fakeEmail) ResetStart(_ context.Context, body *email.ResetStartParams) (*email.ResetStartResponse, error) {
+	if err := f.Record("Email.ResetStart " + body.Email + " " + body.ResetPasswordRedirectURL); err != nil {
+		return nil, err
+	}
+	return &email.ResetStartResponse{StatusCode: 200}, nil
+}
+
+func (f fakeEmail) Reset(_ context.Context, body *email.ResetParams) (*email.ResetResponse, error) {
+	if err := f.Record("Email.Reset " + body.Token + " " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &email.ResetResponse{SessionToken: "new-session-token", SessionJWT: "new-session-jwt", StatusCode: 200}, nil
+}
+
+type fakeSessions struct{ *fakeAPI }
+
+func (f fakeSessions) Reset(_ context.Context, body *session.ResetParams) (*session.ResetResponse, error) {
+	if err := f.Record("Sessions.Reset " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &session.ResetResponse{SessionToken: "new-session-token", SessionJWT: "new-session-jwt", StatusCode: 200}, nil
+}
@@ -0,0 +1,393 @@
+package passwords
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords/session"
+
+	"backend/golang/consumer/pkg/internal"
+)
+
+const (
+	createMethod         = "Passwords.Create"
+	authenticateMethod   = "Passwords.Authenticate"
+	strengthCheckMethod  = "Passwords.StrengthCheck"
+	resetStartMethod     = "Passwords.Email.ResetStart"
+	resetByEmailMethod   = "Passwords.Email.Reset"
+	resetBySessionMethod = "Passwords.Sessions.Reset"
+	migrateMethod        = "Passwords.Migrate"
+)
+
+var (
+	errNoResetToken = internal.NewError(http.StatusBadRequest, internal.ErrorTypeInvalidRequest,
+		"No password reset token found, follow the link in the password reset email again")
+	errMigrationDisabled = internal.NewError(http.StatusNotFound, internal.ErrorTypeNotFound,
+		"Password migration is disabled")
+	errMigrationUnauthorized = internal.NewError(http.StatusUnauthorized, internal.ErrorTypeUnauthorized,
+		"Invalid password migration key")
+)
+
+type passwordRequest struct {
+	EmailAddress string `json:"email_address" validate:"required,email"`
+	Password     string `json:"password" validate:"required,max=256"`
+}
+
+// Create wraps Stytch's Password Create endpoint and creates a user with the email address
+// and password, storing the new session in a cookie. Stytch rejects passwords that fail the
+// project's strength policy; StrengthCheck explains why.
+func (c *Controller) Create(w http.ResponseWriter, r *http.Request) {
+	var req passwordRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, createMethod, err)
+		return
+	}
+
+	resp, err := c.api.Passwords.Create(r.Context(), &passwords.CreateParams{
+		Email:                  req.EmailAddress,
+		Password:               req.Password,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, createMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+	redirectURL := c.redirector.Next(w, r, true)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      createMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Passwords.Create(
+	r.Context(),
+	&passwords.CreateParams{
+		Email:                  req.EmailAddress,
+		Password:               req.Password,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
+	})
+}
+
+// Authenticate wraps Stytch's Password Authenticate endpoint and logs the user in with their
+// email address and password. When the request carries a session, the password is added to
+// it as another factor. Either way, the resulting session is stored in a cookie.
+func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
+	var req passwordRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	// An existing session is optional, so a missing cookie simply starts a new session.
+	st, _ := c.cookieStore.GetSession(r)
+
+	resp, err := c.api.Passwords.Authenticate(r.Context(), &passwords.AuthenticateParams{
+		Email:                  req.EmailAddress,
+		Password:               req.Password,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+	redirectURL := c.redirector.Next(w, r, true)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      authenticateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Passwords.Authenticate(
+	r.Context(),
+	&passwords.AuthenticateParams{
+		Email:                  req.EmailAddress,
+		Password:               req.Password,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
+	})
+}
+
+type strengthCheckRequest struct {
+	// EmailAddress is optional. Stytch penalizes passwords that resemble it.
+	EmailAddress string `json:"email_address" validate:"omitempty,email"`
+	Password     string `json:"password" validate:"required,max=256"`
+}
+
+// StrengthCheck wraps Stytch's Password Strength Check endpoint so that the frontend can
+// give feedback while the user types a new password. The verdict and the feedback on how to
+// improve the password are summarized in the metadata.
+func (c *Controller) StrengthCheck(w http.ResponseWriter, r *http.Request) {
+	var req strengthCheckRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, strengthCheckMethod, err)
+		return
+	}
+
+	resp, err := c.api.Passwords.StrengthCheck(r.Context(), &passwords.StrengthCheckParams{
+		Password: req.Password,
+		Email:    req.EmailAddress,
+	})
+	if err != nil {
+		internal.SendError(w, strengthCheckMethod, err)
+		return
+	}
+
+	metadata := map[string]any{
+		"validPassword":    resp.ValidPassword,
+		"score":            resp.Score,
+		"breachedPassword": resp.BreachedPassword,
+		"strengthPolicy":   resp.StrengthPolicy,
+	}
+	if resp.Feedback != nil {
+		metadata["warning"] = resp.Feedback.Warning
+		metadata["suggestions"] = resp.Feedback.Suggestions
+		metadata["ludsRequirements"] = resp.Feedback.LudsRequirements
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      strengthCheckMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Passwords.StrengthCheck(
+	r.Context(),
+	&passwords.StrengthCheckParams{
+		Password: req.Password,
+		Email:    req.EmailAddress,
+	},
+)`,
+		Metadata: metadata,
+	})
+}
+
+type resetStartRequest struct {
+	EmailAddress string `json:"email_address" validate:"required,email"`
+}
+
+// ResetStart wraps Stytch's Password Email Reset Start endpoint and emails the user a link
+// to reset their password. The link points at the backend's authenticate endpoint, which
+// passes it on to ResetRedirect.
+func (c *Controller) ResetStart(w http.ResponseWriter, r *http.Request) {
+	var req resetStartRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, resetStartMethod, err)
+		return
+	}
+
+	resp, err := c.api.PasswordsEmail.ResetStart(r.Context(), &email.ResetStartParams{
+		Email:                    req.EmailAddress,
+		ResetPasswordRedirectURL: c.resetLinkURL,
+	})
+	if err != nil {
+		internal.SendError(w, resetStartMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      resetStartMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Passwords.Email.ResetStart(
+	r.Context(),
+	&email.ResetStartParams{
+		Email:                    req.EmailAddress,
+		ResetPasswordRedirectURL: c.resetLinkURL,
+	},
+)`,
+	})
+}
+
+// ResetRedirect handles the link in a password reset email. It keeps the reset token in a
+// cookie, so that it never reaches the frontend, and sends the browser to the page where
+// the user chooses their new password.
+func (c *Controller) ResetRedirect(w http.ResponseWriter, r *http.Request) {
+	token := r.URL.Query().Get("token")
+	if token == "" {
+		c.redirector.Error(w, r, resetByEmailMethod, errNoResetToken)
+		return
+	}
+
+	c.cookieStore.StorePasswordResetToken(w, r, token)
+	c.redirector.PasswordReset(w, r)
+}
+
+type resetRequest struct {
+	Password string `json:"password" validate:"required,max=256"`
+}
+
+// ResetByEmail wraps Stytch's Password Email Reset endpoint and sets the new password with
+// the reset token stored by ResetRedirect. The user is logged in with the new password, and
+// an existing session is kept and extended.
+func (c *Controller) ResetByEmail(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, resetByEmailMethod, err)
+		return
+	}
+
+	token, ok := c.cookieStore.GetPasswordResetToken(r)
+	if !ok {
+		internal.SendError(w, resetByEmailMethod, errNoResetToken)
+		return
+	}
+
+	// An existing session is optional, so a missing cookie simply starts a new session.
+	st, _ := c.cookieStore.GetSession(r)
+
+	resp, err := c.api.PasswordsEmail.Reset(r.Context(), &email.ResetParams{
+		Token:                  token,
+		Password:               req.Password,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, resetByEmailMethod, err)
+		return
+	}
+
+	c.cookieStore.ClearPasswordResetToken(w, r)
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+	redirectURL := c.redirector.Next(w, r, true)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      resetByEmailMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Passwords.Email.Reset(
+	r.Context(),
+	&email.ResetParams{
+		Token:                  token,
+		Password:               req.Password,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
+	})
+}
+
+// ResetBySession wraps Stytch's Password Session Reset endpoint and changes the password of
+// the signed-in user. Stytch only accepts sessions that were recently authenticated.
+func (c *Controller) ResetBySession(w http.ResponseWriter, r *http.Request) {
+	var req resetRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, resetBySessionMethod, err)
+		return
+	}
+
+	st, ok := c.cookieStore.GetSession(r)
+	if !ok {
+		internal.SendError(w, resetBySessionMethod, internal.ErrNoSession)
+		return
+	}
+
+	resp, err := c.api.PasswordsSessions.Reset(r.Context(), &session.ResetParams{
+		Password:               req.Password,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, resetBySessionMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      resetBySessionMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Passwords.Sessions.Reset(
+	r.Context(),
+	&session.ResetParams{
+		Password:               req.Password,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+	})
+}
+
+type migrateRequest struct {
+	EmailAddress string `json:"email_address" validate:"required,email"`
+	Hash         string `json:"hash" validate:"required,max=1024"`
+	HashType     string `json:"hash_type" validate:"required,oneof=bcrypt md_5 sha_1 phpass"`
+
+	// PrependSalt and AppendSalt are the salts of md_5 and sha_1 hashes.
+	PrependSalt string `json:"prepend_salt" validate:"max=256"`
+	AppendSalt  string `json:"append_salt" validate:"max=256"`
+}
+
+// Migrate wraps Stytch's Password Migrate endpoint and imports a password hash from a legacy
+// system, creating the user if none exists. It is meant for migration scripts rather than
+// browsers, so it requires the password migration key as a bearer token.
+func (c *Controller) Migrate(w http.ResponseWriter, r *http.Request) {
+	if err := c.authorizeMigration(r); err != nil {
+		internal.SendError(w, migrateMethod, err)
+		return
+	}
+
+	var req migrateRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, migrateMethod, err)
+		return
+	}
+
+	params := &passwords.MigrateParams{
+		Email:    req.EmailAddress,
+		Hash:     req.Hash,
+		HashType: passwords.MigrateRequestHashType(req.HashType),
+	}
+	switch params.HashType {
+	case passwords.MigrateRequestHashTypeMd5:
+		params.Md5Config = &passwords.MD5Config{PrependSalt: req.PrependSalt, AppendSalt: req.AppendSalt}
+	case passwords.MigrateRequestHashTypeSha1:
+		params.Sha1Config = &passwords.SHA1Config{PrependSalt: req.PrependSalt, AppendSalt: req.AppendSalt}
+	}
+
+	resp, err := c.api.Passwords.Migrate(r.Context(), params)
+	if err != nil {
+		internal.SendError(w, migrateMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      migrateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Passwords.Migrate(
+	r.Context(),
+	&passwords.MigrateParams{
+		Email:    req.EmailAddress,
+		Hash:     req.Hash,
+		HashType: passwords.MigrateRequestHashTypeMd5,
+		Md5Config: &passwords.MD5Config{
+			PrependSalt: req.PrependSalt,
+			AppendSalt:  req.AppendSalt,
+		},
+	},
+)`,
+	})
+}
+
+// authorizeMigration checks the request's bearer token against the password migration key.
+func (c *Controller) authorizeMigration(r *http.Request) error {
+	if c.migrationKey == "" {
+		return errMigrationDisabled
+	}
+	key, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || subtle.ConstantTimeCompare([]byte(key), []byte(c.migrationKey)) != 1 {
+		return errMigrationUnauthorized
+	}
+	return nil
+}
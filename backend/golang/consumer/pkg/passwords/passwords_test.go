@@ -0,0 +1,312 @@
+package passwords
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"slices"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/passwords"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+const (
+	resetLinkURL = "http://localhost:3000/authenticate"
+	migrationKey = "migration-key"
+)
+
+func addResetToken(r *http.Request, cs *internal.CookieStore) {
+	testutil.SetCookies(r, func(w http.ResponseWriter, r *http.Request) {
+		cs.StorePasswordResetToken(w, r, "reset-token")
+	})
+}
+
+func TestHandlers(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler func(*Controller) http.HandlerFunc
+		method  string
+		body    string
+		badBody string
+		// cookie adds the cookie that the handler requires, if any.
+		cookie                func(*http.Request, *internal.CookieStore)
+		wantNoCookieStatus    int
+		wantNoCookieErrorType string
+		wantCall              string
+		// wantSession is true for handlers that store the session of the response.
+		wantSession bool
+	}{
+		{
+			name:        "Create",
+			handler:     func(c *Controller) http.HandlerFunc { return c.Create },
+			method:      createMethod,
+			body:        `{"email_address": "user@example.com", "password": "correct horse battery staple"}`,
+			badBody:     `{"email_address": "user@example.com"}`,
+			wantCall:    "Create user@example.com",
+			wantSession: true,
+		},
+		{
+			name:        "Authenticate",
+			handler:     func(c *Controller) http.HandlerFunc { return c.Authenticate },
+			method:      authenticateMethod,
+			body:        `{"email_address": "user@example.com", "password": "correct horse battery staple"}`,
+			badBody:     `{"email_address": "user", "password": "correct horse battery staple"}`,
+			wantCall:    "Authenticate user@example.com ",
+			wantSession: true,
+		},
+		{
+			name:     "StrengthCheck",
+			handler:  func(c *Controller) http.HandlerFunc { return c.StrengthCheck },
+			method:   strengthCheckMethod,
+			body:     `{"password": "password"}`,
+			badBody:  `{"email_address": "user@example.com"}`,
+			wantCall: "StrengthCheck ",
+		},
+		{
+			name:     "ResetStart",
+			handler:  func(c *Controller) http.HandlerFunc { return c.ResetStart },
+			method:   resetStartMethod,
+			body:     `{"email_address": "user@example.com"}`,
+			badBody:  `{"email_address": ["user@example.com"]}`,
+			wantCall: "Email.ResetStart user@example.com " + resetLinkURL,
+		},
+		{
+			name:                  "ResetByEmail",
+			handler:               func(c *Controller) http.HandlerFunc { return c.ResetByEmail },
+			method:                resetByEmailMethod,
+			body:                  `{"password": "correct horse battery staple"}`,
+			badBody:               `{"password": ""}`,
+			cookie:                addResetToken,
+			wantNoCookieStatus:    http.StatusBadRequest,
+			wantNoCookieErrorType: internal.ErrorTypeInvalidRequest,
+			wantCall:              "Email.Reset reset-token ",
+			wantSession:           true,
+		},
+		{
+			name:                  "ResetBySession",
+			handler:               func(c *Controller) http.HandlerFunc { return c.ResetBySession },
+			method:                resetBySessionMethod,
+			body:                  `{"password": "correct horse battery staple"}`,
+			badBody:               `{"new_password": "correct horse battery staple"}`,
+			cookie:                testutil.AddSession,
+			wantNoCookieStatus:    http.StatusUnauthorized,
+			wantNoCookieErrorType: internal.ErrorTypeUnauthorized,
+			wantCall:              "Sessions.Reset session-token",
+			wantSession:           true,
+		},
+		{
+			name:     "Migrate",
+			handler:  func(c *Controller) http.HandlerFunc { return c.Migrate },
+			method:   migrateMethod,
+			body:     `{"email_address": "user@example.com", "hash": "$2a$10$hash", "hash_type": "bcrypt"}`,
+			badBody:  `{"email_address": "user@example.com", "hash": "hash", "hash_type": "md5"}`,
+			wantCall: "Migrate user@example.com",
+		},
+	}
+	tests := []struct {
+		name          string
+		badBody       bool
+		noCookie      bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusBadRequest, "weak_password"),
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: "weak_password",
+		},
+		{
+			name:     "no cookie",
+			noCookie: true,
+		},
+		{
+			name:          "bad body",
+			badBody:       true,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, h := range handlers {
+		for _, tt := range tests {
+			if tt.noCookie && h.cookie == nil {
+				continue
+			}
+			t.Run(h.name+"/"+tt.name, func(t *testing.T) {
+				wantStatus, wantErrorType := tt.wantStatus, tt.wantErrorType
+				if tt.noCookie {
+					wantStatus, wantErrorType = h.wantNoCookieStatus, h.wantNoCookieErrorType
+				}
+				body := h.body
+				if tt.badBody {
+					body = h.badBody
+				}
+
+				f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+				env := testutil.NewEnv(t)
+				c := NewController(f.api(), env.CookieStore, 60, resetLinkURL, migrationKey, env.Redirector)
+				r := testutil.NewRequest(http.MethodPost, "/passwords", body)
+				r.Header.Set("Authorization", "Bearer "+migrationKey)
+				if h.cookie != nil && !tt.noCookie {
+					h.cookie(r, env.CookieStore)
+				}
+				rec := httptest.NewRecorder()
+				h.handler(c)(rec, r)
+
+				if rec.Code != wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, wantStatus, rec.Body)
+				}
+				resp := testutil.Decode(t, rec)
+				if resp.Method != h.method {
+					t.Errorf("method = %q, want %q", resp.Method, h.method)
+				}
+				if resp.ErrorType() != wantErrorType {
+					t.Errorf("error type = %q, want %q", resp.ErrorType(), wantErrorType)
+				}
+				var wantCalls []string
+				if wantStatus == http.StatusOK || tt.apiErr != nil {
+					wantCalls = []string{h.wantCall}
+				}
+				if !slices.Equal(f.Calls, wantCalls) {
+					t.Errorf("calls = %q, want %q", f.Calls, wantCalls)
+				}
+				if wantStatus != http.StatusOK {
+					return
+				}
+				st, _ := env.CookieStore.GetSession(testutil.ResponseCookies(rec))
+				if stored := st == "new-session-token"; stored != h.wantSession {
+					t.Errorf("session stored = %t, want %t", stored, h.wantSession)
+				}
+			})
+		}
+	}
+}
+
+func TestAuthenticateExistingSession(t *testing.T) {
+	f := &fakeAPI{}
+	env := testutil.NewEnv(t)
+	c := NewController(f.api(), env.CookieStore, 60, resetLinkURL, "", env.Redirector)
+	r := testutil.NewRequest(http.MethodPost, "/passwords/authenticate", `{"email_address": "user@example.com", "password": "correct horse battery staple"}`)
+	testutil.AddSession(r, env.CookieStore)
+	rec := httptest.NewRecorder()
+	c.Authenticate(rec, r)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if want := []string{"Authenticate user@example.com session-token"}; !slices.Equal(f.Calls, want) {
+		t.Errorf("calls = %q, want %q", f.Calls, want)
+	}
+}
+
+func TestStrengthCheckFeedback(t *testing.T) {
+	env := testutil.NewEnv(t)
+	c := NewController((&fakeAPI{}).api(), env.CookieStore, 60, resetLinkURL, "", env.Redirector)
+	rec := httptest.NewRecorder()
+	c.StrengthCheck(rec, testutil.NewRequest(http.MethodPost, "/passwords/strength_check", `{"password": "password"}`))
+
+	resp := testutil.Decode(t, rec)
+	if resp.Metadata["validPassword"] != false || resp.Metadata["score"] != float64(1) {
+		t.Errorf("verdict = %v %v, want false 1", resp.Metadata["validPassword"], resp.Metadata["score"])
+	}
+	if resp.Metadata["warning"] != "This is a very common password." {
+		t.Errorf("warning = %v, want the feedback of Stytch", resp.Metadata["warning"])
+	}
+}
+
+func TestResetRedirect(t *testing.T) {
+	tests := []struct {
+		name          string
+		query         string
+		wantLocation  string
+		wantErrorType string
+		wantToken     string
+	}{
+		{
+			name:         "success",
+			query:        "?stytch_token_type=reset_password&token=reset-token",
+			wantLocation: testutil.PasswordResetURL,
+			wantToken:    "reset-token",
+		},
+		{
+			name:          "no token",
+			query:         "?stytch_token_type=reset_password",
+			wantLocation:  testutil.ErrorURL,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			env := testutil.NewEnv(t)
+			c := NewController((&fakeAPI{}).api(), env.CookieStore, 60, resetLinkURL, "", env.Redirector)
+			rec := httptest.NewRecorder()
+			c.ResetRedirect(rec, httptest.NewRequest(http.MethodGet, "/authenticate"+tt.query, nil))
+
+			location, err := url.Parse(rec.Header().Get("Location"))
+			if err != nil {
+				t.Fatalf("parsing Location: %v", err)
+			}
+			if q := location.Query(); q.Get("error_type") != tt.wantErrorType {
+				t.Errorf("error_type = %q, want %q", q.Get("error_type"), tt.wantErrorType)
+			}
+			location.RawQuery = ""
+			if location.String() != tt.wantLocation {
+				t.Errorf("Location = %q, want %q", location, tt.wantLocation)
+			}
+			token, _ := env.CookieStore.GetPasswordResetToken(testutil.ResponseCookies(rec))
+			if token != tt.wantToken {
+				t.Errorf("stored reset token = %q, want %q", token, tt.wantToken)
+			}
+		})
+	}
+}
+
+func TestMigrateAuthorization(t *testing.T) {
+	tests := []struct {
+		name          string
+		migrationKey  string
+		authorization string
+		wantStatus    int
+	}{
+		{name: "disabled", authorization: "Bearer " + migrationKey, wantStatus: http.StatusNotFound},
+		{name: "no key", migrationKey: migrationKey, wantStatus: http.StatusUnauthorized},
+		{name: "wrong key", migrationKey: migrationKey, authorization: "Bearer wrong-key", wantStatus: http.StatusUnauthorized},
+		{name: "key", migrationKey: migrationKey, authorization: "Bearer " + migrationKey, wantStatus: http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, resetLinkURL, tt.migrationKey, env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/passwords/migrate",
+				`{"email_address": "user@example.com", "hash": "hash", "hash_type": "md_5", "prepend_salt": "salt"}`)
+			if tt.authorization != "" {
+				r.Header.Set("Authorization", tt.authorization)
+			}
+			rec := httptest.NewRecorder()
+			c.Migrate(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if tt.wantStatus != http.StatusOK {
+				if f.migrateParams != nil {
+					t.Errorf("Migrate() was called without the migration key")
+				}
+				return
+			}
+			want := passwords.MD5Config{PrependSalt: "salt"}
+			if got := f.migrateParams.Md5Config; got == nil || *got != want {
+				t.Errorf("Migrate() md5 config = %+v, want %+v", got, want)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package passwords
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the Passwords routes. Reset links from password reset emails arrive at the
+// universal authenticate endpoint, which hands them to ResetRedirect.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/passwords/create",
+			Summary: "Create a user with a password and store the session",
+			Handler: c.Create,
+			Request: passwordRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/passwords/authenticate",
+			Summary: "Log in with an email address and password",
+			Handler: c.Authenticate,
+			Request: passwordRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/passwords/strength_check",
+			Summary: "Check the strength of a password",
+			Handler: c.StrengthCheck,
+			Request: strengthCheckRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/passwords/email/reset/start",
+			Summary: "Send a password reset email",
+			Handler: c.ResetStart,
+			Request: resetStartRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/passwords/email/reset",
+			Summary: "Reset the password with the token from a password reset email",
+			Handler: c.ResetByEmail,
+			Request: resetRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/passwords/session/reset",
+			Summary: "Reset the password of the signed-in user",
+			Handler: c.ResetBySession,
+			Request: resetRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/passwords/migrate",
+			Summary: "Migrate a user's password hash from another system",
+			Handler: c.Migrate,
+			Request: migrateRequest{},
+		},
+	}
+}
@@ -0,0 +1,31 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	Sessions SessionsClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		Sessions: client.Sessions,
+	}
+}
+
+// SessionsClient authenticates, lists and revokes user sessions.
+// It is implemented by stytchapi.API.Sessions.
+type SessionsClient interface {
+	Authenticate(ctx context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error)
+	AuthenticateJWTLocal(token string, maxTokenAge time.Duration) (*sessions.Session, error)
+	Get(ctx context.Context, body *sessions.GetParams) (*sessions.GetResponse, error)
+	Revoke(ctx context.Context, body *sessions.RevokeParams) (*sessions.RevokeResponse, error)
+}
@@ -1,16 +1,21 @@
 package session
 
 import (
-	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+	"time"
 
 	"backend/golang/consumer/pkg/internal"
 )
 
 type Controller struct {
-	api         *stytchapi.API
-	cookieStore *internal.CookieStore
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// devices remembers which device last used each session.
+	devices *deviceStore
 }
 
-func NewController(api *stytchapi.API, cookieStore *internal.CookieStore) *Controller {
-	return &Controller{api, cookieStore}
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32) *Controller {
+	devices := newDeviceStore(time.Duration(sessionDurationMinutes) * time.Minute)
+	return &Controller{api, cookieStore, sessionDurationMinutes, devices}
 }
@@ -0,0 +1,48 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestDeviceStoreSweep(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	s := newDeviceStore(time.Hour)
+	s.now = func() time.Time { return now }
+
+	r := httptest.NewRequest(http.MethodGet, "/session", nil)
+	r.Header.Set("User-Agent", "Browser")
+	s.record("session-stale", r)
+	now = now.Add(30 * time.Minute)
+	s.record("session-active", r)
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		wantStale  bool
+		wantActive bool
+	}{
+		{name: "not yet expired", advance: 30 * time.Minute, wantStale: true, wantActive: true},
+		// Sweeps run at most once per interval, so an expired device can outlive its ttl briefly.
+		{name: "expired between sweeps", advance: deviceSweepInterval / 2, wantStale: true, wantActive: true},
+		{name: "next sweep", advance: deviceSweepInterval / 2, wantStale: false, wantActive: true},
+		{name: "active device expired", advance: time.Hour, wantStale: false, wantActive: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			s.record("session-new", r)
+			if _, ok := s.get("session-stale"); ok != tt.wantStale {
+				t.Errorf("stale device kept = %t, want %t", ok, tt.wantStale)
+			}
+			if _, ok := s.get("session-active"); ok != tt.wantActive {
+				t.Errorf("active device kept = %t, want %t", ok, tt.wantActive)
+			}
+			if d, ok := s.get("session-new"); !ok || d.UserAgent != "Browser" || !d.LastSeenAt.Equal(now) {
+				t.Errorf("get(session-new) = %+v, %t, want the recorded device", d, ok)
+			}
+		})
+	}
+}
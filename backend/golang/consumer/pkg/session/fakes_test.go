@@ -0,0 +1,69 @@
+package session
+
+import (
+	"context"
+	"time"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory for user-1, whose sessions are listed in
+// userSessions. Each call is recorded and fails with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+	// jwtErr fails AuthenticateJWTLocal, for example because the JWT has expired.
+	jwtErr error
+
+	userSessions []sessions.Session
+	// sessionToken and sessionJWT are returned by Authenticate.
+	sessionToken string
+	sessionJWT   string
+
+	revoked []sessions.RevokeParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{Sessions: f}
+}
+
+func (f *fakeAPI) userSession(id string) sessions.Session {
+	return sessions.Session{SessionID: id, UserID: "user-1"}
+}
+
+func (f *fakeAPI) Authenticate(_ context.Context, body *sessions.AuthenticateParams) (*sessions.AuthenticateResponse, error) {
+	if err := f.Record("Authenticate " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &sessions.AuthenticateResponse{
+		Session:      f.userSession("session-1"),
+		SessionToken: f.sessionToken,
+		SessionJWT:   f.sessionJWT,
+		StatusCode:   200,
+	}, nil
+}
+
+func (f *fakeAPI) AuthenticateJWTLocal(token string, _ time.Duration) (*sessions.Session, error) {
+	f.Calls = append(f.Calls, "AuthenticateJWTLocal "+token)
+	if f.jwtErr != nil {
+		return nil, f.jwtErr
+	}
+	s := f.userSession("session-1")
+	return &s, nil
+}
+
+func (f *fakeAPI) Get(_ context.Context, body *sessions.GetParams) (*sessions.GetResponse, error) {
+	if err := f.Record("Get " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &sessions.GetResponse{Sessions: f.userSessions, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Revoke(_ context.Context, body *sessions.RevokeParams) (*sessions.RevokeResponse, error) {
+	if err := f.Record("Revoke"); err != nil {
+		return nil, err
+	}
+	f.revoked = append(f.revoked, *body)
+	return &sessions.RevokeResponse{StatusCode: 200}, nil
+}
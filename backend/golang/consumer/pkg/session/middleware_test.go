@@ -0,0 +1,105 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+	"backend/golang/consumer/pkg/mfa"
+)
+
+func TestRefresh(t *testing.T) {
+	tests := []struct {
+		name         string
+		noCookie     bool
+		jwtErr       error
+		apiErr       error
+		sessionToken string
+		wantCalls    []string
+		wantSession  bool
+		// wantCookie is the session token of the cookie set in the response, or "" if the
+		// cookie is left unchanged. "cleared" means that it is cleared.
+		wantCookie string
+	}{
+		{
+			name:     "no cookie",
+			noCookie: true,
+		},
+		{
+			name:        "valid JWT",
+			wantCalls:   []string{"AuthenticateJWTLocal session-jwt"},
+			wantSession: true,
+		},
+		{
+			name:         "expired JWT",
+			jwtErr:       testutil.StytchError(http.StatusUnauthorized, "jwt_expired"),
+			sessionToken: "session-token",
+			wantCalls:    []string{"AuthenticateJWTLocal session-jwt", "Authenticate session-token"},
+			wantSession:  true,
+			wantCookie:   "session-token",
+		},
+		{
+			name:         "rotated session token",
+			jwtErr:       testutil.StytchError(http.StatusUnauthorized, "jwt_expired"),
+			sessionToken: "new-session-token",
+			wantCalls:    []string{"AuthenticateJWTLocal session-jwt", "Authenticate session-token"},
+			wantSession:  true,
+			wantCookie:   "new-session-token",
+		},
+		{
+			name:       "revoked session",
+			jwtErr:     testutil.StytchError(http.StatusUnauthorized, "jwt_expired"),
+			apiErr:     testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantCalls:  []string{"AuthenticateJWTLocal session-jwt", "Authenticate session-token"},
+			wantCookie: "cleared",
+		},
+		{
+			name:      "API error",
+			jwtErr:    testutil.StytchError(http.StatusUnauthorized, "jwt_expired"),
+			apiErr:    testutil.StytchError(http.StatusInternalServerError, "internal_server_error"),
+			wantCalls: []string{"AuthenticateJWTLocal session-jwt", "Authenticate session-token"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}, jwtErr: tt.jwtErr, sessionToken: tt.sessionToken, sessionJWT: "new-session-jwt"}
+			cs := testutil.NewCookieStore(t)
+			c := NewController(f.api(), cs, 60)
+			r := httptest.NewRequest(http.MethodGet, "/session", nil)
+			if !tt.noCookie {
+				testutil.AddSession(r, cs)
+			}
+
+			var gotSession bool
+			rec := httptest.NewRecorder()
+			c.Refresh(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _, gotSession = mfa.FromContext(r.Context())
+			})).ServeHTTP(rec, r)
+
+			if !slices.Equal(f.Calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", f.Calls, tt.wantCalls)
+			}
+			if gotSession != tt.wantSession {
+				t.Errorf("session in context = %t, want %t", gotSession, tt.wantSession)
+			}
+
+			var gotCookie string
+			if len(rec.Result().Cookies()) > 0 {
+				gotCookie = "cleared"
+				if st, ok := cs.GetSession(testutil.ResponseCookies(rec)); ok {
+					gotCookie = st
+				}
+			}
+			if gotCookie != tt.wantCookie {
+				t.Errorf("session cookie = %q, want %q", gotCookie, tt.wantCookie)
+			}
+			if tt.wantSession {
+				if _, ok := c.devices.get("session-1"); !ok {
+					t.Errorf("device of session-1 was not recorded")
+				}
+			}
+		})
+	}
+}
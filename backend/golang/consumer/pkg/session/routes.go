@@ -0,0 +1,50 @@
+package session
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the Sessions routes.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/session",
+			Summary: "Get the current session",
+			Handler: c.GetCurrentSession,
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/sessions",
+			Summary: "List the active sessions of the signed-in user",
+			Handler: c.List,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/sessions/revoke",
+			Summary: "Revoke a session of the signed-in user",
+			Handler: c.Revoke,
+			Request: revokeRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/sessions/revoke_others",
+			Summary: "Revoke every session of the signed-in user except the current one",
+			Handler: c.RevokeOthers,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/logout",
+			Summary: "Log out",
+			Handler: c.Logout,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/logout/all",
+			Summary: "Log out on every device",
+			Handler: c.LogoutEverywhere,
+		},
+	}
+}
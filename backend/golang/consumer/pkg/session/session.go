@@ -1,12 +1,14 @@
 package session
 
 import (
-	"log"
 	"net/http"
+	"slices"
+	"time"
 
 	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
 
 	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/mfa"
 )
 
 // GetCurrentSession wraps Stytch's SessionAuthenticate endpoint and returns information
@@ -15,8 +17,7 @@ import (
 func (c *Controller) GetCurrentSession(w http.ResponseWriter, r *http.Request) {
 	st, ok := c.cookieStore.GetSession(r)
 	if !ok {
-		log.Println("No session token found")
-		w.WriteHeader(http.StatusBadRequest)
+		internal.SendError(w, "Session.GetCurrentSession", internal.ErrNoSession)
 		return
 	}
 
@@ -24,10 +25,7 @@ func (c *Controller) GetCurrentSession(w http.ResponseWriter, r *http.Request) {
 		SessionToken: st,
 	})
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: "Session.GetCurrentSession",
-			Error:  err.Error(),
-		})
+		internal.SendError(w, "Session.GetCurrentSession", err)
 		return
 	}
 
@@ -49,8 +47,7 @@ session, err := c.api.Sessions.Authenticate(
 func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
 	st, ok := c.cookieStore.GetSession(r)
 	if !ok {
-		log.Println("No session token found")
-		w.WriteHeader(http.StatusBadRequest)
+		internal.SendError(w, "Session.Revoke", internal.ErrNoSession)
 		return
 	}
 
@@ -59,10 +56,7 @@ func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
 	})
 
 	if err != nil {
-		internal.SendResponse(w, &internal.Response{
-			Method: "Session.Revoke",
-			Error:  err.Error(),
-		})
+		internal.SendError(w, "Session.Revoke", err)
 		return
 	}
 
@@ -79,3 +73,277 @@ func (c *Controller) Logout(w http.ResponseWriter, r *http.Request) {
 )`,
 	})
 }
+
+// LogoutEverywhere revokes every active session of the requester's user in Stytch and
+// deletes the server-side session values of every device, logging the user out everywhere.
+func (c *Controller) LogoutEverywhere(w http.ResponseWriter, r *http.Request) {
+	session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, "Session.Revoke", err)
+		return
+	}
+
+	resp, err := c.api.Sessions.Get(r.Context(), &sessions.GetParams{
+		UserID: session.Session.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, "Session.Revoke", err)
+		return
+	}
+
+	// Stytch revokes sessions one at a time, so revoke each active session of the user.
+	for _, s := range resp.Sessions {
+		_, err := c.api.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
+			SessionID: s.SessionID,
+		})
+		if err != nil {
+			internal.SendError(w, "Session.Revoke", err)
+			return
+		}
+	}
+
+	c.cookieStore.ClearAllSessions(session.Session.UserID)
+	c.cookieStore.ClearSession(w, r)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      "Session.Revoke",
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Sessions.Get(
+	r.Context(),
+	&sessions.GetParams{
+		UserID: session.Session.UserID,
+	},
+)
+
+for _, s := range resp.Sessions {
+	_, err := c.api.Sessions.Revoke(
+		r.Context(),
+		&sessions.RevokeParams{
+			SessionID: s.SessionID,
+		},
+	)
+}`,
+		Metadata: map[string]any{
+			"revokedSessions": len(resp.Sessions),
+		},
+	})
+}
+
+const (
+	listMethod         = "Sessions.List"
+	revokeMethod       = "Sessions.Revoke"
+	revokeOthersMethod = "Sessions.RevokeOthers"
+)
+
+var errSessionNotFound = internal.NewError(http.StatusNotFound, internal.ErrorTypeNotFound,
+	"The signed-in user has no active session with this ID")
+
+// sessionSummary describes an active session on the session management page.
+type sessionSummary struct {
+	SessionID string `json:"session_id"`
+	// Current is true for the session of the request.
+	Current bool `json:"current"`
+	// UserAgent and IPAddress describe the device that last used the session. They are empty
+	// for sessions that have not been used since the server started, unless Stytch recorded
+	// them when the session was created.
+	UserAgent             string                          `json:"user_agent,omitempty"`
+	IPAddress             string                          `json:"ip_address,omitempty"`
+	AuthenticationFactors []sessions.AuthenticationFactor `json:"authentication_factors"`
+	StartedAt             *time.Time                      `json:"started_at"`
+	LastActiveAt          *time.Time                      `json:"last_active_at"`
+	ExpiresAt             *time.Time                      `json:"expires_at"`
+}
+
+// summarize describes the sessions, combining Stytch's session data with the devices
+// recorded by the Refresh middleware. The last activity is the later of the two.
+func (c *Controller) summarize(userSessions []sessions.Session, currentID string) []sessionSummary {
+	summaries := make([]sessionSummary, 0, len(userSessions))
+	for _, s := range userSessions {
+		summary := sessionSummary{
+			SessionID:             s.SessionID,
+			Current:               s.SessionID == currentID,
+			AuthenticationFactors: s.AuthenticationFactors,
+			StartedAt:             s.StartedAt,
+			LastActiveAt:          s.LastAccessedAt,
+			ExpiresAt:             s.ExpiresAt,
+		}
+		if s.Attributes != nil {
+			summary.UserAgent = s.Attributes.UserAgent
+			summary.IPAddress = s.Attributes.IPAddress
+		}
+		if d, ok := c.devices.get(s.SessionID); ok {
+			summary.UserAgent = d.UserAgent
+			summary.IPAddress = d.IPAddress
+			if summary.LastActiveAt == nil || d.LastSeenAt.After(*summary.LastActiveAt) {
+				summary.LastActiveAt = &d.LastSeenAt
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries
+}
+
+// List wraps Stytch's Get Sessions endpoint and returns the active sessions of the signed-in
+// user, with the device, IP address, authentication factors and last activity of each.
+func (c *Controller) List(w http.ResponseWriter, r *http.Request) {
+	session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, listMethod, err)
+		return
+	}
+
+	resp, err := c.api.Sessions.Get(r.Context(), &sessions.GetParams{
+		UserID: session.Session.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, listMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      listMethod,
+		APIResponse: c.summarize(resp.Sessions, session.Session.SessionID),
+		CodeSnippet: `resp, err := c.api.Sessions.Get(
+	r.Context(),
+	&sessions.GetParams{
+		UserID: session.Session.UserID,
+	},
+)`,
+	})
+}
+
+type revokeRequest struct {
+	SessionID string `json:"session_id" validate:"required"`
+}
+
+// Revoke wraps Stytch's Revoke Session endpoint and revokes one session of the signed-in
+// user, such as a session on a lost device. Stytch revokes sessions by ID alone, so the ID is
+// checked against the user's own sessions first. Revoking the current session logs the user
+// out like Logout.
+func (c *Controller) Revoke(w http.ResponseWriter, r *http.Request) {
+	var req revokeRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, revokeMethod, err)
+		return
+	}
+
+	session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, revokeMethod, err)
+		return
+	}
+
+	list, err := c.api.Sessions.Get(r.Context(), &sessions.GetParams{
+		UserID: session.Session.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, revokeMethod, err)
+		return
+	}
+	owned := slices.ContainsFunc(list.Sessions, func(s sessions.Session) bool {
+		return s.SessionID == req.SessionID
+	})
+	if !owned {
+		internal.SendError(w, revokeMethod, errSessionNotFound)
+		return
+	}
+
+	resp, err := c.api.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
+		SessionID: req.SessionID,
+	})
+	if err != nil {
+		internal.SendError(w, revokeMethod, err)
+		return
+	}
+	c.devices.forget(req.SessionID)
+
+	current := req.SessionID == session.Session.SessionID
+	if current {
+		c.cookieStore.ClearSession(w, r)
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      revokeMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Sessions.Revoke(
+	r.Context(),
+	&sessions.RevokeParams{
+		SessionID: req.SessionID,
+	},
+)`,
+		Metadata: map[string]any{
+			"loggedOut": current,
+		},
+	})
+}
+
+// RevokeOthers revokes every session of the signed-in user except the current one, logging
+// the user out on all other devices. Stytch revokes sessions one at a time, so each of the
+// user's other sessions is revoked in turn, and the revoked sessions are returned.
+func (c *Controller) RevokeOthers(w http.ResponseWriter, r *http.Request) {
+	session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, revokeOthersMethod, err)
+		return
+	}
+
+	resp, err := c.api.Sessions.Get(r.Context(), &sessions.GetParams{
+		UserID: session.Session.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, revokeOthersMethod, err)
+		return
+	}
+
+	revoked := []sessionSummary{}
+	for _, summary := range c.summarize(resp.Sessions, session.Session.SessionID) {
+		if summary.Current {
+			continue
+		}
+		_, err := c.api.Sessions.Revoke(r.Context(), &sessions.RevokeParams{
+			SessionID: summary.SessionID,
+		})
+		if err != nil {
+			internal.SendError(w, revokeOthersMethod, err)
+			return
+		}
+		c.devices.forget(summary.SessionID)
+		revoked = append(revoked, summary)
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      revokeOthersMethod,
+		APIResponse: revoked,
+		CodeSnippet: `resp, err := c.api.Sessions.Get(
+	r.Context(),
+	&sessions.GetParams{
+		UserID: session.Session.UserID,
+	},
+)
+
+for _, s := range resp.Sessions {
+	if s.SessionID == session.Session.SessionID {
+		continue
+	}
+	_, err := c.api.Sessions.Revoke(
+		r.Context(),
+		&sessions.RevokeParams{
+			SessionID: s.SessionID,
+		},
+	)
+}`,
+		Metadata: map[string]any{
+			"revokedSessions": len(revoked),
+		},
+	})
+}
+
+// currentSession returns the session that the Refresh middleware authenticated and attached
+// to the request context.
+func (c *Controller) currentSession(r *http.Request) (*sessions.AuthenticateResponse, error) {
+	_, session, ok := mfa.FromContext(r.Context())
+	if !ok {
+		return nil, internal.ErrNoSession
+	}
+	return session, nil
+}
@@ -0,0 +1,403 @@
+package session
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+func TestGetCurrentSession(t *testing.T) {
+	tests := []struct {
+		name          string
+		noCookie      bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "session_not_found",
+		},
+		{
+			name:          "no cookie",
+			noCookie:      true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			cs := testutil.NewCookieStore(t)
+			c := NewController(f.api(), cs, 60)
+			r := httptest.NewRequest(http.MethodGet, "/session", nil)
+			if !tt.noCookie {
+				testutil.AddSession(r, cs)
+			}
+			rec := httptest.NewRecorder()
+			c.GetCurrentSession(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if resp := testutil.Decode(t, rec); resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+		})
+	}
+}
+
+func TestLogout(t *testing.T) {
+	tests := []struct {
+		name          string
+		noCookie      bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+	}{
+		{
+			name:        "success",
+			wantStatus:  http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{{SessionToken: "session-token"}},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "session_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "session_not_found",
+		},
+		{
+			name:          "no cookie",
+			noCookie:      true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			cs := testutil.NewCookieStore(t)
+			c := NewController(f.api(), cs, 60)
+			r := httptest.NewRequest(http.MethodPost, "/logout", nil)
+			if !tt.noCookie {
+				testutil.AddSession(r, cs)
+			}
+			rec := httptest.NewRecorder()
+			c.Logout(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if resp := testutil.Decode(t, rec); resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			cleared := len(rec.Result().Cookies()) == 1
+			if want := tt.wantStatus == http.StatusOK; cleared != want {
+				t.Errorf("cookies cleared = %t, want %t", cleared, want)
+			}
+		})
+	}
+}
+
+func TestLogoutEverywhere(t *testing.T) {
+	tests := []struct {
+		name          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+	}{
+		{
+			name:        "success",
+			wantStatus:  http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{{SessionID: "session-1"}, {SessionID: "session-2"}},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusForbidden, "unauthorized_action"),
+			wantStatus:    http.StatusForbidden,
+			wantErrorType: "unauthorized_action",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			f.userSessions = []sessions.Session{f.userSession("session-1"), f.userSession("session-2")}
+			cs := testutil.NewCookieStore(t)
+			c := NewController(f.api(), cs, 60)
+			r := httptest.NewRequest(http.MethodPost, "/logout/all", nil)
+			testutil.AddSession(r, cs)
+			if !tt.noSession {
+				r = stytchtest.WithUserSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.LogoutEverywhere(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			cleared := len(rec.Result().Cookies()) > 0
+			if want := tt.wantStatus == http.StatusOK; cleared != want {
+				t.Errorf("session cookie cleared = %t, want %t", cleared, want)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			if tt.wantStatus == http.StatusOK && resp.Metadata["revokedSessions"] != float64(len(tt.wantRevoked)) {
+				t.Errorf("revokedSessions = %v, want %d", resp.Metadata["revokedSessions"], len(tt.wantRevoked))
+			}
+		})
+	}
+}
+
+func TestList(t *testing.T) {
+	tests := []struct {
+		name          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "user_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "user_not_found",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			f.userSessions = []sessions.Session{f.userSession("session-1"), f.userSession("session-2")}
+			c := NewController(f.api(), testutil.NewCookieStore(t), 60)
+			c.devices.record("session-2", &http.Request{RemoteAddr: "192.0.2.1:1234", Header: http.Header{"User-Agent": {"Phone"}}})
+			r := httptest.NewRequest(http.MethodGet, "/sessions", nil)
+			if !tt.noSession {
+				r = stytchtest.WithUserSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.List(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			var summaries []sessionSummary
+			if err := json.Unmarshal(resp.StytchResponse, &summaries); err != nil || len(summaries) != 2 {
+				t.Fatalf("sessions = %s, want 2 sessions", resp.StytchResponse)
+			}
+			if !summaries[0].Current || summaries[1].Current {
+				t.Errorf("current sessions = %t, %t, want true, false", summaries[0].Current, summaries[1].Current)
+			}
+			if summaries[1].UserAgent != "Phone" || summaries[1].IPAddress != "192.0.2.1" || summaries[1].LastActiveAt == nil {
+				t.Errorf("device of session-2 = %+v, want the recorded device", summaries[1])
+			}
+		})
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+		wantLoggedOut bool
+	}{
+		{
+			name:        "other session",
+			body:        `{"session_id": "session-2"}`,
+			wantStatus:  http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{{SessionID: "session-2"}},
+		},
+		{
+			name:          "current session",
+			body:          `{"session_id": "session-1"}`,
+			wantStatus:    http.StatusOK,
+			wantRevoked:   []sessions.RevokeParams{{SessionID: "session-1"}},
+			wantLoggedOut: true,
+		},
+		{
+			name:          "session of another user",
+			body:          `{"session_id": "session-of-user-2"}`,
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: internal.ErrorTypeNotFound,
+		},
+		{
+			name:          "API error",
+			body:          `{"session_id": "session-2"}`,
+			apiErr:        testutil.StytchError(http.StatusInternalServerError, "internal_server_error"),
+			wantStatus:    http.StatusBadGateway,
+			wantErrorType: "internal_server_error",
+		},
+		{
+			name:          "no session",
+			body:          `{"session_id": "session-2"}`,
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			body:          `{"session": "session-2"}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			f.userSessions = []sessions.Session{f.userSession("session-1"), f.userSession("session-2")}
+			cs := testutil.NewCookieStore(t)
+			c := NewController(f.api(), cs, 60)
+			r := testutil.NewRequest(http.MethodPost, "/sessions/revoke", tt.body)
+			testutil.AddSession(r, cs)
+			if !tt.noSession {
+				r = stytchtest.WithUserSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.Revoke(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if got := resp.Metadata["loggedOut"]; got != tt.wantLoggedOut {
+				t.Errorf("loggedOut = %v, want %t", got, tt.wantLoggedOut)
+			}
+			if cleared := len(rec.Result().Cookies()) > 0; cleared != tt.wantLoggedOut {
+				t.Errorf("cookies cleared = %t, want %t", cleared, tt.wantLoggedOut)
+			}
+		})
+	}
+}
+
+func TestRevokeOthers(t *testing.T) {
+	tests := []struct {
+		name          string
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantRevoked   []sessions.RevokeParams
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+			wantRevoked: []sessions.RevokeParams{
+				{SessionID: "session-2"},
+				{SessionID: "session-3"},
+			},
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusTooManyRequests, "too_many_requests"),
+			wantStatus:    http.StatusTooManyRequests,
+			wantErrorType: "too_many_requests",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			f.userSessions = []sessions.Session{f.userSession("session-1"), f.userSession("session-2"), f.userSession("session-3")}
+			c := NewController(f.api(), testutil.NewCookieStore(t), 60)
+			r := httptest.NewRequest(http.MethodPost, "/sessions/revoke_others", nil)
+			if !tt.noSession {
+				r = stytchtest.WithUserSession(r)
+			}
+			rec := httptest.NewRecorder()
+			c.RevokeOthers(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.revoked, tt.wantRevoked) {
+				t.Errorf("revoked = %+v, want %+v", f.revoked, tt.wantRevoked)
+			}
+			if tt.wantStatus == http.StatusOK && resp.Metadata["revokedSessions"] != float64(len(tt.wantRevoked)) {
+				t.Errorf("revokedSessions = %v, want %d", resp.Metadata["revokedSessions"], len(tt.wantRevoked))
+			}
+			if tt.wantStatus == http.StatusOK {
+				var summaries []sessionSummary
+				if err := json.Unmarshal(resp.StytchResponse, &summaries); err != nil {
+					t.Fatalf("decoding revoked sessions: %v", err)
+				}
+				if len(summaries) != len(tt.wantRevoked) {
+					t.Fatalf("revoked sessions = %+v, want %d", summaries, len(tt.wantRevoked))
+				}
+				for i, summary := range summaries {
+					if summary.SessionID != tt.wantRevoked[i].SessionID {
+						t.Errorf("revoked session %d = %q, want %q", i, summary.SessionID, tt.wantRevoked[i].SessionID)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,19 @@
+package stytchtest
+
+import (
+	"net/http"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/mfa"
+)
+
+// WithUserSession attaches session-1 of user-1 to the request, as the session middleware
+// does, so that handlers can be tested without a server.
+func WithUserSession(r *http.Request) *http.Request {
+	return r.WithContext(mfa.WithSession(r.Context(), "session-token", &sessions.AuthenticateResponse{
+		Session: sessions.Session{SessionID: "session-1", UserID: "user-1"},
+		User:    users.User{UserID: "user-1"},
+	}))
+}
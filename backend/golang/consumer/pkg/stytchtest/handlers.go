@@ -0,0 +1,270 @@
+package stytchtest
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"slices"
+	"time"
+)
+
+type handlerFunc func(r *http.Request) (map[string]any, *apiError)
+
+// routes maps the method and path of each implemented Stytch endpoint to its handler.
+func (s *Server) routes() map[string]handlerFunc {
+	return map[string]handlerFunc{
+		"POST /v1/magic_links/email/send":            s.send,
+		"POST /v1/magic_links/email/login_or_create": s.loginOrCreate,
+		"POST /v1/magic_links/authenticate":          s.magicLinksAuthenticate,
+		"POST /v1/oauth/authenticate":                s.oauthAuthenticate,
+		"POST /v1/sessions/authenticate":             s.sessionsAuthenticate,
+		"POST /v1/sessions/revoke":                   s.sessionsRevoke,
+		"GET /v1/sessions":                           s.sessionsGet,
+	}
+}
+
+type sendRequest struct {
+	Email              string `json:"email"`
+	LoginMagicLinkURL  string `json:"login_magic_link_url"`
+	SignupMagicLinkURL string `json:"signup_magic_link_url"`
+	CodeChallenge      string `json:"code_challenge"`
+}
+
+// send emails a login magic link to an existing user, like Stytch's Send endpoint.
+func (s *Server) send(r *http.Request) (map[string]any, *apiError) {
+	var req sendRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	u := s.userByEmail(req.Email)
+	if u == nil {
+		return nil, newError(http.StatusNotFound, "email_not_found", "Email could not be found.")
+	}
+	t := s.issueToken(tokenMagicLink, u.EmailAddress)
+	s.tokens[t].CodeChallenge = req.CodeChallenge
+	s.sendMessage(MessageLogin, u.EmailAddress, t, req.LoginMagicLinkURL)
+	return map[string]any{"user_id": u.ID, "email_id": u.EmailID}, nil
+}
+
+// loginOrCreate emails a login magic link, or a signup magic link to a new user.
+func (s *Server) loginOrCreate(r *http.Request) (map[string]any, *apiError) {
+	var req sendRequest
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	if req.Email == "" {
+		return nil, newError(http.StatusBadRequest, "invalid_email", "email is required.")
+	}
+	u := s.userByEmail(req.Email)
+	created := u == nil
+	messageType, redirectURL := MessageLogin, req.LoginMagicLinkURL
+	if created {
+		u = s.createUser(req.Email)
+		u.Verified = false
+		messageType, redirectURL = MessageSignup, req.SignupMagicLinkURL
+	}
+	t := s.issueToken(tokenMagicLink, u.EmailAddress)
+	s.tokens[t].CodeChallenge = req.CodeChallenge
+	s.sendMessage(messageType, u.EmailAddress, t, redirectURL)
+	return map[string]any{"user_id": u.ID, "email_id": u.EmailID, "user_created": created}, nil
+}
+
+func (s *Server) magicLinksAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		Token                  string `json:"token"`
+		SessionDurationMinutes int32  `json:"session_duration_minutes"`
+		CodeVerifier           string `json:"code_verifier"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	t, err := s.redeemToken(req.Token, tokenMagicLink)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkCodeVerifier(t, req.CodeVerifier); err != nil {
+		return nil, err
+	}
+	u := s.userByEmail(t.EmailAddress)
+	if u == nil {
+		return nil, newError(http.StatusNotFound, "user_not_found", "User could not be found.")
+	}
+	u.Verified = true
+
+	sess := s.createSession(u, req.SessionDurationMinutes, map[string]any{
+		"type":            "magic_link",
+		"delivery_method": "email",
+		"email_factor":    map[string]any{"email_id": u.EmailID, "email_address": u.EmailAddress},
+	})
+	resp := s.sessionResponse(u, sess)
+	resp["method_id"] = u.EmailID
+	resp["reset_sessions"] = false
+	return resp, nil
+}
+
+// oauthStart stands in for Stytch's OAuth start endpoint and the provider's consent screen.
+// It redirects the browser straight back to the login or signup redirect URL with an OAuth
+// token for OAuthEmailAddress, keeping the query parameters of the redirect URL.
+func (s *Server) oauthStart(w http.ResponseWriter, r *http.Request, provider string) {
+	q := r.URL.Query()
+	if q.Get("public_token") == "" {
+		sendError(w, http.StatusUnauthorized, "invalid_public_token", "The public token is missing.")
+		return
+	}
+	if provider != "google" {
+		sendError(w, http.StatusBadRequest, "oauth_provider_not_enabled", "The fake Stytch server only implements Google OAuth.")
+		return
+	}
+
+	s.mu.Lock()
+	redirectURL := q.Get("login_redirect_url")
+	if s.userByEmail(s.OAuthEmailAddress) == nil {
+		redirectURL = q.Get("signup_redirect_url")
+	}
+	t := s.issueToken(tokenOAuth, s.OAuthEmailAddress)
+	s.tokens[t].CodeChallenge = q.Get("code_challenge")
+	s.mu.Unlock()
+
+	u, err := url.Parse(redirectURL)
+	if err != nil || redirectURL == "" {
+		sendError(w, http.StatusBadRequest, "invalid_redirect_url", "The redirect URL is not valid.")
+		return
+	}
+	redirectQuery := u.Query()
+	redirectQuery.Set("stytch_token_type", tokenOAuth)
+	redirectQuery.Set("token", t)
+	u.RawQuery = redirectQuery.Encode()
+	http.Redirect(w, r, u.String(), http.StatusFound)
+}
+
+func (s *Server) oauthAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		Token                  string `json:"token"`
+		SessionDurationMinutes int32  `json:"session_duration_minutes"`
+		CodeVerifier           string `json:"code_verifier"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	t, err := s.redeemToken(req.Token, tokenOAuth)
+	if err != nil {
+		return nil, newError(http.StatusNotFound, "oauth_token_not_found", "The OAuth token could not be found, it may have already been used.")
+	}
+	if err := checkCodeVerifier(t, req.CodeVerifier); err != nil {
+		return nil, err
+	}
+	u := s.userByEmail(t.EmailAddress)
+	if u == nil {
+		u = s.createUser(t.EmailAddress)
+	}
+
+	sess := s.createSession(u, req.SessionDurationMinutes, map[string]any{
+		"type":            "oauth",
+		"delivery_method": "oauth_google",
+	})
+	resp := s.sessionResponse(u, sess)
+	resp["oauth_user_registration_id"] = "oauth-user-registration-test-" + randomID()
+	resp["provider_subject"] = "provider-subject-test-" + u.EmailAddress
+	resp["provider_type"] = "Google"
+	resp["provider_values"] = map[string]any{
+		"access_token":  "provider-access-token-test-" + randomID(),
+		"refresh_token": "provider-refresh-token-test-" + randomID(),
+		"id_token":      "",
+		"expires_at":    s.now().Add(time.Hour).Format(time.RFC3339),
+		"scopes":        []string{"openid", "email", "profile"},
+	}
+	resp["reset_sessions"] = false
+	return resp, nil
+}
+
+// checkCodeVerifier verifies the PKCE code verifier of a magic link or OAuth token that was
+// issued with a code challenge.
+func checkCodeVerifier(t *token, codeVerifier string) *apiError {
+	if t.CodeChallenge == "" {
+		return nil
+	}
+	sum := sha256.Sum256([]byte(codeVerifier))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != t.CodeChallenge {
+		return newError(http.StatusBadRequest, "pkce_mismatch", "The code verifier does not match the code challenge.")
+	}
+	return nil
+}
+
+// sessionResponse is the response of endpoints that start or authenticate a session.
+func (s *Server) sessionResponse(u *user, sess *session) map[string]any {
+	return map[string]any{
+		"user_id":       u.ID,
+		"user":          u.json(),
+		"session":       sess.json(),
+		"session_token": sess.Token,
+		"session_jwt":   sess.JWT,
+	}
+}
+
+func (s *Server) sessionsAuthenticate(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		SessionToken           string `json:"session_token"`
+		SessionJWT             string `json:"session_jwt"`
+		SessionDurationMinutes int32  `json:"session_duration_minutes"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+	sess, err := s.findSession(req.SessionToken, req.SessionJWT)
+	if err != nil {
+		return nil, err
+	}
+
+	now := s.now()
+	sess.LastAccessedAt = now
+	if req.SessionDurationMinutes > 0 {
+		sess.ExpiresAt = now.Add(time.Duration(req.SessionDurationMinutes) * time.Minute)
+	}
+	sess.JWT = s.signJWT(sess)
+	return s.sessionResponse(s.users[sess.UserID], sess), nil
+}
+
+func (s *Server) sessionsRevoke(r *http.Request) (map[string]any, *apiError) {
+	var req struct {
+		SessionID    string `json:"session_id"`
+		SessionToken string `json:"session_token"`
+		SessionJWT   string `json:"session_jwt"`
+	}
+	if err := decode(r, &req); err != nil {
+		return nil, err
+	}
+
+	if req.SessionID != "" {
+		if _, ok := s.sessions[req.SessionID]; !ok {
+			return nil, newError(http.StatusNotFound, "session_not_found", "Session could not be found.")
+		}
+		delete(s.sessions, req.SessionID)
+		return map[string]any{}, nil
+	}
+
+	sess, err := s.findSession(req.SessionToken, req.SessionJWT)
+	if err != nil {
+		return nil, err
+	}
+	delete(s.sessions, sess.ID)
+	return map[string]any{}, nil
+}
+
+func (s *Server) sessionsGet(r *http.Request) (map[string]any, *apiError) {
+	userID := r.URL.Query().Get("user_id")
+
+	var found []*session
+	for _, sess := range s.sessions {
+		if sess.UserID == userID && s.now().Before(sess.ExpiresAt) {
+			found = append(found, sess)
+		}
+	}
+	slices.SortFunc(found, func(a, b *session) int { return a.StartedAt.Compare(b.StartedAt) })
+
+	sessions := []any{}
+	for _, sess := range found {
+		sessions = append(sessions, sess.json())
+	}
+	return map[string]any{"sessions": sessions}, nil
+}
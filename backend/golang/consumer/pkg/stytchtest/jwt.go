@@ -0,0 +1,64 @@
+package stytchtest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"time"
+)
+
+// jwtLifetime is how long session JWTs are valid, like the JWTs issued by Stytch.
+const jwtLifetime = 5 * time.Minute
+
+// jwks returns the public signing key in the format of Stytch's JWKS endpoint.
+func (s *Server) jwks() map[string]any {
+	pub := s.key.PublicKey
+	return map[string]any{
+		"keys": []any{map[string]any{
+			"kty":     "RSA",
+			"use":     "sig",
+			"alg":     "RS256",
+			"kid":     s.keyID,
+			"key_ops": []string{"verify"},
+			"n":       base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":       base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	}
+}
+
+// signJWT issues an RS256 session JWT for the session with the claims that Stytch includes,
+// so that it can be verified locally with the keys from the JWKS endpoint.
+func (s *Server) signJWT(sess *session) string {
+	now := s.now()
+	claims := map[string]any{
+		"sub": sess.UserID,
+		"iss": "stytch.com/" + s.ProjectID,
+		"aud": []string{s.ProjectID},
+		"iat": now.Unix(),
+		"nbf": now.Unix(),
+		"exp": now.Add(jwtLifetime).Unix(),
+		"https://stytch.com/session": map[string]any{
+			"id":                     sess.ID,
+			"started_at":             sess.StartedAt.Format(time.RFC3339),
+			"last_accessed_at":       sess.LastAccessedAt.Format(time.RFC3339),
+			"expires_at":             sess.ExpiresAt.Format(time.RFC3339),
+			"attributes":             map[string]any{"user_agent": "", "ip_address": ""},
+			"authentication_factors": sess.Factors,
+		},
+	}
+
+	header, _ := json.Marshal(map[string]any{"alg": "RS256", "typ": "JWT", "kid": s.keyID})
+	payload, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+
+	digest := sha256.Sum256([]byte(signed))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic("stytchtest: unable to sign JWT: " + err.Error())
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
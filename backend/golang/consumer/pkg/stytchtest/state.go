@@ -0,0 +1,184 @@
+package stytchtest
+
+import (
+	"strings"
+	"time"
+)
+
+type user struct {
+	ID           string
+	EmailID      string
+	EmailAddress string
+	Verified     bool
+	CreatedAt    time.Time
+}
+
+type session struct {
+	ID             string
+	Token          string
+	JWT            string
+	UserID         string
+	StartedAt      time.Time
+	LastAccessedAt time.Time
+	ExpiresAt      time.Time
+	Factors        []map[string]any
+}
+
+// These constants are the types of single-use tokens that complete an authentication flow.
+const (
+	tokenMagicLink = "magic_link"
+	tokenOAuth     = "oauth"
+)
+
+type token struct {
+	Type         string
+	EmailAddress string
+	ExpiresAt    time.Time
+	// CodeChallenge is the PKCE code challenge that the token was sent with, if any.
+	CodeChallenge string
+}
+
+// tokenLifetime is how long magic link and OAuth tokens can be redeemed.
+const tokenLifetime = 10 * time.Minute
+
+// defaultSessionMinutes is the session duration when a request does not set one.
+const defaultSessionMinutes = 60
+
+func (s *Server) createUser(emailAddress string) *user {
+	u := &user{
+		ID:           "user-test-" + randomID(),
+		EmailID:      "email-test-" + randomID(),
+		EmailAddress: strings.ToLower(emailAddress),
+		Verified:     true,
+		CreatedAt:    s.now(),
+	}
+	s.users[u.ID] = u
+	return u
+}
+
+func (s *Server) userByEmail(emailAddress string) *user {
+	for _, u := range s.users {
+		if strings.EqualFold(u.EmailAddress, emailAddress) {
+			return u
+		}
+	}
+	return nil
+}
+
+// issueToken creates a single-use token for a flow of the given type.
+func (s *Server) issueToken(tokenType, emailAddress string) string {
+	t := tokenType + "-test-" + randomID()
+	s.tokens[t] = &token{
+		Type:         tokenType,
+		EmailAddress: strings.ToLower(emailAddress),
+		ExpiresAt:    s.now().Add(tokenLifetime),
+	}
+	return t
+}
+
+// redeemToken consumes a token of the given type. Tokens can only be redeemed once.
+func (s *Server) redeemToken(value, tokenType string) (*token, *apiError) {
+	t, ok := s.tokens[value]
+	if !ok || t.Type != tokenType {
+		return nil, newError(404, "unable_to_auth_magic_link", "The token could not be found, it may have already been used.")
+	}
+	delete(s.tokens, value)
+	if s.now().After(t.ExpiresAt) {
+		return nil, newError(404, "unable_to_auth_magic_link", "The token has expired.")
+	}
+	return t, nil
+}
+
+// sendMessage records a magic link instead of emailing it.
+func (s *Server) sendMessage(messageType, emailAddress, token, redirectURL string) {
+	url := ""
+	if redirectURL != "" {
+		sep := "?"
+		if strings.Contains(redirectURL, "?") {
+			sep = "&"
+		}
+		url = redirectURL + sep + "stytch_token_type=magic_links&token=" + token
+	}
+	msg := Message{
+		Type:         messageType,
+		EmailAddress: strings.ToLower(emailAddress),
+		Token:        token,
+		URL:          url,
+	}
+	s.messages = append(s.messages, msg)
+	if s.OnMessage != nil {
+		s.OnMessage(msg)
+	}
+}
+
+// createSession starts a session for the user with a single authentication factor.
+func (s *Server) createSession(u *user, minutes int32, factor map[string]any) *session {
+	if minutes <= 0 {
+		minutes = defaultSessionMinutes
+	}
+	now := s.now()
+	factor["last_authenticated_at"] = now.Format(time.RFC3339)
+	sess := &session{
+		ID:             "session-test-" + randomID(),
+		Token:          "session-token-test-" + randomID(),
+		UserID:         u.ID,
+		StartedAt:      now,
+		LastAccessedAt: now,
+		ExpiresAt:      now.Add(time.Duration(minutes) * time.Minute),
+		Factors:        []map[string]any{factor},
+	}
+	sess.JWT = s.signJWT(sess)
+	s.sessions[sess.ID] = sess
+	return sess
+}
+
+// findSession looks up an unexpired session by its token or JWT. Expired sessions are
+// removed.
+func (s *Server) findSession(sessionToken, sessionJWT string) (*session, *apiError) {
+	for id, sess := range s.sessions {
+		if (sessionToken != "" && sess.Token == sessionToken) || (sessionJWT != "" && sess.JWT == sessionJWT) {
+			if s.now().After(sess.ExpiresAt) {
+				delete(s.sessions, id)
+				break
+			}
+			return sess, nil
+		}
+	}
+	return nil, newError(404, "session_not_found", "Session could not be found.")
+}
+
+func (u *user) json() map[string]any {
+	return map[string]any{
+		"user_id": u.ID,
+		"emails": []any{map[string]any{
+			"email_id": u.EmailID,
+			"email":    u.EmailAddress,
+			"verified": u.Verified,
+		}},
+		"status":                  "active",
+		"name":                    map[string]any{"first_name": "", "middle_name": "", "last_name": ""},
+		"phone_numbers":           []any{},
+		"webauthn_registrations":  []any{},
+		"providers":               []any{},
+		"totps":                   []any{},
+		"crypto_wallets":          []any{},
+		"biometric_registrations": []any{},
+		"is_locked":               false,
+		"trusted_metadata":        map[string]any{},
+		"untrusted_metadata":      map[string]any{},
+		"created_at":              u.CreatedAt.Format(time.RFC3339),
+	}
+}
+
+func (sess *session) json() map[string]any {
+	return map[string]any{
+		"session_id":             sess.ID,
+		"user_id":                sess.UserID,
+		"started_at":             sess.StartedAt.Format(time.RFC3339),
+		"last_accessed_at":       sess.LastAccessedAt.Format(time.RFC3339),
+		"expires_at":             sess.ExpiresAt.Format(time.RFC3339),
+		"attributes":             map[string]any{"ip_address": "", "user_agent": ""},
+		"authentication_factors": sess.Factors,
+		"custom_claims":          map[string]any{},
+	}
+}
@@ -0,0 +1,40 @@
+package totp
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/totps"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	TOTPs TOTPsClient
+	Users UsersClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		TOTPs: client.TOTPs,
+		Users: client.Users,
+	}
+}
+
+// TOTPsClient enrolls authenticator apps and verifies their codes and recovery codes.
+// It is implemented by stytchapi.API.TOTPs.
+type TOTPsClient interface {
+	Create(ctx context.Context, body *totps.CreateParams) (*totps.CreateResponse, error)
+	Authenticate(ctx context.Context, body *totps.AuthenticateParams) (*totps.AuthenticateResponse, error)
+	RecoveryCodes(ctx context.Context, body *totps.RecoveryCodesParams) (*totps.RecoveryCodesResponse, error)
+	Recover(ctx context.Context, body *totps.RecoverParams) (*totps.RecoverResponse, error)
+}
+
+// UsersClient reads and deletes the authenticator apps of users.
+// It is implemented by stytchapi.API.Users.
+type UsersClient interface {
+	Get(ctx context.Context, body *users.GetParams) (*users.GetResponse, error)
+	DeleteTOTP(ctx context.Context, body *users.DeleteTOTPParams) (*users.DeleteTOTPResponse, error)
+}
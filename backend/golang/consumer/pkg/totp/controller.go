@@ -0,0 +1,16 @@
+package totp
+
+import "backend/golang/consumer/pkg/internal"
+
+type Controller struct {
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
+}
+
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, redirector *internal.Redirector) *Controller {
+	return &Controller{api, cookieStore, sessionDurationMinutes, redirector}
+}
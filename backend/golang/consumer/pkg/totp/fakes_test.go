@@ -0,0 +1,63 @@
+package totp
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/totps"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory for a user with one authenticator app,
+// totp-1. Each call records the user, code and session token it was made with, and fails
+// with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+}
+
+func (f *fakeAPI) api() API {
+	return API{TOTPs: f, Users: f}
+}
+
+func (f *fakeAPI) Create(_ context.Context, body *totps.CreateParams) (*totps.CreateResponse, error) {
+	if err := f.Record("Create " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &totps.CreateResponse{TOTPID: "totp-2", Secret: "secret", RecoveryCodes: []string{"recovery-code"}, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Authenticate(_ context.Context, body *totps.AuthenticateParams) (*totps.AuthenticateResponse, error) {
+	if err := f.Record("Authenticate " + body.UserID + " " + body.TOTPCode + " " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &totps.AuthenticateResponse{TOTPID: "totp-1", SessionToken: "new-session-token", SessionJWT: "new-session-jwt", StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) RecoveryCodes(_ context.Context, body *totps.RecoveryCodesParams) (*totps.RecoveryCodesResponse, error) {
+	if err := f.Record("RecoveryCodes " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &totps.RecoveryCodesResponse{UserID: body.UserID, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Recover(_ context.Context, body *totps.RecoverParams) (*totps.RecoverResponse, error) {
+	if err := f.Record("Recover " + body.UserID + " " + body.RecoveryCode + " " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &totps.RecoverResponse{TOTPID: "totp-1", SessionToken: "new-session-token", SessionJWT: "new-session-jwt", StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Get(_ context.Context, body *users.GetParams) (*users.GetResponse, error) {
+	if err := f.Record("Users.Get " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &users.GetResponse{UserID: body.UserID, TOTPs: []users.TOTP{{TOTPID: "totp-1", Verified: true}}, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) DeleteTOTP(_ context.Context, body *users.DeleteTOTPParams) (*users.DeleteTOTPResponse, error) {
+	if err := f.Record("Users.DeleteTOTP " + body.TOTPID); err != nil {
+		return nil, err
+	}
+	return &users.DeleteTOTPResponse{StatusCode: 200}, nil
+}
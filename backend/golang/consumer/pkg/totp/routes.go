@@ -0,0 +1,48 @@
+package totp
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the TOTP routes. Recovery codes can only be viewed or replaced with a
+// two-factor session.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/totps/create",
+			Summary: "Enroll an authenticator app for the signed-in user",
+			Handler: c.Create,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/totps/authenticate",
+			Summary: "Verify an authenticator app code and add it to the session",
+			Handler: c.Authenticate,
+			Request: authenticateRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/totps/recover",
+			Summary: "Verify a recovery code and add it to the session",
+			Handler: c.Recover,
+			Request: recoverRequest{},
+		},
+		{
+			Method:     http.MethodGet,
+			Path:       "/totps/recovery_codes",
+			Summary:    "Get the recovery codes of the signed-in user",
+			Handler:    c.RecoveryCodes,
+			RequireMFA: true,
+		},
+		{
+			Method:     http.MethodPost,
+			Path:       "/totps/recovery_codes/regenerate",
+			Summary:    "Replace the authenticator app and recovery codes of the signed-in user",
+			Handler:    c.RegenerateRecoveryCodes,
+			RequireMFA: true,
+		},
+	}
+}
@@ -0,0 +1,262 @@
+package totp
+
+import (
+	"net/http"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/totps"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/mfa"
+)
+
+const (
+	createMethod                  = "TOTPs.Create"
+	authenticateMethod            = "TOTPs.Authenticate"
+	recoverMethod                 = "TOTPs.Recover"
+	recoveryCodesMethod           = "TOTPs.RecoveryCodes"
+	regenerateRecoveryCodesMethod = "TOTPs.RegenerateRecoveryCodes"
+)
+
+// Create wraps Stytch's TOTP Create endpoint and enrolls an authenticator app for the
+// signed-in user. The response holds the QR code to scan, as a data URL, the secret to
+// type in instead, and the recovery codes to save. The authenticator app only becomes a
+// factor of the user once a code from it is verified with Authenticate.
+func (c *Controller) Create(w http.ResponseWriter, r *http.Request) {
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, createMethod, err)
+		return
+	}
+
+	resp, err := c.api.TOTPs.Create(r.Context(), &totps.CreateParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, createMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      createMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.TOTPs.Create(
+	r.Context(),
+	&totps.CreateParams{
+		UserID: session.User.UserID,
+	},
+)`,
+	})
+}
+
+type authenticateRequest struct {
+	TOTPCode string `json:"totp_code" validate:"required,min=6,max=6"`
+}
+
+// Authenticate wraps Stytch's TOTP Authenticate endpoint and verifies a code from the
+// signed-in user's authenticator app. The code is added to the session as another factor,
+// and the session is stored in a cookie.
+func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
+	var req authenticateRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	st, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	resp, err := c.api.TOTPs.Authenticate(r.Context(), &totps.AuthenticateParams{
+		UserID:                 session.User.UserID,
+		TOTPCode:               req.TOTPCode,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+	redirectURL := c.redirector.Next(w, r, true)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      authenticateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.TOTPs.Authenticate(
+	r.Context(),
+	&totps.AuthenticateParams{
+		UserID:                 session.User.UserID,
+		TOTPCode:               req.TOTPCode,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
+	})
+}
+
+type recoverRequest struct {
+	RecoveryCode string `json:"recovery_code" validate:"required,max=64"`
+}
+
+// Recover wraps Stytch's TOTP Recover endpoint for users who lost their authenticator app.
+// Each recovery code can be used once and is added to the session as a factor in place of
+// an authenticator app code.
+func (c *Controller) Recover(w http.ResponseWriter, r *http.Request) {
+	var req recoverRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, recoverMethod, err)
+		return
+	}
+
+	st, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, recoverMethod, err)
+		return
+	}
+
+	resp, err := c.api.TOTPs.Recover(r.Context(), &totps.RecoverParams{
+		UserID:                 session.User.UserID,
+		RecoveryCode:           req.RecoveryCode,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, recoverMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+	redirectURL := c.redirector.Next(w, r, true)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      recoverMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.TOTPs.Recover(
+	r.Context(),
+	&totps.RecoverParams{
+		UserID:                 session.User.UserID,
+		RecoveryCode:           req.RecoveryCode,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
+	})
+}
+
+// RecoveryCodes wraps Stytch's TOTP Get Recovery Codes endpoint and returns the unused
+// recovery codes of the signed-in user. The route requires a two-factor session.
+func (c *Controller) RecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	_, session, ok := mfa.FromContext(r.Context())
+	if !ok {
+		internal.SendError(w, recoveryCodesMethod, internal.ErrNoSession)
+		return
+	}
+
+	resp, err := c.api.TOTPs.RecoveryCodes(r.Context(), &totps.RecoveryCodesParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, recoveryCodesMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      recoveryCodesMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.TOTPs.RecoveryCodes(
+	r.Context(),
+	&totps.RecoveryCodesParams{
+		UserID: session.User.UserID,
+	},
+)`,
+	})
+}
+
+// RegenerateRecoveryCodes replaces the signed-in user's recovery codes. Stytch issues
+// recovery codes together with an authenticator app, so the user's authenticator apps are
+// deleted and a new one is enrolled like in Create, which the user scans and verifies with
+// Authenticate. The route requires a two-factor session.
+func (c *Controller) RegenerateRecoveryCodes(w http.ResponseWriter, r *http.Request) {
+	_, session, ok := mfa.FromContext(r.Context())
+	if !ok {
+		internal.SendError(w, regenerateRecoveryCodesMethod, internal.ErrNoSession)
+		return
+	}
+
+	user, err := c.api.Users.Get(r.Context(), &users.GetParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, regenerateRecoveryCodesMethod, err)
+		return
+	}
+
+	for _, t := range user.TOTPs {
+		_, err := c.api.Users.DeleteTOTP(r.Context(), &users.DeleteTOTPParams{
+			TOTPID: t.TOTPID,
+		})
+		if err != nil {
+			internal.SendError(w, regenerateRecoveryCodesMethod, err)
+			return
+		}
+	}
+
+	resp, err := c.api.TOTPs.Create(r.Context(), &totps.CreateParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, regenerateRecoveryCodesMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      regenerateRecoveryCodesMethod,
+		APIResponse: resp,
+		CodeSnippet: `user, err := c.api.Users.Get(
+	r.Context(),
+	&users.GetParams{
+		UserID: session.User.UserID,
+	},
+)
+
+for _, t := range user.TOTPs {
+	_, err := c.api.Users.DeleteTOTP(
+		r.Context(),
+		&users.DeleteTOTPParams{
+			TOTPID: t.TOTPID,
+		},
+	)
+}
+
+resp, err := c.api.TOTPs.Create(
+	r.Context(),
+	&totps.CreateParams{
+		UserID: session.User.UserID,
+	},
+)`,
+		Metadata: map[string]any{
+			"deletedTOTPs": len(user.TOTPs),
+		},
+	})
+}
+
+// currentSession returns the session token and the session that the session middleware
+// authenticated and attached to the request context.
+func (c *Controller) currentSession(r *http.Request) (string, *sessions.AuthenticateResponse, error) {
+	st, session, ok := mfa.FromContext(r.Context())
+	if !ok {
+		return "", nil, internal.ErrNoSession
+	}
+	return st, session, nil
+}
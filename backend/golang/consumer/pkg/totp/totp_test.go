@@ -0,0 +1,146 @@
+package totp
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+func TestHandlers(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler func(*Controller) http.HandlerFunc
+		method  string
+		body    string
+		// badBody is "" for handlers that read no body.
+		badBody   string
+		wantCalls []string
+		// wantSession is set for handlers that add a factor to the session and store it.
+		wantSession bool
+	}{
+		{
+			name:      "Create",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Create },
+			method:    createMethod,
+			wantCalls: []string{"Create user-1"},
+		},
+		{
+			name:        "Authenticate",
+			handler:     func(c *Controller) http.HandlerFunc { return c.Authenticate },
+			method:      authenticateMethod,
+			body:        `{"totp_code": "123456"}`,
+			badBody:     `{"totp_code": "1234"}`,
+			wantCalls:   []string{"Authenticate user-1 123456 session-token"},
+			wantSession: true,
+		},
+		{
+			name:        "Recover",
+			handler:     func(c *Controller) http.HandlerFunc { return c.Recover },
+			method:      recoverMethod,
+			body:        `{"recovery_code": "recovery-code"}`,
+			badBody:     `{"recovery_code": ""}`,
+			wantCalls:   []string{"Recover user-1 recovery-code session-token"},
+			wantSession: true,
+		},
+		{
+			name:      "RecoveryCodes",
+			handler:   func(c *Controller) http.HandlerFunc { return c.RecoveryCodes },
+			method:    recoveryCodesMethod,
+			wantCalls: []string{"RecoveryCodes user-1"},
+		},
+		{
+			name:      "RegenerateRecoveryCodes",
+			handler:   func(c *Controller) http.HandlerFunc { return c.RegenerateRecoveryCodes },
+			method:    regenerateRecoveryCodesMethod,
+			wantCalls: []string{"Users.Get user-1", "Users.DeleteTOTP totp-1", "Create user-1"},
+		},
+	}
+	tests := []struct {
+		name          string
+		badBody       bool
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusUnauthorized, "unable_to_auth_totp_code"),
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: "unable_to_auth_totp_code",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			badBody:       true,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, h := range handlers {
+		for _, tt := range tests {
+			if tt.badBody && h.badBody == "" {
+				continue
+			}
+			t.Run(h.name+"/"+tt.name, func(t *testing.T) {
+				body := h.body
+				if tt.badBody {
+					body = h.badBody
+				}
+				f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+				env := testutil.NewEnv(t)
+				c := NewController(f.api(), env.CookieStore, 60, env.Redirector)
+				r := testutil.NewRequest(http.MethodPost, "/totps", body)
+				if !tt.noSession {
+					r = stytchtest.WithUserSession(r)
+				}
+				rec := httptest.NewRecorder()
+				h.handler(c)(rec, r)
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				resp := testutil.Decode(t, rec)
+				if resp.Method != h.method {
+					t.Errorf("method = %q, want %q", resp.Method, h.method)
+				}
+				if resp.ErrorType() != tt.wantErrorType {
+					t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+				}
+				var wantCalls []string
+				if tt.wantStatus == http.StatusOK {
+					wantCalls = h.wantCalls
+				} else if tt.apiErr != nil {
+					// The first call fails and stops the handler.
+					wantCalls = h.wantCalls[:1]
+				}
+				if !slices.Equal(f.Calls, wantCalls) {
+					t.Errorf("calls = %q, want %q", f.Calls, wantCalls)
+				}
+				if tt.wantStatus != http.StatusOK || !h.wantSession {
+					return
+				}
+				if st, _ := env.CookieStore.GetSession(testutil.ResponseCookies(rec)); st != "new-session-token" {
+					t.Errorf("stored session = %q, want %q", st, "new-session-token")
+				}
+				if got := resp.Metadata["redirectURL"]; got != testutil.SuccessURL {
+					t.Errorf("redirectURL = %v, want %q", got, testutil.SuccessURL)
+				}
+			})
+		}
+	}
+}
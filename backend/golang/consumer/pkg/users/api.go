@@ -0,0 +1,53 @@
+package users
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/sms"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	Users     UsersClient
+	OTPsEmail OTPsEmailClient
+	OTPsSms   OTPsSmsClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		Users:     client.Users,
+		OTPsEmail: client.OTPs.Email,
+		OTPsSms:   client.OTPs.Sms,
+	}
+}
+
+// UsersClient reads, updates and deletes users and their authentication factors.
+// It is implemented by stytchapi.API.Users.
+type UsersClient interface {
+	Get(ctx context.Context, body *users.GetParams) (*users.GetResponse, error)
+	Update(ctx context.Context, body *users.UpdateParams) (*users.UpdateResponse, error)
+	Delete(ctx context.Context, body *users.DeleteParams) (*users.DeleteResponse, error)
+	DeleteEmail(ctx context.Context, body *users.DeleteEmailParams) (*users.DeleteEmailResponse, error)
+	DeletePhoneNumber(ctx context.Context, body *users.DeletePhoneNumberParams) (*users.DeletePhoneNumberResponse, error)
+	DeleteWebAuthnRegistration(ctx context.Context, body *users.DeleteWebAuthnRegistrationParams) (*users.DeleteWebAuthnRegistrationResponse, error)
+	DeleteTOTP(ctx context.Context, body *users.DeleteTOTPParams) (*users.DeleteTOTPResponse, error)
+	DeleteOAuthRegistration(ctx context.Context, body *users.DeleteOAuthRegistrationParams) (*users.DeleteOAuthRegistrationResponse, error)
+	DeletePassword(ctx context.Context, body *users.DeletePasswordParams) (*users.DeletePasswordResponse, error)
+}
+
+// OTPsEmailClient sends passcodes that verify new email addresses of signed-in users.
+// It is implemented by stytchapi.API.OTPs.Email.
+type OTPsEmailClient interface {
+	Send(ctx context.Context, body *email.SendParams) (*email.SendResponse, error)
+}
+
+// OTPsSmsClient sends passcodes that verify new phone numbers of signed-in users.
+// It is implemented by stytchapi.API.OTPs.Sms.
+type OTPsSmsClient interface {
+	Send(ctx context.Context, body *sms.SendParams) (*sms.SendResponse, error)
+}
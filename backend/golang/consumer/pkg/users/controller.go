@@ -0,0 +1,16 @@
+package users
+
+import "backend/golang/consumer/pkg/internal"
+
+type Controller struct {
+	api         API
+	cookieStore *internal.CookieStore
+
+	// providerTokens keeps the OAuth provider tokens of users, which are deleted along with
+	// the user. It is nil when provider tokens are not stored.
+	providerTokens *internal.ProviderTokenStore
+}
+
+func NewController(api API, cookieStore *internal.CookieStore, providerTokens *internal.ProviderTokenStore) *Controller {
+	return &Controller{api, cookieStore, providerTokens}
+}
@@ -0,0 +1,115 @@
+package users
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/sms"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory for user-1, who has one factor of each
+// type. Each call records the user, factor or session token it was made with, and fails
+// with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+
+	updateParams *users.UpdateParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{Users: f, OTPsEmail: fakeEmail{f}, OTPsSms: fakeSms{f}}
+}
+
+func (f *fakeAPI) Get(_ context.Context, body *users.GetParams) (*users.GetResponse, error) {
+	if err := f.Record("Get " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &users.GetResponse{
+		UserID:                body.UserID,
+		Emails:                []users.Email{{EmailID: "email-1", Email: "user@example.com"}},
+		PhoneNumbers:          []users.PhoneNumber{{PhoneID: "phone-1", PhoneNumber: "+15555550100"}},
+		WebAuthnRegistrations: []users.WebAuthnRegistration{{WebAuthnRegistrationID: "registration-1"}},
+		TOTPs:                 []users.TOTP{{TOTPID: "totp-1"}},
+		Providers:             []users.OAuthProvider{{ProviderType: "Google", OAuthUserRegistrationID: "oauth-1"}},
+		Password:              &users.Password{PasswordID: "password-1"},
+		StatusCode:            200,
+	}, nil
+}
+
+func (f *fakeAPI) Update(_ context.Context, body *users.UpdateParams) (*users.UpdateResponse, error) {
+	f.updateParams = body
+	if err := f.Record("Update " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &users.UpdateResponse{UserID: body.UserID, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Delete(_ context.Context, body *users.DeleteParams) (*users.DeleteResponse, error) {
+	if err := f.Record("Delete " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &users.DeleteResponse{UserID: body.UserID, StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) DeleteEmail(_ context.Context, body *users.DeleteEmailParams) (*users.DeleteEmailResponse, error) {
+	if err := f.Record("DeleteEmail " + body.EmailID); err != nil {
+		return nil, err
+	}
+	return &users.DeleteEmailResponse{StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) DeletePhoneNumber(_ context.Context, body *users.DeletePhoneNumberParams) (*users.DeletePhoneNumberResponse, error) {
+	if err := f.Record("DeletePhoneNumber " + body.PhoneID); err != nil {
+		return nil, err
+	}
+	return &users.DeletePhoneNumberResponse{StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) DeleteWebAuthnRegistration(_ context.Context, body *users.DeleteWebAuthnRegistrationParams) (*users.DeleteWebAuthnRegistrationResponse, error) {
+	if err := f.Record("DeleteWebAuthnRegistration " + body.WebAuthnRegistrationID); err != nil {
+		return nil, err
+	}
+	return &users.DeleteWebAuthnRegistrationResponse{StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) DeleteTOTP(_ context.Context, body *users.DeleteTOTPParams) (*users.DeleteTOTPResponse, error) {
+	if err := f.Record("DeleteTOTP " + body.TOTPID); err != nil {
+		return nil, err
+	}
+	return &users.DeleteTOTPResponse{StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) DeleteOAuthRegistration(_ context.Context, body *users.DeleteOAuthRegistrationParams) (*users.DeleteOAuthRegistrationResponse, error) {
+	if err := f.Record("DeleteOAuthRegistration " + body.OAuthUserRegistrationID); err != nil {
+		return nil, err
+	}
+	return &users.DeleteOAuthRegistrationResponse{StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) DeletePassword(_ context.Context, body *users.DeletePasswordParams) (*users.DeletePasswordResponse, error) {
+	if err := f.Record("DeletePassword " + body.PasswordID); err != nil {
+		return nil, err
+	}
+	return &users.DeletePasswordResponse{StatusCode: 200}, nil
+}
+
+type fakeEmail struct{ *fakeAPI }
+
+func (f fakeEmail) Send(_ context.Context, body *email.SendParams) (*email.SendResponse, error) {
+	if err := f.Record("OTPs.Email.Send " + body.Email + " " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &email.SendResponse{EmailID: "email-2", StatusCode: 200}, nil
+}
+
+type fakeSms struct{ *fakeAPI }
+
+func (f fakeSms) Send(_ context.Context, body *sms.SendParams) (*sms.SendResponse, error) {
+	if err := f.Record("OTPs.Sms.Send " + body.PhoneNumber + " " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &sms.SendResponse{PhoneID: "phone-2", StatusCode: 200}, nil
+}
@@ -0,0 +1,54 @@
+package users
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the Users routes. Every route acts on the user of the session cookie.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodGet,
+			Path:    "/users/me",
+			Summary: "Get the signed-in user",
+			Handler: c.Get,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/update",
+			Summary: "Update the name and metadata of the signed-in user",
+			Handler: c.Update,
+			Request: updateRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/emails/add",
+			Summary: "Send a passcode that adds an email address to the signed-in user",
+			Handler: c.AddEmail,
+			Request: addEmailRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/phone_numbers/add",
+			Summary: "Send a passcode that adds a phone number to the signed-in user",
+			Handler: c.AddPhoneNumber,
+			Request: addPhoneNumberRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/factors/delete",
+			Summary: "Delete an email address, phone number or other factor of the signed-in user",
+			Handler: c.DeleteFactor,
+			Request: deleteFactorRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/users/delete",
+			Summary: "Delete the signed-in user and log out",
+			Handler: c.Delete,
+			Request: deleteRequest{},
+		},
+	}
+}
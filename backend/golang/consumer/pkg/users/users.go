@@ -0,0 +1,368 @@
+package users
+
+import (
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/email"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/otp/sms"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/mfa"
+)
+
+const (
+	getMethod            = "Users.Get"
+	updateMethod         = "Users.Update"
+	addEmailMethod       = "OTPs.Email.Send"
+	addPhoneNumberMethod = "OTPs.Sms.Send"
+	deleteFactorMethod   = "Users.DeleteFactor"
+	deleteMethod         = "Users.Delete"
+)
+
+var errFactorNotFound = internal.NewError(http.StatusNotFound, internal.ErrorTypeNotFound,
+	"The signed-in user has no factor of this type with this ID")
+
+// Get wraps Stytch's Get User endpoint and returns the signed-in user, including their
+// email addresses, phone numbers, other factors and metadata.
+func (c *Controller) Get(w http.ResponseWriter, r *http.Request) {
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, getMethod, err)
+		return
+	}
+
+	resp, err := c.api.Users.Get(r.Context(), &users.GetParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, getMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      getMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Users.Get(
+	r.Context(),
+	&users.GetParams{
+		UserID: session.User.UserID,
+	},
+)`,
+	})
+}
+
+type updateRequest struct {
+	FirstName  string `json:"first_name" validate:"max=256"`
+	MiddleName string `json:"middle_name" validate:"max=256"`
+	LastName   string `json:"last_name" validate:"max=256"`
+
+	// UntrustedMetadata is merged into the user's untrusted metadata. Top-level keys set to
+	// null are removed. Trusted metadata can only be changed by the backend itself.
+	UntrustedMetadata map[string]any `json:"untrusted_metadata" validate:"max=20"`
+}
+
+// Update wraps Stytch's Update User endpoint and changes the name and untrusted metadata of
+// the signed-in user. The name is left unchanged when no part of it is given.
+func (c *Controller) Update(w http.ResponseWriter, r *http.Request) {
+	var req updateRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, updateMethod, err)
+		return
+	}
+
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, updateMethod, err)
+		return
+	}
+
+	params := &users.UpdateParams{
+		UserID:            session.User.UserID,
+		UntrustedMetadata: req.UntrustedMetadata,
+	}
+	if req.FirstName != "" || req.MiddleName != "" || req.LastName != "" {
+		params.Name = &users.Name{
+			FirstName:  req.FirstName,
+			MiddleName: req.MiddleName,
+			LastName:   req.LastName,
+		}
+	}
+
+	resp, err := c.api.Users.Update(r.Context(), params)
+	if err != nil {
+		internal.SendError(w, updateMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      updateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Users.Update(
+	r.Context(),
+	&users.UpdateParams{
+		UserID: session.User.UserID,
+		Name: &users.Name{
+			FirstName:  req.FirstName,
+			MiddleName: req.MiddleName,
+			LastName:   req.LastName,
+		},
+		UntrustedMetadata: req.UntrustedMetadata,
+	},
+)`,
+	})
+}
+
+type addEmailRequest struct {
+	EmailAddress string `json:"email_address" validate:"required,email"`
+}
+
+// AddEmail wraps Stytch's Email OTP Send endpoint with the user's session, which emails a
+// passcode to the new address. The address is added to the user once the passcode is
+// verified with POST /otps/authenticate, using the methodID from the metadata.
+func (c *Controller) AddEmail(w http.ResponseWriter, r *http.Request) {
+	var req addEmailRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, addEmailMethod, err)
+		return
+	}
+
+	st, ok := c.cookieStore.GetSession(r)
+	if !ok {
+		internal.SendError(w, addEmailMethod, internal.ErrNoSession)
+		return
+	}
+
+	resp, err := c.api.OTPsEmail.Send(r.Context(), &email.SendParams{
+		Email:        req.EmailAddress,
+		SessionToken: st,
+	})
+	if err != nil {
+		internal.SendError(w, addEmailMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      addEmailMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.OTPs.Email.Send(
+	r.Context(),
+	&email.SendParams{
+		Email:        req.EmailAddress,
+		SessionToken: st,
+	},
+)`,
+		Metadata: map[string]any{
+			"methodID": resp.EmailID,
+		},
+	})
+}
+
+type addPhoneNumberRequest struct {
+	PhoneNumber string `json:"phone_number" validate:"required,phone"`
+}
+
+// AddPhoneNumber wraps Stytch's SMS OTP Send endpoint with the user's session, which texts a
+// passcode to the new number. The number is added to the user once the passcode is verified
+// with POST /otps/authenticate, using the methodID from the metadata.
+func (c *Controller) AddPhoneNumber(w http.ResponseWriter, r *http.Request) {
+	var req addPhoneNumberRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, addPhoneNumberMethod, err)
+		return
+	}
+
+	st, ok := c.cookieStore.GetSession(r)
+	if !ok {
+		internal.SendError(w, addPhoneNumberMethod, internal.ErrNoSession)
+		return
+	}
+
+	resp, err := c.api.OTPsSms.Send(r.Context(), &sms.SendParams{
+		PhoneNumber:  req.PhoneNumber,
+		SessionToken: st,
+	})
+	if err != nil {
+		internal.SendError(w, addPhoneNumberMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      addPhoneNumberMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.OTPs.Sms.Send(
+	r.Context(),
+	&sms.SendParams{
+		PhoneNumber:  req.PhoneNumber,
+		SessionToken: st,
+	},
+)`,
+		Metadata: map[string]any{
+			"methodID": resp.PhoneID,
+		},
+	})
+}
+
+// These constants are the factor types accepted by DeleteFactor.
+const (
+	factorEmail                = "email"
+	factorPhoneNumber          = "phone_number"
+	factorWebAuthnRegistration = "webauthn_registration"
+	factorTOTP                 = "totp"
+	factorOAuthRegistration    = "oauth_registration"
+	factorPassword             = "password"
+)
+
+type deleteFactorRequest struct {
+	FactorType string `json:"factor_type" validate:"required,oneof=email phone_number webauthn_registration totp oauth_registration password"`
+	// FactorID is the ID of the factor, such as the email_id of an email address or the
+	// oauth_user_registration_id of an OAuth provider.
+	FactorID string `json:"factor_id" validate:"required"`
+}
+
+// DeleteFactor removes an email address, phone number, passkey, authenticator app, OAuth
+// provider or password from the signed-in user with the matching Stytch Delete endpoint.
+// Stytch deletes factors by ID alone, so the ID is checked against the user's own factors
+// first. Removing an OAuth provider also deletes its stored provider tokens.
+func (c *Controller) DeleteFactor(w http.ResponseWriter, r *http.Request) {
+	var req deleteFactorRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, deleteFactorMethod, err)
+		return
+	}
+
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, deleteFactorMethod, err)
+		return
+	}
+	// The session only holds the ID of the user, so load the factors of the user to check
+	// that the factor belongs to them.
+	ctx := r.Context()
+	user, err := c.api.Users.Get(ctx, &users.GetParams{UserID: session.User.UserID})
+	if err != nil {
+		internal.SendError(w, deleteFactorMethod, err)
+		return
+	}
+
+	var resp any
+	switch req.FactorType {
+	case factorEmail:
+		if !slices.ContainsFunc(user.Emails, func(e users.Email) bool { return e.EmailID == req.FactorID }) {
+			err = errFactorNotFound
+			break
+		}
+		resp, err = c.api.Users.DeleteEmail(ctx, &users.DeleteEmailParams{EmailID: req.FactorID})
+	case factorPhoneNumber:
+		if !slices.ContainsFunc(user.PhoneNumbers, func(p users.PhoneNumber) bool { return p.PhoneID == req.FactorID }) {
+			err = errFactorNotFound
+			break
+		}
+		resp, err = c.api.Users.DeletePhoneNumber(ctx, &users.DeletePhoneNumberParams{PhoneID: req.FactorID})
+	case factorWebAuthnRegistration:
+		if !slices.ContainsFunc(user.WebAuthnRegistrations, func(reg users.WebAuthnRegistration) bool { return reg.WebAuthnRegistrationID == req.FactorID }) {
+			err = errFactorNotFound
+			break
+		}
+		resp, err = c.api.Users.DeleteWebAuthnRegistration(ctx, &users.DeleteWebAuthnRegistrationParams{WebAuthnRegistrationID: req.FactorID})
+	case factorTOTP:
+		if !slices.ContainsFunc(user.TOTPs, func(t users.TOTP) bool { return t.TOTPID == req.FactorID }) {
+			err = errFactorNotFound
+			break
+		}
+		resp, err = c.api.Users.DeleteTOTP(ctx, &users.DeleteTOTPParams{TOTPID: req.FactorID})
+	case factorOAuthRegistration:
+		i := slices.IndexFunc(user.Providers, func(p users.OAuthProvider) bool { return p.OAuthUserRegistrationID == req.FactorID })
+		if i < 0 {
+			err = errFactorNotFound
+			break
+		}
+		resp, err = c.api.Users.DeleteOAuthRegistration(ctx, &users.DeleteOAuthRegistrationParams{OAuthUserRegistrationID: req.FactorID})
+		if err == nil && c.providerTokens != nil {
+			c.providerTokens.Delete(user.UserID, strings.ToLower(user.Providers[i].ProviderType))
+		}
+	case factorPassword:
+		if user.Password == nil || user.Password.PasswordID != req.FactorID {
+			err = errFactorNotFound
+			break
+		}
+		resp, err = c.api.Users.DeletePassword(ctx, &users.DeletePasswordParams{PasswordID: req.FactorID})
+	}
+	if err != nil {
+		internal.SendError(w, deleteFactorMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      deleteFactorMethod,
+		APIResponse: resp,
+		CodeSnippet: `// Each factor type has its own Delete method, such as DeletePhoneNumber,
+// DeleteWebAuthnRegistration, DeleteTOTP, DeleteOAuthRegistration and DeletePassword.
+resp, err := c.api.Users.DeleteEmail(
+	r.Context(),
+	&users.DeleteEmailParams{
+		EmailID: req.FactorID,
+	},
+)`,
+	})
+}
+
+type deleteRequest struct {
+	// Confirm must be true, so that accounts are not deleted by accident.
+	Confirm bool `json:"confirm" validate:"required"`
+}
+
+// Delete wraps Stytch's Delete User endpoint and deletes the signed-in user along with all of
+// their sessions. The server-side session values and provider tokens of the user are
+// deleted as well, and the session cookie is cleared.
+func (c *Controller) Delete(w http.ResponseWriter, r *http.Request) {
+	var req deleteRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, deleteMethod, err)
+		return
+	}
+
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, deleteMethod, err)
+		return
+	}
+
+	resp, err := c.api.Users.Delete(r.Context(), &users.DeleteParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, deleteMethod, err)
+		return
+	}
+
+	if c.providerTokens != nil {
+		c.providerTokens.DeleteUser(session.User.UserID)
+	}
+	c.cookieStore.ClearAllSessions(session.User.UserID)
+	c.cookieStore.ClearSession(w, r)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      deleteMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Users.Delete(
+	r.Context(),
+	&users.DeleteParams{
+		UserID: session.User.UserID,
+	},
+)`,
+	})
+}
+
+// currentSession returns the session token and the session that the session middleware
+// authenticated and attached to the request context.
+func (c *Controller) currentSession(r *http.Request) (string, *sessions.AuthenticateResponse, error) {
+	st, session, ok := mfa.FromContext(r.Context())
+	if !ok {
+		return "", nil, internal.ErrNoSession
+	}
+	return st, session, nil
+}
@@ -0,0 +1,284 @@
+package users
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+// serve calls the handler with the body, as user-1 with a session cookie unless noSession
+// is set. It returns the cookie store so that tests can read the cookies of the response.
+func serve(t *testing.T, f *fakeAPI, providerTokens *internal.ProviderTokenStore, handler func(*Controller) http.HandlerFunc, body string, noSession bool) (*httptest.ResponseRecorder, testutil.Response, *internal.CookieStore) {
+	t.Helper()
+	cs := testutil.NewCookieStore(t)
+	c := NewController(f.api(), cs, providerTokens)
+	r := testutil.NewRequest(http.MethodPost, "/users", body)
+	if !noSession {
+		testutil.AddSession(r, cs)
+		r = stytchtest.WithUserSession(r)
+	}
+	rec := httptest.NewRecorder()
+	handler(c)(rec, r)
+	return rec, testutil.Decode(t, rec), cs
+}
+
+func TestHandlers(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler func(*Controller) http.HandlerFunc
+		method  string
+		body    string
+		// badBody is "" for handlers that read no body.
+		badBody      string
+		wantCalls    []string
+		wantMethodID string
+	}{
+		{
+			name:      "Get",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Get },
+			method:    getMethod,
+			wantCalls: []string{"Get user-1"},
+		},
+		{
+			name:      "Update",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Update },
+			method:    updateMethod,
+			body:      `{"first_name": "Ada"}`,
+			badBody:   `{"first_name": 1}`,
+			wantCalls: []string{"Update user-1"},
+		},
+		{
+			name:         "AddEmail",
+			handler:      func(c *Controller) http.HandlerFunc { return c.AddEmail },
+			method:       addEmailMethod,
+			body:         `{"email_address": "new@example.com"}`,
+			badBody:      `{"email_address": "new"}`,
+			wantCalls:    []string{"OTPs.Email.Send new@example.com session-token"},
+			wantMethodID: "email-2",
+		},
+		{
+			name:         "AddPhoneNumber",
+			handler:      func(c *Controller) http.HandlerFunc { return c.AddPhoneNumber },
+			method:       addPhoneNumberMethod,
+			body:         `{"phone_number": "+15555550101"}`,
+			badBody:      `{"phone_number": "555-0101"}`,
+			wantCalls:    []string{"OTPs.Sms.Send +15555550101 session-token"},
+			wantMethodID: "phone-2",
+		},
+		{
+			name:      "DeleteFactor",
+			handler:   func(c *Controller) http.HandlerFunc { return c.DeleteFactor },
+			method:    deleteFactorMethod,
+			body:      `{"factor_type": "email", "factor_id": "email-1"}`,
+			badBody:   `{"factor_type": "magic_link", "factor_id": "email-1"}`,
+			wantCalls: []string{"Get user-1", "DeleteEmail email-1"},
+		},
+		{
+			name:      "Delete",
+			handler:   func(c *Controller) http.HandlerFunc { return c.Delete },
+			method:    deleteMethod,
+			body:      `{"confirm": true}`,
+			badBody:   `{"confirm": false}`,
+			wantCalls: []string{"Delete user-1"},
+		},
+	}
+	tests := []struct {
+		name          string
+		badBody       bool
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusNotFound, "user_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "user_not_found",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			badBody:       true,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, h := range handlers {
+		for _, tt := range tests {
+			if tt.badBody && h.badBody == "" {
+				continue
+			}
+			t.Run(h.name+"/"+tt.name, func(t *testing.T) {
+				body := h.body
+				if tt.badBody {
+					body = h.badBody
+				}
+				f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+				rec, resp, _ := serve(t, f, nil, h.handler, body, tt.noSession)
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				if resp.Method != h.method {
+					t.Errorf("method = %q, want %q", resp.Method, h.method)
+				}
+				if resp.ErrorType() != tt.wantErrorType {
+					t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+				}
+				var wantCalls []string
+				if tt.wantStatus == http.StatusOK {
+					wantCalls = h.wantCalls
+				} else if tt.apiErr != nil {
+					// The first call fails and stops the handler.
+					wantCalls = h.wantCalls[:1]
+				}
+				if !slices.Equal(f.Calls, wantCalls) {
+					t.Errorf("calls = %q, want %q", f.Calls, wantCalls)
+				}
+				if tt.wantStatus == http.StatusOK && h.wantMethodID != "" && resp.Metadata["methodID"] != h.wantMethodID {
+					t.Errorf("methodID = %v, want %q", resp.Metadata["methodID"], h.wantMethodID)
+				}
+			})
+		}
+	}
+}
+
+func TestUpdateName(t *testing.T) {
+	tests := []struct {
+		name     string
+		body     string
+		wantName *users.Name
+	}{
+		{name: "omitted", body: `{"untrusted_metadata": {"theme": "dark"}}`},
+		{name: "set", body: `{"first_name": "Ada", "last_name": "Lovelace"}`, wantName: &users.Name{FirstName: "Ada", LastName: "Lovelace"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{}
+			rec, _, _ := serve(t, f, nil, func(c *Controller) http.HandlerFunc { return c.Update }, tt.body, false)
+			if rec.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+			}
+			name := f.updateParams.Name
+			if (name == nil) != (tt.wantName == nil) || name != nil && *name != *tt.wantName {
+				t.Errorf("name = %+v, want %+v", name, tt.wantName)
+			}
+		})
+	}
+}
+
+func TestDeleteFactor(t *testing.T) {
+	tests := []struct {
+		name           string
+		body           string
+		wantStatus     int
+		wantErrorType  string
+		wantCalls      []string
+		wantGoogleKept bool
+	}{
+		{
+			name:           "phone number",
+			body:           `{"factor_type": "phone_number", "factor_id": "phone-1"}`,
+			wantStatus:     http.StatusOK,
+			wantCalls:      []string{"Get user-1", "DeletePhoneNumber phone-1"},
+			wantGoogleKept: true,
+		},
+		{
+			name:           "passkey",
+			body:           `{"factor_type": "webauthn_registration", "factor_id": "registration-1"}`,
+			wantStatus:     http.StatusOK,
+			wantCalls:      []string{"Get user-1", "DeleteWebAuthnRegistration registration-1"},
+			wantGoogleKept: true,
+		},
+		{
+			name:           "authenticator app",
+			body:           `{"factor_type": "totp", "factor_id": "totp-1"}`,
+			wantStatus:     http.StatusOK,
+			wantCalls:      []string{"Get user-1", "DeleteTOTP totp-1"},
+			wantGoogleKept: true,
+		},
+		{
+			name:       "OAuth provider",
+			body:       `{"factor_type": "oauth_registration", "factor_id": "oauth-1"}`,
+			wantStatus: http.StatusOK,
+			wantCalls:  []string{"Get user-1", "DeleteOAuthRegistration oauth-1"},
+		},
+		{
+			name:           "password",
+			body:           `{"factor_type": "password", "factor_id": "password-1"}`,
+			wantStatus:     http.StatusOK,
+			wantCalls:      []string{"Get user-1", "DeletePassword password-1"},
+			wantGoogleKept: true,
+		},
+		{
+			name:           "factor of another user",
+			body:           `{"factor_type": "email", "factor_id": "email-of-user-2"}`,
+			wantStatus:     http.StatusNotFound,
+			wantErrorType:  internal.ErrorTypeNotFound,
+			wantCalls:      []string{"Get user-1"},
+			wantGoogleKept: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{}
+			providerTokens := internal.NewProviderTokenStore("", []byte("0123456789abcdef0123456789abcdef"))
+			if err := providerTokens.Store("user-1", internal.ProviderTokens{Provider: "google", AccessToken: "access-token"}); err != nil {
+				t.Fatal(err)
+			}
+			rec, resp, _ := serve(t, f, providerTokens, func(c *Controller) http.HandlerFunc { return c.DeleteFactor }, tt.body, false)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.Calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", f.Calls, tt.wantCalls)
+			}
+			if _, kept := providerTokens.Get("user-1", "google"); kept != tt.wantGoogleKept {
+				t.Errorf("Google tokens kept = %t, want %t", kept, tt.wantGoogleKept)
+			}
+		})
+	}
+}
+
+func TestDeleteClearsSession(t *testing.T) {
+	f := &fakeAPI{}
+	providerTokens := internal.NewProviderTokenStore("", []byte("0123456789abcdef0123456789abcdef"))
+	if err := providerTokens.Store("user-1", internal.ProviderTokens{Provider: "google", AccessToken: "access-token"}); err != nil {
+		t.Fatal(err)
+	}
+	rec, _, cs := serve(t, f, providerTokens, func(c *Controller) http.HandlerFunc { return c.Delete }, `{"confirm": true}`, false)
+
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusOK, rec.Body)
+	}
+	if len(rec.Result().Cookies()) == 0 {
+		t.Error("session cookie was not cleared")
+	}
+	if st, ok := cs.GetSession(testutil.ResponseCookies(rec)); ok {
+		t.Errorf("session %q still stored", st)
+	}
+	if providers := providerTokens.Providers("user-1"); len(providers) != 0 {
+		t.Errorf("provider tokens of %q kept", providers)
+	}
+}
@@ -0,0 +1,40 @@
+package webauthn
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/stytchapi"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/webauthn"
+)
+
+// API holds the clients of the Stytch Consumer API that the controller uses. Each client is
+// declared as a narrow interface, so that tests can replace it with a fake.
+type API struct {
+	WebAuthn WebAuthnClient
+	Users    UsersClient
+}
+
+// NewAPI returns the clients of the Stytch Consumer API that the controller uses.
+func NewAPI(client *stytchapi.API) API {
+	return API{
+		WebAuthn: client.WebAuthn,
+		Users:    client.Users,
+	}
+}
+
+// WebAuthnClient registers passkeys and authenticates with them.
+// It is implemented by stytchapi.API.WebAuthn.
+type WebAuthnClient interface {
+	RegisterStart(ctx context.Context, body *webauthn.RegisterStartParams) (*webauthn.RegisterStartResponse, error)
+	Register(ctx context.Context, body *webauthn.RegisterParams) (*webauthn.RegisterResponse, error)
+	AuthenticateStart(ctx context.Context, body *webauthn.AuthenticateStartParams) (*webauthn.AuthenticateStartResponse, error)
+	Authenticate(ctx context.Context, body *webauthn.AuthenticateParams) (*webauthn.AuthenticateResponse, error)
+}
+
+// UsersClient reads and deletes the passkeys of users.
+// It is implemented by stytchapi.API.Users.
+type UsersClient interface {
+	Get(ctx context.Context, body *users.GetParams) (*users.GetResponse, error)
+	DeleteWebAuthnRegistration(ctx context.Context, body *users.DeleteWebAuthnRegistrationParams) (*users.DeleteWebAuthnRegistrationResponse, error)
+}
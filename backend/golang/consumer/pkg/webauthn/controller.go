@@ -0,0 +1,20 @@
+package webauthn
+
+import "backend/golang/consumer/pkg/internal"
+
+type Controller struct {
+	api                    API
+	cookieStore            *internal.CookieStore
+	sessionDurationMinutes int32
+
+	// domain is the WebAuthn relying party ID, the domain of the frontend that passkeys
+	// are registered for.
+	domain string
+
+	// redirector decides which frontend page to send the browser to once a flow completes.
+	redirector *internal.Redirector
+}
+
+func NewController(api API, cookieStore *internal.CookieStore, sessionDurationMinutes int32, domain string, redirector *internal.Redirector) *Controller {
+	return &Controller{api, cookieStore, sessionDurationMinutes, domain, redirector}
+}
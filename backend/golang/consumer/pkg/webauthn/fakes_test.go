@@ -0,0 +1,84 @@
+package webauthn
+
+import (
+	"context"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/webauthn"
+
+	"backend/golang/consumer/pkg/internal/testutil"
+)
+
+// fakeAPI implements every client of API in memory for a user with one passkey,
+// registration-1. Each call records the user, domain or session token it was made with, and
+// fails with Err when it is set.
+type fakeAPI struct {
+	testutil.Fake
+
+	registerStartParams     *webauthn.RegisterStartParams
+	authenticateStartParams *webauthn.AuthenticateStartParams
+}
+
+func (f *fakeAPI) api() API {
+	return API{WebAuthn: f, Users: f}
+}
+
+func (f *fakeAPI) RegisterStart(_ context.Context, body *webauthn.RegisterStartParams) (*webauthn.RegisterStartResponse, error) {
+	f.registerStartParams = body
+	if err := f.Record("RegisterStart " + body.UserID + " " + body.Domain + " " + body.AuthenticatorType); err != nil {
+		return nil, err
+	}
+	return &webauthn.RegisterStartResponse{UserID: body.UserID, PublicKeyCredentialCreationOptions: "{}", StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Register(_ context.Context, body *webauthn.RegisterParams) (*webauthn.RegisterResponse, error) {
+	if err := f.Record("Register " + body.UserID + " " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &webauthn.RegisterResponse{
+		UserID:                 body.UserID,
+		WebAuthnRegistrationID: "registration-2",
+		SessionToken:           "new-session-token",
+		SessionJWT:             "new-session-jwt",
+		StatusCode:             200,
+	}, nil
+}
+
+func (f *fakeAPI) AuthenticateStart(_ context.Context, body *webauthn.AuthenticateStartParams) (*webauthn.AuthenticateStartResponse, error) {
+	f.authenticateStartParams = body
+	if err := f.Record("AuthenticateStart " + body.Domain); err != nil {
+		return nil, err
+	}
+	return &webauthn.AuthenticateStartResponse{PublicKeyCredentialRequestOptions: "{}", StatusCode: 200}, nil
+}
+
+func (f *fakeAPI) Authenticate(_ context.Context, body *webauthn.AuthenticateParams) (*webauthn.AuthenticateResponse, error) {
+	if err := f.Record("Authenticate " + body.SessionToken); err != nil {
+		return nil, err
+	}
+	return &webauthn.AuthenticateResponse{
+		UserID:                 "user-1",
+		WebAuthnRegistrationID: "registration-1",
+		SessionToken:           "new-session-token",
+		SessionJWT:             "new-session-jwt",
+		StatusCode:             200,
+	}, nil
+}
+
+func (f *fakeAPI) Get(_ context.Context, body *users.GetParams) (*users.GetResponse, error) {
+	if err := f.Record("Users.Get " + body.UserID); err != nil {
+		return nil, err
+	}
+	return &users.GetResponse{
+		UserID:                body.UserID,
+		WebAuthnRegistrations: []users.WebAuthnRegistration{{WebAuthnRegistrationID: "registration-1", Domain: "localhost", Verified: true}},
+		StatusCode:            200,
+	}, nil
+}
+
+func (f *fakeAPI) DeleteWebAuthnRegistration(_ context.Context, body *users.DeleteWebAuthnRegistrationParams) (*users.DeleteWebAuthnRegistrationResponse, error) {
+	if err := f.Record("Users.DeleteWebAuthnRegistration " + body.WebAuthnRegistrationID); err != nil {
+		return nil, err
+	}
+	return &users.DeleteWebAuthnRegistrationResponse{StatusCode: 200}, nil
+}
@@ -0,0 +1,53 @@
+package webauthn
+
+import (
+	"net/http"
+
+	"backend/golang/consumer/pkg/router"
+)
+
+// Routes returns the WebAuthn routes.
+func (c *Controller) Routes() []router.Route {
+	return []router.Route{
+		{
+			Method:  http.MethodPost,
+			Path:    "/webauthn/register/start",
+			Summary: "Start registering a passkey for the signed-in user",
+			Handler: c.RegisterStart,
+			Request: registerStartRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/webauthn/register",
+			Summary: "Complete registering a passkey and store the session",
+			Handler: c.Register,
+			Request: credentialRequest{},
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/webauthn/authenticate/start",
+			Summary: "Start logging in with a passkey",
+			Handler: c.AuthenticateStart,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/webauthn/authenticate",
+			Summary: "Complete logging in with a passkey and store the session",
+			Handler: c.Authenticate,
+			Request: credentialRequest{},
+		},
+		{
+			Method:  http.MethodGet,
+			Path:    "/webauthn/registrations",
+			Summary: "List the passkeys of the signed-in user",
+			Handler: c.ListRegistrations,
+		},
+		{
+			Method:  http.MethodPost,
+			Path:    "/webauthn/registrations/delete",
+			Summary: "Delete a passkey of the signed-in user",
+			Handler: c.DeleteRegistration,
+			Request: deleteRegistrationRequest{},
+		},
+	}
+}
@@ -0,0 +1,292 @@
+package webauthn
+
+import (
+	"net/http"
+	"slices"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/sessions"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/users"
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/webauthn"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/mfa"
+)
+
+const (
+	registerStartMethod      = "WebAuthn.RegisterStart"
+	registerMethod           = "WebAuthn.Register"
+	authenticateStartMethod  = "WebAuthn.AuthenticateStart"
+	authenticateMethod       = "WebAuthn.Authenticate"
+	listRegistrationsMethod  = "WebAuthn.ListRegistrations"
+	deleteRegistrationMethod = "Users.DeleteWebAuthnRegistration"
+)
+
+var errRegistrationNotFound = internal.NewError(http.StatusNotFound, internal.ErrorTypeNotFound,
+	"The signed-in user has no passkey with this ID")
+
+type registerStartRequest struct {
+	// AuthenticatorType optionally restricts the passkey to the device's built-in
+	// authenticator ("platform") or to security keys ("cross-platform").
+	AuthenticatorType string `json:"authenticator_type" validate:"omitempty,oneof=platform cross-platform"`
+}
+
+// RegisterStart wraps Stytch's WebAuthn Register Start endpoint and returns the options that
+// the frontend passes to navigator.credentials.create() to create a passkey for the
+// signed-in user. Stytch encodes the binary fields of the options in standard base64.
+func (c *Controller) RegisterStart(w http.ResponseWriter, r *http.Request) {
+	var req registerStartRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, registerStartMethod, err)
+		return
+	}
+
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, registerStartMethod, err)
+		return
+	}
+
+	resp, err := c.api.WebAuthn.RegisterStart(r.Context(), &webauthn.RegisterStartParams{
+		UserID:                         session.User.UserID,
+		Domain:                         c.domain,
+		UserAgent:                      r.UserAgent(),
+		AuthenticatorType:              req.AuthenticatorType,
+		ReturnPasskeyCredentialOptions: true,
+	})
+	if err != nil {
+		internal.SendError(w, registerStartMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      registerStartMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.WebAuthn.RegisterStart(
+	r.Context(),
+	&webauthn.RegisterStartParams{
+		UserID:                         session.User.UserID,
+		Domain:                         c.domain,
+		UserAgent:                      r.UserAgent(),
+		AuthenticatorType:              req.AuthenticatorType,
+		ReturnPasskeyCredentialOptions: true,
+	},
+)`,
+	})
+}
+
+type credentialRequest struct {
+	// PublicKeyCredential is the JSON serialization of the credential returned by
+	// navigator.credentials.create() or navigator.credentials.get().
+	PublicKeyCredential string `json:"public_key_credential" validate:"required,max=32768"`
+}
+
+// Register wraps Stytch's WebAuthn Register endpoint and saves the passkey created by the
+// browser. The passkey is added to the session as another factor, and the session is
+// stored in a cookie.
+func (c *Controller) Register(w http.ResponseWriter, r *http.Request) {
+	var req credentialRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, registerMethod, err)
+		return
+	}
+
+	st, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, registerMethod, err)
+		return
+	}
+
+	resp, err := c.api.WebAuthn.Register(r.Context(), &webauthn.RegisterParams{
+		UserID:                 session.User.UserID,
+		PublicKeyCredential:    req.PublicKeyCredential,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, registerMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      registerMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.WebAuthn.Register(
+	r.Context(),
+	&webauthn.RegisterParams{
+		UserID:                 session.User.UserID,
+		PublicKeyCredential:    req.PublicKeyCredential,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+	})
+}
+
+// AuthenticateStart wraps Stytch's WebAuthn Authenticate Start endpoint and returns the
+// options that the frontend passes to navigator.credentials.get(). No user is named, so the
+// browser offers every passkey that is registered for the domain.
+func (c *Controller) AuthenticateStart(w http.ResponseWriter, r *http.Request) {
+	resp, err := c.api.WebAuthn.AuthenticateStart(r.Context(), &webauthn.AuthenticateStartParams{
+		Domain:                         c.domain,
+		ReturnPasskeyCredentialOptions: true,
+	})
+	if err != nil {
+		internal.SendError(w, authenticateStartMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      authenticateStartMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.WebAuthn.AuthenticateStart(
+	r.Context(),
+	&webauthn.AuthenticateStartParams{
+		Domain:                         c.domain,
+		ReturnPasskeyCredentialOptions: true,
+	},
+)`,
+	})
+}
+
+// Authenticate wraps Stytch's WebAuthn Authenticate endpoint and logs the user in with the
+// passkey assertion returned by the browser. When the request carries a session, the
+// passkey is added to it as another factor. Either way, the resulting session is stored in
+// a cookie.
+func (c *Controller) Authenticate(w http.ResponseWriter, r *http.Request) {
+	var req credentialRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	// An existing session is optional, so a missing cookie simply starts a new session.
+	st, _ := c.cookieStore.GetSession(r)
+
+	resp, err := c.api.WebAuthn.Authenticate(r.Context(), &webauthn.AuthenticateParams{
+		PublicKeyCredential:    req.PublicKeyCredential,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	})
+	if err != nil {
+		internal.SendError(w, authenticateMethod, err)
+		return
+	}
+
+	c.cookieStore.StoreSession(w, r, resp.SessionToken, resp.SessionJWT)
+	redirectURL := c.redirector.Next(w, r, true)
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      authenticateMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.WebAuthn.Authenticate(
+	r.Context(),
+	&webauthn.AuthenticateParams{
+		PublicKeyCredential:    req.PublicKeyCredential,
+		SessionToken:           st,
+		SessionDurationMinutes: c.sessionDurationMinutes,
+	},
+)`,
+		Metadata: map[string]any{
+			"redirectURL": redirectURL,
+		},
+	})
+}
+
+// ListRegistrations wraps Stytch's Get User endpoint and returns the passkeys of the
+// signed-in user.
+func (c *Controller) ListRegistrations(w http.ResponseWriter, r *http.Request) {
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, listRegistrationsMethod, err)
+		return
+	}
+
+	user, err := c.api.Users.Get(r.Context(), &users.GetParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, listRegistrationsMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      listRegistrationsMethod,
+		APIResponse: user.WebAuthnRegistrations,
+		CodeSnippet: `user, err := c.api.Users.Get(
+	r.Context(),
+	&users.GetParams{
+		UserID: session.User.UserID,
+	},
+)
+
+registrations := user.WebAuthnRegistrations`,
+	})
+}
+
+type deleteRegistrationRequest struct {
+	WebAuthnRegistrationID string `json:"webauthn_registration_id" validate:"required"`
+}
+
+// DeleteRegistration wraps Stytch's Delete WebAuthn Registration endpoint and deletes one of
+// the signed-in user's passkeys. Stytch deletes registrations by ID alone, so the ID is
+// checked against the user's own passkeys first.
+func (c *Controller) DeleteRegistration(w http.ResponseWriter, r *http.Request) {
+	var req deleteRegistrationRequest
+	if err := internal.DecodeJSON(r, &req); err != nil {
+		internal.SendError(w, deleteRegistrationMethod, err)
+		return
+	}
+
+	_, session, err := c.currentSession(r)
+	if err != nil {
+		internal.SendError(w, deleteRegistrationMethod, err)
+		return
+	}
+
+	user, err := c.api.Users.Get(r.Context(), &users.GetParams{
+		UserID: session.User.UserID,
+	})
+	if err != nil {
+		internal.SendError(w, deleteRegistrationMethod, err)
+		return
+	}
+
+	owned := slices.ContainsFunc(user.WebAuthnRegistrations, func(reg users.WebAuthnRegistration) bool {
+		return reg.WebAuthnRegistrationID == req.WebAuthnRegistrationID
+	})
+	if !owned {
+		internal.SendError(w, deleteRegistrationMethod, errRegistrationNotFound)
+		return
+	}
+
+	resp, err := c.api.Users.DeleteWebAuthnRegistration(r.Context(), &users.DeleteWebAuthnRegistrationParams{
+		WebAuthnRegistrationID: req.WebAuthnRegistrationID,
+	})
+	if err != nil {
+		internal.SendError(w, deleteRegistrationMethod, err)
+		return
+	}
+
+	internal.SendResponse(w, &internal.Response{
+		Method:      deleteRegistrationMethod,
+		APIResponse: resp,
+		CodeSnippet: `resp, err := c.api.Users.DeleteWebAuthnRegistration(
+	r.Context(),
+	&users.DeleteWebAuthnRegistrationParams{
+		WebAuthnRegistrationID: req.WebAuthnRegistrationID,
+	},
+)`,
+	})
+}
+
+// currentSession returns the session token and the session that the session middleware
+// authenticated and attached to the request context.
+func (c *Controller) currentSession(r *http.Request) (string, *sessions.AuthenticateResponse, error) {
+	st, session, ok := mfa.FromContext(r.Context())
+	if !ok {
+		return "", nil, internal.ErrNoSession
+	}
+	return st, session, nil
+}
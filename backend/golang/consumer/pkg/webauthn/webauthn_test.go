@@ -0,0 +1,270 @@
+package webauthn
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/stytchauth/stytch-go/v16/stytch/consumer/webauthn"
+
+	"backend/golang/consumer/pkg/internal"
+	"backend/golang/consumer/pkg/internal/testutil"
+	"backend/golang/consumer/pkg/stytchtest"
+)
+
+func TestHandlers(t *testing.T) {
+	handlers := []struct {
+		name    string
+		handler func(*Controller) http.HandlerFunc
+		method  string
+		body    string
+		// badBody is "" for handlers that read no body.
+		badBody string
+		// sessionOptional is set for handlers that do not require a session.
+		sessionOptional bool
+		wantCalls       []string
+		// wantSession is set for handlers that add a factor to the session and store it.
+		wantSession bool
+	}{
+		{
+			name:      "RegisterStart",
+			handler:   func(c *Controller) http.HandlerFunc { return c.RegisterStart },
+			method:    registerStartMethod,
+			body:      `{"authenticator_type": "platform"}`,
+			badBody:   `{"authenticator_type": "usb"}`,
+			wantCalls: []string{"RegisterStart user-1 localhost platform"},
+		},
+		{
+			name:        "Register",
+			handler:     func(c *Controller) http.HandlerFunc { return c.Register },
+			method:      registerMethod,
+			body:        `{"public_key_credential": "{}"}`,
+			badBody:     `{}`,
+			wantCalls:   []string{"Register user-1 session-token"},
+			wantSession: true,
+		},
+		{
+			name:            "AuthenticateStart",
+			handler:         func(c *Controller) http.HandlerFunc { return c.AuthenticateStart },
+			method:          authenticateStartMethod,
+			sessionOptional: true,
+			wantCalls:       []string{"AuthenticateStart localhost"},
+		},
+		{
+			name:      "ListRegistrations",
+			handler:   func(c *Controller) http.HandlerFunc { return c.ListRegistrations },
+			method:    listRegistrationsMethod,
+			wantCalls: []string{"Users.Get user-1"},
+		},
+		{
+			name:      "DeleteRegistration",
+			handler:   func(c *Controller) http.HandlerFunc { return c.DeleteRegistration },
+			method:    deleteRegistrationMethod,
+			body:      `{"webauthn_registration_id": "registration-1"}`,
+			badBody:   `{"webauthn_registration_id": ""}`,
+			wantCalls: []string{"Users.Get user-1", "Users.DeleteWebAuthnRegistration registration-1"},
+		},
+	}
+	tests := []struct {
+		name          string
+		badBody       bool
+		noSession     bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+	}{
+		{
+			name:       "success",
+			wantStatus: http.StatusOK,
+		},
+		{
+			name:          "API error",
+			apiErr:        testutil.StytchError(http.StatusBadRequest, "invalid_public_key_credential"),
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: "invalid_public_key_credential",
+		},
+		{
+			name:          "no session",
+			noSession:     true,
+			wantStatus:    http.StatusUnauthorized,
+			wantErrorType: internal.ErrorTypeUnauthorized,
+		},
+		{
+			name:          "bad body",
+			badBody:       true,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, h := range handlers {
+		for _, tt := range tests {
+			if tt.badBody && h.badBody == "" || tt.noSession && h.sessionOptional {
+				continue
+			}
+			t.Run(h.name+"/"+tt.name, func(t *testing.T) {
+				body := h.body
+				if tt.badBody {
+					body = h.badBody
+				}
+				f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+				env := testutil.NewEnv(t)
+				c := NewController(f.api(), env.CookieStore, 60, "localhost", env.Redirector)
+				r := testutil.NewRequest(http.MethodPost, "/webauthn", body)
+				if !tt.noSession {
+					r = stytchtest.WithUserSession(r)
+				}
+				rec := httptest.NewRecorder()
+				h.handler(c)(rec, r)
+
+				if rec.Code != tt.wantStatus {
+					t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+				}
+				resp := testutil.Decode(t, rec)
+				if resp.Method != h.method {
+					t.Errorf("method = %q, want %q", resp.Method, h.method)
+				}
+				if resp.ErrorType() != tt.wantErrorType {
+					t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+				}
+				var wantCalls []string
+				if tt.wantStatus == http.StatusOK {
+					wantCalls = h.wantCalls
+				} else if tt.apiErr != nil {
+					// The first call fails and stops the handler.
+					wantCalls = h.wantCalls[:1]
+				}
+				if !slices.Equal(f.Calls, wantCalls) {
+					t.Errorf("calls = %q, want %q", f.Calls, wantCalls)
+				}
+				if tt.wantStatus != http.StatusOK || !h.wantSession {
+					return
+				}
+				if st, _ := env.CookieStore.GetSession(testutil.ResponseCookies(rec)); st != "new-session-token" {
+					t.Errorf("stored session = %q, want %q", st, "new-session-token")
+				}
+			})
+		}
+	}
+}
+
+func TestStartParams(t *testing.T) {
+	f := &fakeAPI{}
+	env := testutil.NewEnv(t)
+	c := NewController(f.api(), env.CookieStore, 60, "localhost", env.Redirector)
+
+	r := stytchtest.WithUserSession(testutil.NewRequest(http.MethodPost, "/webauthn/register/start", `{"authenticator_type": "cross-platform"}`))
+	r.Header.Set("User-Agent", "Browser")
+	c.RegisterStart(httptest.NewRecorder(), r)
+	wantRegister := webauthn.RegisterStartParams{
+		UserID:                         "user-1",
+		Domain:                         "localhost",
+		UserAgent:                      "Browser",
+		AuthenticatorType:              "cross-platform",
+		ReturnPasskeyCredentialOptions: true,
+	}
+	if f.registerStartParams == nil || *f.registerStartParams != wantRegister {
+		t.Errorf("RegisterStart() params = %+v, want %+v", f.registerStartParams, wantRegister)
+	}
+
+	c.AuthenticateStart(httptest.NewRecorder(), testutil.NewRequest(http.MethodPost, "/webauthn/authenticate/start", ""))
+	wantAuthenticate := webauthn.AuthenticateStartParams{
+		Domain:                         "localhost",
+		ReturnPasskeyCredentialOptions: true,
+	}
+	if f.authenticateStartParams == nil || *f.authenticateStartParams != wantAuthenticate {
+		t.Errorf("AuthenticateStart() params = %+v, want %+v", f.authenticateStartParams, wantAuthenticate)
+	}
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name          string
+		body          string
+		cookie        bool
+		apiErr        error
+		wantStatus    int
+		wantErrorType string
+		wantCalls     []string
+	}{
+		{
+			name:       "new session",
+			body:       `{"public_key_credential": "{}"}`,
+			wantStatus: http.StatusOK,
+			wantCalls:  []string{"Authenticate "},
+		},
+		{
+			name:       "existing session",
+			body:       `{"public_key_credential": "{}"}`,
+			cookie:     true,
+			wantStatus: http.StatusOK,
+			wantCalls:  []string{"Authenticate session-token"},
+		},
+		{
+			name:          "API error",
+			body:          `{"public_key_credential": "{}"}`,
+			apiErr:        testutil.StytchError(http.StatusNotFound, "webauthn_registration_not_found"),
+			wantStatus:    http.StatusNotFound,
+			wantErrorType: "webauthn_registration_not_found",
+			wantCalls:     []string{"Authenticate "},
+		},
+		{
+			name:          "bad body",
+			body:          `{"public_key_credential": ""}`,
+			wantStatus:    http.StatusBadRequest,
+			wantErrorType: internal.ErrorTypeInvalidRequest,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := &fakeAPI{Fake: testutil.Fake{Err: tt.apiErr}}
+			env := testutil.NewEnv(t)
+			c := NewController(f.api(), env.CookieStore, 60, "localhost", env.Redirector)
+			r := testutil.NewRequest(http.MethodPost, "/webauthn/authenticate", tt.body)
+			if tt.cookie {
+				testutil.AddSession(r, env.CookieStore)
+			}
+			rec := httptest.NewRecorder()
+			c.Authenticate(rec, r)
+
+			if rec.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", rec.Code, tt.wantStatus, rec.Body)
+			}
+			resp := testutil.Decode(t, rec)
+			if resp.ErrorType() != tt.wantErrorType {
+				t.Errorf("error type = %q, want %q", resp.ErrorType(), tt.wantErrorType)
+			}
+			if !slices.Equal(f.Calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", f.Calls, tt.wantCalls)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+			if st, _ := env.CookieStore.GetSession(testutil.ResponseCookies(rec)); st != "new-session-token" {
+				t.Errorf("stored session = %q, want %q", st, "new-session-token")
+			}
+			if got := resp.Metadata["redirectURL"]; got != testutil.SuccessURL {
+				t.Errorf("redirectURL = %v, want %q", got, testutil.SuccessURL)
+			}
+		})
+	}
+}
+
+func TestDeleteRegistrationOfAnotherUser(t *testing.T) {
+	f := &fakeAPI{}
+	env := testutil.NewEnv(t)
+	c := NewController(f.api(), env.CookieStore, 60, "localhost", env.Redirector)
+	rec := httptest.NewRecorder()
+	c.DeleteRegistration(rec, stytchtest.WithUserSession(testutil.NewRequest(http.MethodPost, "/webauthn/registrations/delete",
+		`{"webauthn_registration_id": "registration-of-user-2"}`)))
+
+	if rec.Code != http.StatusNotFound {
+		t.Fatalf("status = %d, want %d: %s", rec.Code, http.StatusNotFound, rec.Body)
+	}
+	if resp := testutil.Decode(t, rec); resp.ErrorType() != internal.ErrorTypeNotFound {
+		t.Errorf("error type = %q, want %q", resp.ErrorType(), internal.ErrorTypeNotFound)
+	}
+	if want := []string{"Users.Get user-1"}; !slices.Equal(f.Calls, want) {
+		t.Errorf("calls = %q, want %q", f.Calls, want)
+	}
+}
@@ -23,12 +23,56 @@ type BaseResponse = {
   status_code: number;
 };
 
+// csrfToken caches the CSRF token of the current session, which the backend
+// checks on every state-changing request.
+let csrfToken: string | undefined;
+
+// getCSRFToken returns the CSRF token of the current session, fetching it from
+// the backend the first time it is needed.
+const getCSRFToken = async (): Promise<string> => {
+  if (!csrfToken) {
+    const response = await fetch("http://localhost:3000/csrf-token", {
+      method: "GET",
+      credentials: "include",
+    });
+    const body: { csrfToken: string } = await response.json();
+    csrfToken = body.csrfToken;
+  }
+  return csrfToken;
+};
+
+// fetchWithCSRF sends a state-changing request with the CSRF token in the
+// X-CSRF-Token header. The backend replaces the token when the user logs in or
+// out, so a request that fails the CSRF check is retried once with a fresh
+// token.
+const fetchWithCSRF = async (
+  url: string,
+  init: RequestInit & { headers?: Record<string, string> }
+): Promise<Response> => {
+  const send = async () =>
+    fetch(url, {
+      ...init,
+      headers: { ...init.headers, "X-CSRF-Token": await getCSRFToken() },
+    });
+
+  const response = await send();
+  if (response.status !== 403) {
+    return response;
+  }
+  const body: { method?: string } = await response.clone().json();
+  if (body.method !== "CSRF.Verify") {
+    return response;
+  }
+  csrfToken = undefined;
+  return await send();
+};
+
 type SendDiscoveryEmailResponse = BaseResponse;
 
 export const sendDiscoveryEmail = async (
   email: string
 ): Promise<APIResponse<SendDiscoveryEmailResponse>> => {
-  const response = await fetch(
+  const response = await fetchWithCSRF(
     "http://localhost:3000/magic_links/email/discovery/send",
     {
       headers: { "Content-Type": "application/json" },
@@ -81,7 +125,7 @@ type CreateOrganizationViaDiscoveryResponse = BaseResponse & {
 export const createOrganizationViaDiscovery = async (
   orgName: string
 ): Promise<APIResponse<CreateOrganizationViaDiscoveryResponse>> => {
-  const response = await fetch(
+  const response = await fetchWithCSRF(
     "http://localhost:3000/discovery/organizations/create",
     {
       headers: { "Content-Type": "application/json" },
@@ -111,12 +155,15 @@ type ExchangeSessionResponse = BaseResponse & {
 export const exchangeSession = async (
   orgId: string
 ): Promise<APIResponse<ExchangeSessionResponse>> => {
-  const response = await fetch("http://localhost:3000/sessions/exchange", {
-    headers: { "Content-Type": "application/json" },
-    method: "POST",
-    body: JSON.stringify({ organization_id: orgId }),
-    credentials: "include",
-  });
+  const response = await fetchWithCSRF(
+    "http://localhost:3000/sessions/exchange",
+    {
+      headers: { "Content-Type": "application/json" },
+      method: "POST",
+      body: JSON.stringify({ organization_id: orgId }),
+      credentials: "include",
+    }
+  );
 
   return await response.json();
 };
@@ -146,9 +193,12 @@ export const getCurrentSession = async (): Promise<
 };
 
 export const logout = async (): Promise<APIResponse<BaseResponse>> => {
-  const response = await fetch(`http://localhost:3000/logout`, {
+  const response = await fetchWithCSRF(`http://localhost:3000/logout`, {
+    method: "POST",
     credentials: "include",
   });
+  // The backend issues a new CSRF token once the session is gone.
+  csrfToken = undefined;
 
   return await response.json();
 };
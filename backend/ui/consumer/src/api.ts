@@ -11,25 +11,72 @@ type BaseResponse = {
   status_code: number;
 };
 
+// csrfToken caches the CSRF token of the current session, which the backend
+// checks on every state-changing request.
+let csrfToken: string | undefined;
+
+// getCSRFToken returns the CSRF token of the current session, fetching it from
+// the backend the first time it is needed.
+const getCSRFToken = async (): Promise<string> => {
+  if (!csrfToken) {
+    const response = await fetch("http://localhost:3000/csrf-token", {
+      method: "GET",
+      credentials: "include",
+    });
+    const body: { csrfToken: string } = await response.json();
+    csrfToken = body.csrfToken;
+  }
+  return csrfToken;
+};
+
+// fetchWithCSRF sends a state-changing request with the CSRF token in the
+// X-CSRF-Token header. The backend replaces the token when the user logs in or
+// out, so a request that fails the CSRF check is retried once with a fresh
+// token.
+const fetchWithCSRF = async (
+  url: string,
+  init: RequestInit & { headers?: Record<string, string> }
+): Promise<Response> => {
+  const send = async () =>
+    fetch(url, {
+      ...init,
+      headers: { ...init.headers, "X-CSRF-Token": await getCSRFToken() },
+    });
+
+  const response = await send();
+  if (response.status !== 403) {
+    return response;
+  }
+  const body: { method?: string } = await response.clone().json();
+  if (body.method !== "CSRF.Verify") {
+    return response;
+  }
+  csrfToken = undefined;
+  return await send();
+};
+
 type SendMagicLinkEmailResponse = BaseResponse;
 
 export const sendMagicLinkEmail = async (
   email: string
 ): Promise<APIResponse<SendMagicLinkEmailResponse>> => {
-  const response = await fetch("http://localhost:3000/magic_links/email/send", {
-    method: "POST",
-    headers: {
-      "Content-Type": "application/json",
-    },
-    body: JSON.stringify({
-      email_address: email,
-      login_magic_link_url: "http://localhost:3000/authenticate",
-      login_expiration_minutes: 60,
-      signup_magic_link_url: "http://localhost:3000/authenticate",
-      signup_expiration_minutes: 60,
-    }),
-    credentials: "include",
-  });
+  const response = await fetchWithCSRF(
+    "http://localhost:3000/magic_links/email/send",
+    {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({
+        email_address: email,
+        login_magic_link_url: "http://localhost:3000/authenticate",
+        login_expiration_minutes: 60,
+        signup_magic_link_url: "http://localhost:3000/authenticate",
+        signup_expiration_minutes: 60,
+      }),
+      credentials: "include",
+    }
+  );
 
   return await response.json();
 };
@@ -45,7 +92,7 @@ type AuthenticateMagicLinkResponse = BaseResponse & {
 export const authenticateMagicLink = async (
   token: string
 ): Promise<APIResponse<AuthenticateMagicLinkResponse>> => {
-  const response = await fetch(
+  const response = await fetchWithCSRF(
     "http://localhost:3000/magic_links/authenticate",
     {
       method: "POST",
@@ -71,14 +118,17 @@ type AuthenticateOAuthResponse = BaseResponse & {
 export const authenticateOAuth = async (
   token: string
 ): Promise<APIResponse<AuthenticateOAuthResponse>> => {
-  const response = await fetch("http://localhost:3000/oauth/authenticate", {
-    method: "POST",
-    headers: {
-      "Content-Type": "application/json",
-    },
-    body: JSON.stringify({ token }),
-    credentials: "include",
-  });
+  const response = await fetchWithCSRF(
+    "http://localhost:3000/oauth/authenticate",
+    {
+      method: "POST",
+      headers: {
+        "Content-Type": "application/json",
+      },
+      body: JSON.stringify({ token }),
+      credentials: "include",
+    }
+  );
 
   return await response.json();
 };
@@ -102,9 +152,12 @@ export const getCurrentSession = async (): Promise<
 };
 
 export const logout = async (): Promise<APIResponse<BaseResponse>> => {
-  const response = await fetch(`http://localhost:3000/logout`, {
+  const response = await fetchWithCSRF(`http://localhost:3000/logout`, {
+    method: "POST",
     credentials: "include",
   });
+  // The backend issues a new CSRF token once the session is gone.
+  csrfToken = undefined;
 
   return await response.json();
 };
@@ -33,23 +33,10 @@ export function Login() {
     }
   };
 
-  const handleGoogleLogin = async () => {
-    const startUrl = new URL(
-      "https://test.stytch.com/v1/public/oauth/google/start"
-    );
-    startUrl.searchParams.set(
-      "public_token",
-      import.meta.env.VITE_STYTCH_PUBLIC_TOKEN ?? ""
-    );
-    startUrl.searchParams.set(
-      "login_redirect_url",
-      "http://localhost:3000/authenticate"
-    );
-    startUrl.searchParams.set(
-      "signup_redirect_url",
-      "http://localhost:3000/authenticate"
-    );
-    window.location.href = startUrl.toString();
+  const handleGoogleLogin = () => {
+    // The backend starts the flow so that it can bind it to this browser with
+    // a state cookie and a PKCE code verifier.
+    window.location.href = "http://localhost:3000/oauth/start/google";
   };
 
   return (